@@ -0,0 +1,295 @@
+// Package analysis works out the structure of a ROM without running it. It decodes instructions from the entry point with the same
+// table the interpreter uses, following jumps, calls and skips to build a control-flow graph of basic blocks. From the graph it finds
+// the subroutines, bytes that can never run, data that is drawn as sprites and calls that can nest deeper than the stack.
+//
+// Only the flow that can be seen in the ROM is followed. BNNN jumps to an address worked out at run time, so the code it reaches is
+// missing from the graph and the jump is listed as unresolved instead.
+package analysis
+
+import (
+	"chip8/chip8"
+	"sort"
+)
+
+// EdgeKind is how control gets from one block to another
+type EdgeKind int
+
+const (
+	EdgeNext      EdgeKind = iota // Falling through to the next instruction, including when a skip isn't taken
+	EdgeJump                      // 1NNN
+	EdgeSkip                      // A skip that is taken, jumping over the next instruction
+	EdgeCall                      // 2NNN to the subroutine
+	EdgeAfterCall                 // From a 2NNN to the instruction after it, where the subroutine returns to
+	EdgeReturn                    // 00EE back to the instruction after each call of the subroutine
+)
+
+var edgeKindNames = []string{"next", "jump", "skip", "call", "after call", "return"}
+
+func (k EdgeKind) String() string {
+	if k >= 0 && int(k) < len(edgeKindNames) {
+		return edgeKindNames[k]
+	}
+
+	return "unknown"
+}
+
+// Edge leads from a block to another address
+type Edge struct {
+	To   uint16
+	Kind EdgeKind
+}
+
+// Reference is an instruction that jumps or calls to an address
+type Reference struct {
+	From uint16
+	To   uint16
+	Kind EdgeKind
+}
+
+// Decoded is an instruction found in the ROM
+type Decoded struct {
+	Address     uint16
+	Opcode      uint16
+	Instruction *chip8.Instruction
+}
+
+// Block is a run of instructions that always execute together, from the first to the last
+type Block struct {
+	Start        uint16
+	Instructions []Decoded
+	Edges        []Edge
+}
+
+// End is the address after the block
+func (b *Block) End() uint16 {
+	return b.Start + uint16(2*len(b.Instructions))
+}
+
+// Last is the instruction that ends the block
+func (b *Block) Last() Decoded {
+	return b.Instructions[len(b.Instructions)-1]
+}
+
+// Graph is the control-flow graph of a ROM
+type Graph struct {
+	Blocks      map[uint16]*Block
+	Subroutines []uint16    // Entry points of every subroutine that is called, in order
+	Unresolved  []uint16    // Addresses of BNNN jumps, whose target isn't known until they run
+	Invalid     []uint16    // Addresses reached that don't hold a valid instruction
+	Outside     []Reference // Jumps, calls and skips that lead outside the ROM
+
+	rom  []uint8
+	code []bool // Whether each byte of the ROM is part of an instruction that can be reached
+}
+
+// Analyze builds the control-flow graph of a ROM loaded at chip8.ProgramStart
+func Analyze(rom []uint8) *Graph {
+	g := Graph{Blocks: map[uint16]*Block{}, rom: rom, code: make([]bool, len(rom))}
+
+	// Find every reachable instruction and which of them start blocks
+	instructions := map[uint16]Decoded{}
+	leaders := map[uint16]bool{chip8.ProgramStart: true}
+	calls := map[uint16]bool{}
+	invalid := map[uint16]bool{}
+	work := []uint16{chip8.ProgramStart}
+
+	for len(work) > 0 {
+		address := work[len(work)-1]
+		work = work[:len(work)-1]
+		if _, ok := instructions[address]; ok || invalid[address] {
+			continue
+		}
+
+		decoded, ok := g.decode(address)
+		if !ok {
+			invalid[address] = true
+			g.Invalid = append(g.Invalid, address)
+			continue
+		}
+		instructions[address] = decoded
+		g.code[address-chip8.ProgramStart] = true
+		g.code[address-chip8.ProgramStart+1] = true
+
+		edges, ends := successors(decoded)
+		for _, edge := range edges {
+			if edge.Kind == EdgeCall {
+				calls[edge.To] = true
+			}
+			if !g.inROM(edge.To) {
+				g.Outside = append(g.Outside, Reference{From: address, To: edge.To, Kind: edge.Kind})
+				continue
+			}
+			if ends {
+				leaders[edge.To] = true
+			}
+			work = append(work, edge.To)
+		}
+		if decoded.Instruction.Command == chip8.CmdJumpV0Addr {
+			g.Unresolved = append(g.Unresolved, address)
+		}
+	}
+
+	// Split the instructions into blocks, each running from a leader to the next instruction that transfers control
+	for leader := range leaders {
+		if _, ok := instructions[leader]; !ok {
+			continue
+		}
+
+		block := Block{Start: leader}
+		for address := leader; ; address += 2 {
+			decoded, ok := instructions[address]
+			if !ok || (address != leader && leaders[address]) {
+				block.Edges = []Edge{{To: address, Kind: EdgeNext}}
+				break
+			}
+			block.Instructions = append(block.Instructions, decoded)
+
+			if edges, ends := successors(decoded); ends {
+				for _, edge := range edges {
+					if g.inROM(edge.To) {
+						block.Edges = append(block.Edges, edge)
+					}
+				}
+				break
+			}
+		}
+		g.Blocks[leader] = &block
+	}
+
+	for entry := range calls {
+		if _, ok := g.Blocks[entry]; ok {
+			g.Subroutines = append(g.Subroutines, entry)
+		}
+	}
+	sortAddresses(g.Subroutines)
+	sortAddresses(g.Unresolved)
+	sortAddresses(g.Invalid)
+	g.addReturns()
+
+	return &g
+}
+
+// decode reads the instruction at an address, if it is a valid instruction inside the ROM
+func (g *Graph) decode(address uint16) (Decoded, bool) {
+	if !g.inROM(address) || !g.inROM(address+1) {
+		return Decoded{}, false
+	}
+
+	i := address - chip8.ProgramStart
+	opcode := uint16(g.rom[i])<<8 | uint16(g.rom[i+1])
+	instruction, err := chip8.Decode(opcode)
+	if err != nil {
+		return Decoded{}, false
+	}
+
+	return Decoded{Address: address, Opcode: opcode, Instruction: instruction}, true
+}
+
+func (g *Graph) inROM(address uint16) bool {
+	return address >= chip8.ProgramStart && int(address-chip8.ProgramStart) < len(g.rom)
+}
+
+// successors returns where control can go after an instruction, and whether the instruction ends a block
+func successors(decoded Decoded) ([]Edge, bool) {
+	next := decoded.Address + 2
+
+	switch decoded.Instruction.Command {
+	case chip8.CmdJump:
+		return []Edge{{To: decoded.Instruction.Arguments[0], Kind: EdgeJump}}, true
+	case chip8.CmdCallSubRoutine:
+		return []Edge{{To: decoded.Instruction.Arguments[0], Kind: EdgeCall}, {To: next, Kind: EdgeAfterCall}}, true
+	case chip8.CmdReturn, chip8.CmdJumpV0Addr:
+		return nil, true
+	case chip8.CmdSkipIfEqual, chip8.CmdSkipIfNotEqual, chip8.CmdSkipIfEqualRegister, chip8.CmdSkipIfNotEqualRegister,
+		chip8.CmdSkipIfKeyPressed, chip8.CmdSkipIfKeyNotPressed:
+		return []Edge{{To: next, Kind: EdgeNext}, {To: next + 2, Kind: EdgeSkip}}, true
+	}
+
+	return []Edge{{To: next, Kind: EdgeNext}}, false
+}
+
+// Routine returns the blocks of a subroutine, or the main routine at chip8.ProgramStart: every block reachable from its entry
+// without following calls into other subroutines. Blocks shared between routines belong to each of them.
+func (g *Graph) Routine(entry uint16) []*Block {
+	seen := map[uint16]bool{}
+	blocks := []*Block{}
+	work := []uint16{entry}
+
+	for len(work) > 0 {
+		address := work[len(work)-1]
+		work = work[:len(work)-1]
+		block, ok := g.Blocks[address]
+		if !ok || seen[address] {
+			continue
+		}
+		seen[address] = true
+		blocks = append(blocks, block)
+
+		for _, edge := range block.Edges {
+			if edge.Kind != EdgeCall && edge.Kind != EdgeReturn {
+				work = append(work, edge.To)
+			}
+		}
+	}
+
+	sort.Slice(blocks, func(i, j int) bool {
+		return blocks[i].Start < blocks[j].Start
+	})
+
+	return blocks
+}
+
+// addReturns links each RET to the instruction after every call of the subroutine it belongs to
+func (g *Graph) addReturns() {
+	returnSites := map[uint16][]uint16{}
+	for _, block := range g.Blocks {
+		for _, edge := range block.Edges {
+			if edge.Kind == EdgeCall {
+				returnSites[edge.To] = append(returnSites[edge.To], block.Last().Address+2)
+			}
+		}
+	}
+
+	for _, entry := range g.Subroutines {
+		sites := returnSites[entry]
+		sortAddresses(sites)
+
+		for _, block := range g.Routine(entry) {
+			if block.Last().Instruction.Command != chip8.CmdReturn {
+				continue
+			}
+			for _, site := range sites {
+				if !hasEdge(block, site, EdgeReturn) && g.inROM(site) {
+					block.Edges = append(block.Edges, Edge{To: site, Kind: EdgeReturn})
+				}
+			}
+		}
+	}
+}
+
+func hasEdge(block *Block, to uint16, kind EdgeKind) bool {
+	for _, edge := range block.Edges {
+		if edge.To == to && edge.Kind == kind {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Addresses returns the start of every block, in order
+func (g *Graph) Addresses() []uint16 {
+	addresses := []uint16{}
+	for address := range g.Blocks {
+		addresses = append(addresses, address)
+	}
+	sortAddresses(addresses)
+
+	return addresses
+}
+
+func sortAddresses(addresses []uint16) {
+	sort.Slice(addresses, func(i, j int) bool {
+		return addresses[i] < addresses[j]
+	})
+}
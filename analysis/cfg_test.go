@@ -0,0 +1,141 @@
+package analysis
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+var program = []uint8{
+	0x22, 0x0A, // 200: CALL 0x20A
+	0x30, 0x01, // 202: SE V0, 0x01
+	0x12, 0x00, // 204: JP 0x200
+	0xB2, 0x00, // 206: JP V0, 0x200
+	0x00, 0x00, // 208: never reached
+	0xA2, 0x12, // 20A: LD I, 0x212
+	0xD0, 0x13, // 20C: DRW V0, V1, 3
+	0x00, 0xEE, // 20E: RET
+	0xFF, 0xFF, // 210: never reached
+	0x3C, 0x7E, 0x18, // 212: sprite
+}
+
+func TestBlocks(t *testing.T) {
+	g := Analyze(program)
+
+	expected := []uint16{0x200, 0x202, 0x204, 0x206, 0x20A}
+	addresses := g.Addresses()
+	if len(addresses) != len(expected) {
+		t.Fatalf("Blocks were not found correctly. Expected %X, got %X", expected, addresses)
+	}
+	for i := range expected {
+		if addresses[i] != expected[i] {
+			t.Errorf("Blocks were not found correctly. Expected %X, got %X", expected, addresses)
+		}
+	}
+
+	skip := g.Blocks[0x202]
+	if !hasEdge(skip, 0x204, EdgeNext) || !hasEdge(skip, 0x206, EdgeSkip) {
+		t.Errorf("Skip edges were not set correctly. Got %+v", skip.Edges)
+	}
+	if !hasEdge(g.Blocks[0x200], 0x20A, EdgeCall) || !hasEdge(g.Blocks[0x20A], 0x202, EdgeReturn) {
+		t.Errorf("Call and return edges were not set correctly")
+	}
+	if len(g.Blocks[0x20A].Instructions) != 3 {
+		t.Errorf("Subroutine block length was not set correctly. Expected %d, got %d", 3, len(g.Blocks[0x20A].Instructions))
+	}
+}
+
+func TestStartsWithCLS(t *testing.T) {
+	g := Analyze([]uint8{
+		0x00, 0xE0, // 200: CLS
+		0x60, 0x01, // 202: LD V0, 0x01
+		0x12, 0x02, // 204: JP 0x202
+	})
+
+	if len(g.Invalid) != 0 || len(g.Unreachable()) != 0 {
+		t.Errorf("CLS was not decoded correctly. Got invalid %X, unreachable %+v", g.Invalid, g.Unreachable())
+	}
+	if len(g.Blocks) != 2 || !hasEdge(g.Blocks[0x200], 0x202, EdgeNext) {
+		t.Errorf("Blocks were not found correctly. Got %X", g.Addresses())
+	}
+}
+
+func TestSubroutinesAndUnresolved(t *testing.T) {
+	g := Analyze(program)
+
+	if len(g.Subroutines) != 1 || g.Subroutines[0] != 0x20A {
+		t.Errorf("Subroutines were not found correctly. Got %X", g.Subroutines)
+	}
+	if len(g.Unresolved) != 1 || g.Unresolved[0] != 0x206 {
+		t.Errorf("Unresolved jumps were not found correctly. Got %X", g.Unresolved)
+	}
+}
+
+func TestUnreachable(t *testing.T) {
+	ranges := Analyze(program).Unreachable()
+
+	expected := []Range{{0x208, 0x209}, {0x210, 0x214}}
+	if len(ranges) != len(expected) || ranges[0] != expected[0] || ranges[1] != expected[1] {
+		t.Errorf("Unreachable bytes were not found correctly. Expected %+v, got %+v", expected, ranges)
+	}
+}
+
+func TestSprites(t *testing.T) {
+	sprites := Analyze(program).Sprites()
+
+	if len(sprites) != 1 {
+		t.Fatalf("Expected %d sprite, got %+v", 1, sprites)
+	}
+	if sprites[0].Draw != 0x20C || sprites[0].Range != (Range{0x212, 0x214}) {
+		t.Errorf("Sprite was not found correctly. Got %+v", sprites[0])
+	}
+}
+
+func TestSpriteAcrossBlocks(t *testing.T) {
+	g := Analyze([]uint8{
+		0xA2, 0x08, // 200: LD I, 0x208
+		0x30, 0x00, // 202: SE V0, 0x00
+		0xD0, 0x11, // 204: DRW V0, V1, 1
+		0x12, 0x06, // 206: JP 0x206
+		0xFF, // 208: sprite
+	})
+
+	// The draw can be skipped to, but is only reached from one block
+	sprites := g.Sprites()
+	if len(sprites) != 1 || sprites[0].Range != (Range{0x208, 0x208}) {
+		t.Errorf("Sprite was not found correctly. Got %+v", sprites)
+	}
+}
+
+func TestStackOverflows(t *testing.T) {
+	recursive := Analyze([]uint8{
+		0x22, 0x04, // 200: CALL 0x204
+		0x12, 0x02, // 202: JP 0x202
+		0x22, 0x04, // 204: CALL 0x204
+		0x00, 0xEE, // 206: RET
+	})
+
+	overflows := recursive.StackOverflows()
+	if len(overflows) != 1 || overflows[0].Call != 0x204 || len(overflows[0].Chain) != StackSize {
+		t.Errorf("Recursion was not found correctly. Got %+v", overflows)
+	}
+
+	if overflows := Analyze(program).StackOverflows(); len(overflows) != 0 {
+		t.Errorf("Expected no overflows, got %+v", overflows)
+	}
+}
+
+func TestWriteDOT(t *testing.T) {
+	var b bytes.Buffer
+	if err := Analyze(program).WriteDOT(&b, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	dot := b.String()
+	for _, expected := range []string{"digraph rom {", "b200 -> b20A [color=purple, style=bold];", "b202 -> b206 [color=darkgreen, label=skip];",
+		"peripheries=2", "color=red"} {
+		if !strings.Contains(dot, expected) {
+			t.Errorf("DOT output is missing %q:\n%s", expected, dot)
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package analysis
+
+import (
+	"chip8/chip8"
+	"chip8/symbols"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// edgeStyles are the Graphviz attributes for each kind of edge
+var edgeStyles = map[EdgeKind]string{
+	EdgeNext:      "",
+	EdgeJump:      "color=blue",
+	EdgeSkip:      "color=darkgreen, label=skip",
+	EdgeCall:      "color=purple, style=bold",
+	EdgeAfterCall: "style=dotted",
+	EdgeReturn:    "color=gray, style=dashed",
+}
+
+// WriteDOT writes the graph in Graphviz DOT format, with a box for each block listing its instructions. Subroutine entries are
+// double boxes and blocks ending in an unresolved BNNN jump are red. The symbol table may be nil.
+func (g *Graph) WriteDOT(w io.Writer, table *symbols.Table) error {
+	var b strings.Builder
+	b.WriteString("digraph rom {\n")
+	b.WriteString("\tnode [shape=box, fontname=monospace];\n")
+
+	subroutines := map[uint16]bool{}
+	for _, entry := range g.Subroutines {
+		subroutines[entry] = true
+	}
+
+	for _, address := range g.Addresses() {
+		block := g.Blocks[address]
+
+		label := ""
+		if symbol, ok := table.Symbol(address); ok {
+			label = symbol + ":\\l"
+		}
+		for _, decoded := range block.Instructions {
+			label += fmt.Sprintf("0x%03X  %s\\l", decoded.Address, dotEscape(chip8.Disassemble(decoded.Opcode)))
+		}
+
+		attributes := []string{fmt.Sprintf("label=\"%s\"", label)}
+		if subroutines[address] {
+			attributes = append(attributes, "peripheries=2")
+		}
+		if block.Last().Instruction.Command == chip8.CmdJumpV0Addr {
+			attributes = append(attributes, "color=red")
+		}
+		fmt.Fprintf(&b, "\tb%03X [%s];\n", address, strings.Join(attributes, ", "))
+	}
+
+	for _, address := range g.Addresses() {
+		for _, edge := range g.Blocks[address].Edges {
+			if _, ok := g.Blocks[edge.To]; !ok {
+				continue
+			}
+			fmt.Fprintf(&b, "\tb%03X -> b%03X", address, edge.To)
+			if style := edgeStyles[edge.Kind]; style != "" {
+				fmt.Fprintf(&b, " [%s]", style)
+			}
+			b.WriteString(";\n")
+		}
+	}
+
+	b.WriteString("}\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func dotEscape(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s)
+}
@@ -0,0 +1,249 @@
+package analysis
+
+import (
+	"chip8/chip8"
+	"chip8/symbols"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// StackSize is how many calls can be nested before CALL overflows the stack
+const StackSize = 16
+
+// maxSpriteSearch limits how many blocks back a sprite's LD I is looked for
+const maxSpriteSearch = 16
+
+// Range is a run of bytes in memory
+type Range struct {
+	Start uint16
+	End   uint16 // Inclusive
+}
+
+// Len returns the number of bytes in the range
+func (r Range) Len() int {
+	return int(r.End) - int(r.Start) + 1
+}
+
+// Unreachable returns the runs of ROM bytes that aren't part of any instruction that can be reached. These are usually data, but can
+// also be code only reached through a BNNN jump.
+func (g *Graph) Unreachable() []Range {
+	ranges := []Range{}
+	for i, isCode := range g.code {
+		if isCode {
+			continue
+		}
+
+		address := uint16(chip8.ProgramStart + i)
+		if last := len(ranges) - 1; last >= 0 && ranges[last].End == address-1 {
+			ranges[last].End = address
+		} else {
+			ranges = append(ranges, Range{Start: address, End: address})
+		}
+	}
+
+	return ranges
+}
+
+// Sprite is data a DRW draws
+type Sprite struct {
+	Draw  uint16 // Address of the DXYN
+	Range Range  // The bytes drawn, from the ANNN that set I
+}
+
+// Sprites finds the data each DXYN draws, by looking back from it for the ANNN that set I. Only draws where the search doesn't run
+// into anything else that changes I, or into a block that can be reached from more than one place, are found.
+func (g *Graph) Sprites() []Sprite {
+	predecessors := g.predecessors()
+	sprites := []Sprite{}
+
+	for _, address := range g.Addresses() {
+		block := g.Blocks[address]
+		for i, decoded := range block.Instructions {
+			if decoded.Instruction.Command != chip8.CmdDisplaySprite || decoded.Instruction.Arguments[2] == 0 {
+				continue
+			}
+
+			if start, ok := g.findI(block, i, predecessors); ok {
+				length := decoded.Instruction.Arguments[2]
+				sprites = append(sprites, Sprite{Draw: decoded.Address, Range: Range{Start: start, End: start + length - 1}})
+			}
+		}
+	}
+
+	return sprites
+}
+
+// findI looks back from instruction i of a block for the value I was set to
+func (g *Graph) findI(block *Block, i int, predecessors map[uint16][]*Block) (uint16, bool) {
+	for searched := 0; searched < maxSpriteSearch; searched++ {
+		for i--; i >= 0; i-- {
+			instruction := block.Instructions[i].Instruction
+			switch instruction.Command {
+			case chip8.CmdSetI:
+				return instruction.Arguments[0], true
+			case chip8.CmdAddToI, chip8.CmdSetIToFont, chip8.CmdReadMemoryRange, chip8.CmdReadRegisterRange:
+				// Changes I by an amount only known at run time (Fx55 and Fx65 only do with some quirks, but can't be relied on)
+				return 0, false
+			}
+		}
+
+		from := predecessors[block.Start]
+		if len(from) != 1 || block.Start == chip8.ProgramStart {
+			return 0, false
+		}
+		block = from[0]
+		i = len(block.Instructions)
+	}
+
+	return 0, false
+}
+
+// predecessors finds the blocks with an edge into each block. Returns and the instruction after a call are left out, as I can be
+// changed by the subroutine.
+func (g *Graph) predecessors() map[uint16][]*Block {
+	predecessors := map[uint16][]*Block{}
+	for _, address := range g.Addresses() {
+		block := g.Blocks[address]
+		for _, edge := range block.Edges {
+			switch edge.Kind {
+			case EdgeReturn, EdgeAfterCall:
+				// Mark the block as having an unknown predecessor so the search stops there
+				predecessors[edge.To] = append(predecessors[edge.To], nil, nil)
+			default:
+				predecessors[edge.To] = append(predecessors[edge.To], block)
+			}
+		}
+	}
+
+	return predecessors
+}
+
+// SpriteData returns the bytes drawn as sprites, merged into runs
+func (g *Graph) SpriteData() []Range {
+	ranges := []Range{}
+	for _, sprite := range g.Sprites() {
+		ranges = append(ranges, sprite.Range)
+	}
+
+	return mergeRanges(ranges)
+}
+
+func mergeRanges(ranges []Range) []Range {
+	sort.Slice(ranges, func(i, j int) bool {
+		return ranges[i].Start < ranges[j].Start
+	})
+
+	merged := []Range{}
+	for _, r := range ranges {
+		if last := len(merged) - 1; last >= 0 && int(r.Start) <= int(merged[last].End)+1 {
+			if r.End > merged[last].End {
+				merged[last].End = r.End
+			}
+			continue
+		}
+		merged = append(merged, r)
+	}
+
+	return merged
+}
+
+// Overflow is a call that can nest deeper than the stack allows
+type Overflow struct {
+	Call  uint16   // Address of the CALL that overflows
+	Chain []uint16 // Addresses of the calls leading to it from the main routine, outermost first
+}
+
+// StackOverflows finds the calls that can be made with the stack already full, following every chain of calls from the main routine.
+// Recursion shows up here as it always can overflow as far as the graph can tell. Only the first chain found to each call is given.
+func (g *Graph) StackOverflows() []Overflow {
+	calls := map[uint16][]Reference{}
+	overflows := map[uint16]Overflow{}
+	// Deepest depth each routine has been searched at. A search at the same or a shallower depth is skipped, as the deeper search
+	// reaches the stack limit sooner and so finds at least as much.
+	searched := map[uint16]int{}
+
+	var search func(entry uint16, depth int, chain []uint16)
+	search = func(entry uint16, depth int, chain []uint16) {
+		if previous, ok := searched[entry]; ok && previous >= depth {
+			return
+		}
+		searched[entry] = depth
+
+		if _, ok := calls[entry]; !ok {
+			calls[entry] = g.callsFrom(entry)
+		}
+		for _, call := range calls[entry] {
+			chain := append(append([]uint16{}, chain...), call.From)
+			if depth+1 > StackSize {
+				if _, ok := overflows[call.From]; !ok {
+					overflows[call.From] = Overflow{Call: call.From, Chain: chain[:len(chain)-1]}
+				}
+				continue
+			}
+			search(call.To, depth+1, chain)
+		}
+	}
+	search(chip8.ProgramStart, 0, nil)
+
+	result := []Overflow{}
+	for _, overflow := range overflows {
+		result = append(result, overflow)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Call < result[j].Call
+	})
+
+	return result
+}
+
+// callsFrom lists the calls made directly by a routine
+func (g *Graph) callsFrom(entry uint16) []Reference {
+	calls := []Reference{}
+	for _, block := range g.Routine(entry) {
+		for _, edge := range block.Edges {
+			if edge.Kind == EdgeCall {
+				calls = append(calls, Reference{From: block.Last().Address, To: edge.To, Kind: EdgeCall})
+			}
+		}
+	}
+
+	return calls
+}
+
+// WriteReport prints a summary of the graph: the subroutines, unresolved jumps, bytes that can't be reached, sprite data and calls
+// that can overflow the stack. The symbol table may be nil.
+func (g *Graph) WriteReport(w io.Writer, table *symbols.Table) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%d blocks, %d subroutines\n", len(g.Blocks), len(g.Subroutines))
+
+	for _, entry := range g.Subroutines {
+		fmt.Fprintf(&b, "subroutine %s (%d blocks)\n", table.Format(entry), len(g.Routine(entry)))
+	}
+	for _, address := range g.Unresolved {
+		fmt.Fprintf(&b, "unresolved jump at %s\n", table.Format(address))
+	}
+	for _, address := range g.Invalid {
+		fmt.Fprintf(&b, "invalid instruction at %s\n", table.Format(address))
+	}
+	for _, reference := range g.Outside {
+		fmt.Fprintf(&b, "%s at %s leads outside the ROM to 0x%03X\n", reference.Kind, table.Format(reference.From), reference.To)
+	}
+	for _, r := range g.Unreachable() {
+		fmt.Fprintf(&b, "unreachable 0x%03X-0x%03X (%d bytes)\n", r.Start, r.End, r.Len())
+	}
+	for _, r := range g.SpriteData() {
+		fmt.Fprintf(&b, "sprite data 0x%03X-0x%03X (%d bytes)\n", r.Start, r.End, r.Len())
+	}
+	for _, overflow := range g.StackOverflows() {
+		chain := []string{}
+		for _, call := range overflow.Chain {
+			chain = append(chain, table.Format(call))
+		}
+		fmt.Fprintf(&b, "call at %s can overflow the stack, after calls at %s\n", table.Format(overflow.Call), strings.Join(chain, ", "))
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
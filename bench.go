@@ -0,0 +1,60 @@
+package main
+
+import (
+	"chip8/chip8"
+	"chip8/headless"
+	"fmt"
+	"os"
+	"time"
+)
+
+func benchCommand(args []string) int {
+	fs := newFlagSet("bench")
+	cycles := fs.Int("cycles", 1000000, "number of instructions to execute")
+	quirks := fs.String("quirks", quirksAuto, quirksUsage())
+	rom, err := parseROMArgs(fs, args)
+	if err != nil {
+		return usageExitCode(err)
+	}
+
+	if err := validateQuirks(*quirks); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitUsage
+	}
+
+	display := headless.New()
+	computer := chip8.New(display)
+	info, err := computer.LoadROM(rom)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Could not load ROM:", err)
+		return exitROM
+	}
+
+	preset, err := resolveQuirks(*quirks, info)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitUsage
+	}
+	computer.SetQuirks(preset)
+
+	start := time.Now()
+	executed := 0
+	for ; executed < *cycles; executed++ {
+		if err := computer.Tick(); err != nil {
+			break
+		}
+	}
+	elapsed := time.Since(start)
+
+	fmt.Printf("Executed %d instructions in %s\n", executed, elapsed)
+	if elapsed > 0 {
+		fmt.Printf("%.0f instructions/second\n", float64(executed)/elapsed.Seconds())
+	}
+
+	if executed < *cycles {
+		fmt.Fprintln(os.Stderr, "ROM halted early")
+		return exitError
+	}
+
+	return exitOK
+}
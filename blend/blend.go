@@ -0,0 +1,144 @@
+package blend
+
+import (
+	"errors"
+)
+
+// Mode is how consecutive frames are combined to reduce the flicker caused by games erasing and redrawing sprites with XOR
+type Mode int
+
+const (
+	// Frames are shown as they are
+	ModeNone Mode = iota
+	// A pixel is shown if it was on in either of the last two frames
+	ModeOR
+	// A pixel that turns off fades out over a number of frames, like the phosphor of an old CRT
+	ModePhosphor
+)
+
+var modeNames = []string{"none", "or", "phosphor"}
+
+func (m Mode) String() string {
+	if int(m) < len(modeNames) {
+		return modeNames[m]
+	}
+
+	return "unknown"
+}
+
+// ParseMode gets a mode from its name
+func ParseMode(name string) (Mode, error) {
+	for i, n := range modeNames {
+		if n == name {
+			return Mode(i), nil
+		}
+	}
+
+	return ModeNone, errors.New("Unknown blend mode " + name)
+}
+
+// ModeNames returns the names of all modes
+func ModeNames() []string {
+	return append([]string{}, modeNames...)
+}
+
+// Pixel is a blended pixel ready to be drawn
+type Pixel struct {
+	// The pixel value (palette index) to draw. For a pixel that is fading out this is the value it had before turning off.
+	Value uint8
+	// How strongly to draw the value over the background, from 0 to 1
+	Intensity float64
+}
+
+// Blender combines frames. It is fed every frame in turn and keeps the history it needs itself.
+type Blender struct {
+	mode   Mode
+	frames int
+
+	previous [64][32]uint8
+	// For phosphor mode, the value each fading pixel had and how many frames it has left
+	fadeValue [64][32]uint8
+	fadeLeft  [64][32]int
+	// Pixels still fading, which need redrawing each frame even though the emulator hasn't changed them
+	fading [64][32]bool
+
+	output  [64][32]Pixel
+	changed [64][32]bool
+}
+
+// New creates a blender. frames is how long pixels take to fade out in phosphor mode and is ignored by the other modes.
+func New(mode Mode, frames int) *Blender {
+	if frames < 1 {
+		frames = 1
+	}
+
+	return &Blender{mode: mode, frames: frames}
+}
+
+// Mode returns the mode in use
+func (b *Blender) Mode() Mode {
+	return b.mode
+}
+
+// Blend takes the next frame along with the pixels that changed in it and returns the pixels to draw along with which of those changed
+// since the last frame. The results are only valid until the next call.
+func (b *Blender) Blend(screen *[64][32]uint8, dirty *[64][32]bool) (*[64][32]Pixel, *[64][32]bool) {
+	b.changed = [64][32]bool{}
+
+	for x := range screen {
+		for y, value := range screen[x] {
+			// Anything that hasn't changed and has settled since it last did can be skipped
+			if !dirty[x][y] && value == b.previous[x][y] && b.output[x][y] == steady(value) {
+				continue
+			}
+
+			pixel := b.blendPixel(x, y, value)
+			if pixel != b.output[x][y] {
+				b.output[x][y] = pixel
+				b.changed[x][y] = true
+			}
+		}
+	}
+
+	b.previous = *screen
+	return &b.output, &b.changed
+}
+
+func (b *Blender) blendPixel(x int, y int, value uint8) Pixel {
+	switch b.mode {
+	case ModeOR:
+		if value == 0 && b.previous[x][y] != 0 {
+			return Pixel{Value: b.previous[x][y], Intensity: 1}
+		}
+	case ModePhosphor:
+		if value != 0 {
+			b.fadeValue[x][y] = value
+			b.fadeLeft[x][y] = b.frames + 1
+			b.fading[x][y] = false
+			break
+		}
+
+		if b.previous[x][y] != 0 {
+			b.fading[x][y] = true
+		}
+		if b.fading[x][y] {
+			b.fadeLeft[x][y]--
+			if b.fadeLeft[x][y] <= 0 {
+				b.fading[x][y] = false
+				return Pixel{}
+			}
+			return Pixel{Value: b.fadeValue[x][y], Intensity: float64(b.fadeLeft[x][y]) / float64(b.frames+1)}
+		}
+	}
+
+	return steady(value)
+}
+
+// steady is how a pixel looks once it has had the same value for a while
+func steady(value uint8) Pixel {
+	if value == 0 {
+		return Pixel{}
+	}
+
+	return Pixel{Value: value, Intensity: 1}
+}
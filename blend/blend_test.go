@@ -0,0 +1,93 @@
+package blend
+
+import (
+	"testing"
+)
+
+// feed runs a single pixel at 0,0 through a sequence of values, returning what was drawn for each frame
+func feed(b *Blender, values ...uint8) []Pixel {
+	result := []Pixel{}
+	screen := [64][32]uint8{}
+	dirty := [64][32]bool{}
+
+	for _, v := range values {
+		dirty[0][0] = screen[0][0] != v
+		screen[0][0] = v
+		out, _ := b.Blend(&screen, &dirty)
+		result = append(result, out[0][0])
+	}
+
+	return result
+}
+
+func TestModeNonePassesThrough(t *testing.T) {
+	got := feed(New(ModeNone, 1), 1, 0, 0)
+
+	if got[0].Intensity != 1 || got[1].Intensity != 0 || got[2].Intensity != 0 {
+		t.Errorf("Frames were not passed through, got %v", got)
+	}
+}
+
+func TestModeORKeepsPixelForOneFrame(t *testing.T) {
+	got := feed(New(ModeOR, 1), 1, 0, 0, 1)
+
+	expected := []Pixel{{1, 1}, {1, 1}, {0, 0}, {1, 1}}
+	for i := range expected {
+		if got[i] != expected[i] {
+			t.Errorf("Frame %d was not blended correctly. Expected %v, got %v", i, expected[i], got[i])
+		}
+	}
+}
+
+func TestModePhosphorFades(t *testing.T) {
+	got := feed(New(ModePhosphor, 3), 2, 0, 0, 0, 0)
+
+	expected := []float64{1, 0.75, 0.5, 0.25, 0}
+	for i := range expected {
+		if got[i].Intensity != expected[i] {
+			t.Errorf("Frame %d intensity was not correct. Expected %v, got %v", i, expected[i], got[i].Intensity)
+		}
+	}
+	if got[2].Value != 2 {
+		t.Errorf("Fading pixel should keep its value. Expected %d, got %d", 2, got[2].Value)
+	}
+}
+
+func TestModePhosphorRelightWhileFading(t *testing.T) {
+	got := feed(New(ModePhosphor, 3), 1, 0, 1, 0)
+
+	if got[2] != (Pixel{1, 1}) {
+		t.Errorf("Pixel was not relit, got %v", got[2])
+	}
+	if got[3].Intensity != 0.75 {
+		t.Errorf("Fade did not restart. Expected %v, got %v", 0.75, got[3].Intensity)
+	}
+}
+
+func TestChangedOnlyReportsChanges(t *testing.T) {
+	b := New(ModeOR, 1)
+	screen := [64][32]uint8{}
+	dirty := [64][32]bool{}
+	screen[5][5] = 1
+	dirty[5][5] = true
+
+	_, changed := b.Blend(&screen, &dirty)
+	if !changed[5][5] || changed[0][0] {
+		t.Error("Changed pixels were not reported correctly on the first frame")
+	}
+
+	dirty = [64][32]bool{}
+	_, changed = b.Blend(&screen, &dirty)
+	if changed[5][5] {
+		t.Error("A pixel that didn't change was reported as changed")
+	}
+}
+
+func TestParseMode(t *testing.T) {
+	if mode, err := ParseMode("phosphor"); err != nil || mode != ModePhosphor {
+		t.Errorf("Mode was not parsed correctly, got %v %v", mode, err)
+	}
+	if _, err := ParseMode("blur"); err == nil {
+		t.Error("Expected an error for an unknown mode")
+	}
+}
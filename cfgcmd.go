@@ -0,0 +1,58 @@
+package main
+
+import (
+	"chip8/analysis"
+	"chip8/symbols"
+	"fmt"
+	"os"
+)
+
+func cfgCommand(args []string) int {
+	fs := newFlagSet("cfg")
+	dot := fs.String("dot", "", "write the control-flow graph in Graphviz DOT format to this file, or - for standard output")
+	symbolFile := fs.String("symbols", "", "symbol file naming addresses in the report (default the ROM with a .sym extension if it exists)")
+	source := addROMFlags(fs)
+	rom, err := parseROMArgs(fs, args)
+	if err != nil {
+		return usageExitCode(err)
+	}
+
+	data, err := source.read(rom)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Could not load ROM:", err)
+		return exitROM
+	}
+
+	table, err := symbols.LoadForROM(rom, *symbolFile)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Could not load symbols:", err)
+		return exitUsage
+	}
+
+	graph := analysis.Analyze(data)
+
+	switch *dot {
+	case "":
+		err = graph.WriteReport(os.Stdout, table)
+	case "-":
+		err = graph.WriteDOT(os.Stdout, table)
+	default:
+		var file *os.File
+		file, err = os.Create(*dot)
+		if err == nil {
+			err = graph.WriteDOT(file, table)
+			if closeErr := file.Close(); err == nil {
+				err = closeErr
+			}
+		}
+		if err == nil {
+			err = graph.WriteReport(os.Stdout, table)
+		}
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Could not write graph:", err)
+		return exitError
+	}
+
+	return exitOK
+}
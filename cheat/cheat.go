@@ -0,0 +1,184 @@
+// Package cheat finds and changes the variables of a running ROM. A Search narrows down which addresses hold a value, such as the
+// number of lives, by comparing snapshots of memory taken as the game runs. Cheats then either freeze bytes at a value every frame or
+// patch the ROM's bytes once it is loaded. Cheats are kept in a file per ROM, named by the ROM's SHA-1.
+//
+// A nil *File has no cheats, so frontends can apply one without checking whether any were loaded.
+package cheat
+
+import (
+	"chip8/chip8"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Memory is the part of the CPU cheats need. *chip8.Chip8 implements it.
+type Memory interface {
+	ReadMemory(address uint16, length int) ([]uint8, error)
+	WriteMemory(address uint16, data []uint8) error
+}
+
+// Kind is how a cheat changes memory
+type Kind string
+
+const (
+	KindFreeze Kind = "freeze" // Write the bytes before every frame
+	KindPatch  Kind = "patch"  // Write the bytes once, after the ROM is loaded
+)
+
+// Bytes is a run of bytes, written to JSON as a hex string so cheat files are easy to edit
+type Bytes []uint8
+
+func (b Bytes) MarshalJSON() ([]byte, error) {
+	return json.Marshal(strings.ToUpper(hex.EncodeToString(b)))
+}
+
+func (b *Bytes) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+
+	decoded, err := ParseBytes(s)
+	if err != nil {
+		return err
+	}
+
+	*b = decoded
+	return nil
+}
+
+// ParseBytes reads bytes written in hex, such as "03" or "6001A2F0". Spaces between bytes are allowed.
+func ParseBytes(s string) (Bytes, error) {
+	decoded, err := hex.DecodeString(strings.ReplaceAll(strings.TrimPrefix(strings.ToLower(s), "0x"), " ", ""))
+	if err != nil {
+		return nil, errors.New("Invalid hex bytes " + s)
+	}
+
+	return decoded, nil
+}
+
+// Cheat writes bytes into memory
+type Cheat struct {
+	Name     string `json:"name"`
+	Kind     Kind   `json:"kind"`
+	Address  uint16 `json:"address"`
+	Bytes    Bytes  `json:"bytes"`
+	Disabled bool   `json:"disabled,omitempty"`
+}
+
+// Validate checks the cheat can be applied
+func (c Cheat) Validate() error {
+	if c.Kind != KindFreeze && c.Kind != KindPatch {
+		return errors.New("Unknown cheat kind " + string(c.Kind))
+	}
+	if len(c.Bytes) == 0 {
+		return errors.New("Cheat has no bytes to write")
+	}
+	if int(c.Address)+len(c.Bytes) > chip8.MemorySize {
+		return errors.New("Cheat goes past the end of memory")
+	}
+
+	return nil
+}
+
+// File is the cheats for one ROM
+type File struct {
+	ROM    string  `json:"rom"` // SHA-1 of the ROM, as given by chip8.ROMInfo
+	Cheats []Cheat `json:"cheats"`
+}
+
+// DefaultDir is where cheat files are kept if a directory isn't given explicitly
+func DefaultDir() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+
+	return filepath.Join(dir, "chip8", "cheats")
+}
+
+// Path is the file the cheats for a ROM are kept in
+func Path(dir string, rom string) string {
+	return filepath.Join(dir, strings.ToLower(rom)+".json")
+}
+
+// Load reads the cheats for a ROM from a directory. A ROM without a cheat file has no cheats.
+func Load(dir string, rom string) (*File, error) {
+	data, err := ioutil.ReadFile(Path(dir, rom))
+	if os.IsNotExist(err) {
+		return &File{ROM: rom}, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	f := File{}
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, err
+	}
+	if !strings.EqualFold(f.ROM, rom) {
+		return nil, errors.New("Cheat file is for a different ROM")
+	}
+	for _, c := range f.Cheats {
+		if err := c.Validate(); err != nil {
+			return nil, errors.New(c.Name + ": " + err.Error())
+		}
+	}
+
+	return &f, nil
+}
+
+// Save writes the cheats to their file in a directory, creating the directory if needed
+func (f *File) Save(dir string) error {
+	data, err := json.MarshalIndent(f, "", "\t")
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(Path(dir, f.ROM), append(data, '\n'), 0644)
+}
+
+// Add adds a cheat after checking it can be applied
+func (f *File) Add(c Cheat) error {
+	if err := c.Validate(); err != nil {
+		return err
+	}
+
+	f.Cheats = append(f.Cheats, c)
+	return nil
+}
+
+// Patch applies the enabled patches. Call it once after the ROM is loaded.
+func (f *File) Patch(m Memory) error {
+	return f.apply(m, KindPatch)
+}
+
+// Freeze applies the enabled freezes. Call it before every frame.
+func (f *File) Freeze(m Memory) error {
+	return f.apply(m, KindFreeze)
+}
+
+func (f *File) apply(m Memory, kind Kind) error {
+	if f == nil {
+		return nil
+	}
+
+	for _, c := range f.Cheats {
+		if c.Kind != kind || c.Disabled {
+			continue
+		}
+		if err := m.WriteMemory(c.Address, c.Bytes); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
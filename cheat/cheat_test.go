@@ -0,0 +1,145 @@
+package cheat
+
+import (
+	"chip8/chip8"
+	"testing"
+)
+
+type display struct{}
+
+func (d *display) Update(*[64][32]uint8, *[64][32]bool) {}
+func (d *display) Closed() bool                         { return false }
+func (d *display) KeyDown(key uint8) bool               { return false }
+
+// Stores a count of lives at 0x300, losing one every time round the loop
+var lives = []uint8{
+	0x60, 0x03, // 200: LD V0, 3
+	0xA3, 0x00, // 202: LD I, 0x300
+	0xF1, 0x55, // 204: LD [I], V1 (stores V0 only on this interpreter)
+	0x70, 0xFF, // 206: ADD V0, 0xFF
+	0x12, 0x04, // 208: JP 0x204
+}
+
+func start(t *testing.T) *chip8.Chip8 {
+	computer := chip8.New(&display{})
+	if _, err := computer.LoadFromMemory(lives); err != nil {
+		t.Fatal(err)
+	}
+
+	return computer
+}
+
+func tick(t *testing.T, computer *chip8.Chip8, n int) {
+	for i := 0; i < n; i++ {
+		if err := computer.Tick(); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func snapshot(t *testing.T, computer *chip8.Chip8) []uint8 {
+	memory, err := Snapshot(computer)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return memory
+}
+
+func TestSearch(t *testing.T) {
+	computer := start(t)
+	search := NewSearch(snapshot(t, computer))
+
+	tick(t, computer, 3)
+	search.Narrow(snapshot(t, computer), CompareValue, 3)
+	tick(t, computer, 3)
+	if left, _ := search.Narrow(snapshot(t, computer), CompareDecreased, 0); left != 1 {
+		t.Fatalf("Expected %d candidate, got %X", 1, search.Candidates())
+	}
+
+	if search.Candidates()[0] != 0x300 || search.Value(0x300) != 2 {
+		t.Errorf("Candidate was not found correctly. Expected 0x300 holding %d, got %X holding %d", 2, search.Candidates()[0],
+			search.Value(search.Candidates()[0]))
+	}
+	if left, _ := search.Narrow(snapshot(t, computer), CompareEqual, 0); left != 1 {
+		t.Errorf("Equal comparison dropped a candidate that didn't change")
+	}
+}
+
+func TestSearchRejectsShortSnapshot(t *testing.T) {
+	search := NewSearch(make([]uint8, chip8.MemorySize))
+
+	left, err := search.Narrow(make([]uint8, 16), CompareEqual, 0)
+	if err != ErrSnapshotSize {
+		t.Errorf("Expected ErrSnapshotSize, got %v", err)
+	}
+	if left != chip8.MemorySize || len(search.Candidates()) != chip8.MemorySize {
+		t.Errorf("Search was changed by a failed narrow. Expected %d candidates, got %d", chip8.MemorySize, left)
+	}
+}
+
+func TestFreezeAndPatch(t *testing.T) {
+	computer := start(t)
+	f := &File{}
+	if err := f.Add(Cheat{Name: "No lives lost", Kind: KindPatch, Address: 0x206, Bytes: Bytes{0x70, 0x00}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Add(Cheat{Name: "Nine lives", Kind: KindFreeze, Address: 0x301, Bytes: Bytes{0x09}}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := f.Patch(computer); err != nil {
+		t.Fatal(err)
+	}
+	tick(t, computer, 9)
+	if err := f.Freeze(computer); err != nil {
+		t.Fatal(err)
+	}
+
+	memory, _ := computer.ReadMemory(0x300, 2)
+	if memory[0] != 3 || memory[1] != 9 {
+		t.Errorf("Cheats were not applied correctly. Expected 3 and 9, got %d and %d", memory[0], memory[1])
+	}
+
+	var none *File
+	if err := none.Freeze(computer); err != nil {
+		t.Errorf("Freezing with no cheats failed: %v", err)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	invalid := []Cheat{
+		{Kind: "poke", Address: 0x300, Bytes: Bytes{1}},
+		{Kind: KindFreeze, Address: 0x300},
+		{Kind: KindFreeze, Address: 0xFFF, Bytes: Bytes{1, 2}},
+	}
+	for _, c := range invalid {
+		if err := c.Validate(); err == nil {
+			t.Errorf("Expected an error for %+v", c)
+		}
+	}
+}
+
+func TestSaveAndLoad(t *testing.T) {
+	dir := t.TempDir()
+	f := &File{ROM: "ABCDEF"}
+	if err := f.Add(Cheat{Name: "Nine lives", Kind: KindFreeze, Address: 0x300, Bytes: Bytes{0x09}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Save(dir); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := Load(dir, "abcdef")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(loaded.Cheats) != 1 || loaded.Cheats[0].Address != 0x300 || len(loaded.Cheats[0].Bytes) != 1 || loaded.Cheats[0].Bytes[0] != 9 {
+		t.Errorf("Cheats were not loaded correctly. Got %+v", loaded.Cheats)
+	}
+
+	empty, err := Load(dir, "123456")
+	if err != nil || len(empty.Cheats) != 0 || empty.ROM != "123456" {
+		t.Errorf("Expected no cheats for a ROM without a file, got %+v, %v", empty, err)
+	}
+}
@@ -0,0 +1,108 @@
+package cheat
+
+import (
+	"chip8/chip8"
+	"errors"
+)
+
+// Comparison picks which addresses a search keeps
+type Comparison int
+
+const (
+	CompareValue     Comparison = iota // Holds the given value
+	CompareEqual                       // Hasn't changed since the last snapshot
+	CompareChanged                     // Has changed since the last snapshot
+	CompareIncreased                   // Is higher than in the last snapshot
+	CompareDecreased                   // Is lower than in the last snapshot
+)
+
+var comparisonNames = []string{"value", "equal", "changed", "increased", "decreased"}
+
+func (c Comparison) String() string {
+	if c >= 0 && int(c) < len(comparisonNames) {
+		return comparisonNames[c]
+	}
+
+	return "unknown"
+}
+
+// ParseComparison looks up a comparison by name
+func ParseComparison(name string) (Comparison, error) {
+	for i, n := range comparisonNames {
+		if n == name {
+			return Comparison(i), nil
+		}
+	}
+
+	return 0, errors.New("Unknown comparison " + name)
+}
+
+// Snapshot copies the whole of memory
+func Snapshot(m Memory) ([]uint8, error) {
+	return m.ReadMemory(0, chip8.MemorySize)
+}
+
+// Search narrows down the addresses that could hold a variable. It starts with every address in memory, and each snapshot taken
+// while the game runs keeps only those that compare as expected with the snapshot before.
+type Search struct {
+	previous   []uint8
+	candidates []uint16
+}
+
+// NewSearch starts a search from a snapshot of memory
+func NewSearch(snapshot []uint8) *Search {
+	s := Search{previous: snapshot}
+	for address := range snapshot {
+		s.candidates = append(s.candidates, uint16(address))
+	}
+
+	return &s
+}
+
+// ErrSnapshotSize is returned when a snapshot isn't the same size as the one the search started from
+var ErrSnapshotSize = errors.New("Snapshot is a different size from the last one")
+
+// Narrow keeps the candidates that compare as expected between the last snapshot and this one, returning how many are left. The value
+// is only used by CompareValue.
+// Will return an error if the snapshot is a different size from the last; the search is unchanged in that case.
+func (s *Search) Narrow(snapshot []uint8, comparison Comparison, value uint8) (int, error) {
+	if len(snapshot) != len(s.previous) {
+		return len(s.candidates), ErrSnapshotSize
+	}
+
+	kept := s.candidates[:0]
+	for _, address := range s.candidates {
+		before, now := s.previous[address], snapshot[address]
+
+		var keep bool
+		switch comparison {
+		case CompareValue:
+			keep = now == value
+		case CompareEqual:
+			keep = now == before
+		case CompareChanged:
+			keep = now != before
+		case CompareIncreased:
+			keep = now > before
+		case CompareDecreased:
+			keep = now < before
+		}
+		if keep {
+			kept = append(kept, address)
+		}
+	}
+
+	s.candidates = kept
+	s.previous = snapshot
+	return len(kept), nil
+}
+
+// Candidates returns the addresses still in the search, in order
+func (s *Search) Candidates() []uint16 {
+	return append([]uint16{}, s.candidates...)
+}
+
+// Value returns what an address held in the last snapshot
+func (s *Search) Value(address uint16) uint8 {
+	return s.previous[address]
+}
@@ -0,0 +1,406 @@
+package main
+
+import (
+	"bufio"
+	"chip8/cheat"
+	"chip8/chip8"
+	"chip8/headless"
+	"chip8/symbols"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+func cheatDirUsage() string {
+	return "directory of cheat files, one per ROM"
+}
+
+// loadCheats reads the saved cheats for a ROM if -cheats was given, or returns nil
+func loadCheats(enabled bool, dir string, info *chip8.ROMInfo) (*cheat.File, error) {
+	if !enabled {
+		return nil, nil
+	}
+
+	return cheat.Load(dir, info.SHA1)
+}
+
+func cheatCommand(args []string) int {
+	fs := newFlagSet("cheat")
+	dir := fs.String("cheat-dir", cheat.DefaultDir(), cheatDirUsage())
+	cycles := fs.Int("cycles", 5, "instructions executed per 60Hz frame")
+	quirks := fs.String("quirks", quirksAuto, quirksUsage())
+	source := addROMFlags(fs)
+	rom, err := parseROMArgs(fs, args)
+	if err != nil {
+		return usageExitCode(err)
+	}
+
+	if err := validateQuirks(*quirks); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitUsage
+	}
+
+	data, err := source.read(rom)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Could not load ROM:", err)
+		return exitROM
+	}
+
+	display := headless.New()
+	computer := chip8.New(display)
+	info, err := computer.LoadFromMemory(data)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Invalid ROM:", err)
+		return exitROM
+	}
+
+	preset, err := resolveQuirks(*quirks, info)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitUsage
+	}
+	computer.SetQuirks(preset)
+
+	cheats, err := cheat.Load(*dir, info.SHA1)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Could not load cheats:", err)
+		return exitUsage
+	}
+	if err := cheats.Patch(computer); err != nil {
+		fmt.Fprintln(os.Stderr, "Could not apply cheats:", err)
+		return exitError
+	}
+
+	c := console{computer: computer, display: display, cheats: cheats, dir: *dir, cycles: *cycles, out: os.Stdout}
+	fmt.Printf("Cheat console for %s. Type help for a list of commands.\n", rom)
+	c.run(os.Stdin)
+
+	return exitOK
+}
+
+// console runs a ROM without a window under typed commands, to search memory for variables and try out cheats
+type console struct {
+	computer *chip8.Chip8
+	display  *headless.HeadlessDisplay
+	cheats   *cheat.File
+	dir      string
+	cycles   int
+	search   *cheat.Search
+	out      io.Writer
+}
+
+type consoleCommand struct {
+	usage   string
+	summary string
+	run     func(c *console, args []string) error
+}
+
+var consoleCommands = map[string]consoleCommand{
+	"run":       {"run [frames]", "run the ROM for some frames (default 1)", (*console).runFrames},
+	"press":     {"press <key>", "hold down a chip8 key (0-F)", (*console).press},
+	"release":   {"release <key>", "let go of a chip8 key", (*console).release},
+	"new":       {"new", "start a search with every address in memory", (*console).newSearch},
+	"value":     {"value <n>", "keep addresses holding n", (*console).narrow},
+	"equal":     {"equal", "keep addresses that haven't changed since the last search", (*console).narrow},
+	"changed":   {"changed", "keep addresses that have changed", (*console).narrow},
+	"increased": {"increased", "keep addresses that have gone up", (*console).narrow},
+	"decreased": {"decreased", "keep addresses that have gone down", (*console).narrow},
+	"list":      {"list", "show the addresses left in the search", (*console).list},
+	"peek":      {"peek <addr> [length]", "show bytes of memory", (*console).peek},
+	"poke":      {"poke <addr> <bytes>", "write hex bytes to memory once", (*console).poke},
+	"freeze":    {"freeze <addr> <bytes> <name...>", "add a cheat that writes the bytes every frame", (*console).add},
+	"patch":     {"patch <addr> <bytes> <name...>", "add a cheat that writes the bytes when the ROM loads", (*console).add},
+	"cheats":    {"cheats", "list the cheats", (*console).listCheats},
+	"toggle":    {"toggle <n>", "turn cheat n on or off", (*console).toggle},
+	"remove":    {"remove <n>", "delete cheat n", (*console).remove},
+	"save":      {"save", "save the cheats for this ROM", (*console).save},
+}
+
+// maxListed is how many candidates list shows, as the first searches leave most of memory
+const maxListed = 32
+
+// run reads commands until the input ends or quit is typed
+func (c *console) run(in io.Reader) {
+	scanner := bufio.NewScanner(in)
+	for {
+		fmt.Fprint(c.out, "> ")
+		if !scanner.Scan() {
+			fmt.Fprintln(c.out)
+			return
+		}
+
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+
+		name := fields[0]
+		switch name {
+		case "quit", "exit":
+			return
+		case "help":
+			c.help()
+			continue
+		}
+
+		command, ok := consoleCommands[name]
+		if !ok {
+			fmt.Fprintf(c.out, "Unknown command %q, type help for a list\n", name)
+			continue
+		}
+		if err := command.run(c, fields); err != nil {
+			fmt.Fprintln(c.out, err)
+		}
+	}
+}
+
+func (c *console) help() {
+	for _, name := range []string{"run", "press", "release", "new", "value", "equal", "changed", "increased", "decreased", "list",
+		"peek", "poke", "freeze", "patch", "cheats", "toggle", "remove", "save"} {
+		fmt.Fprintf(c.out, "  %-32s %s\n", consoleCommands[name].usage, consoleCommands[name].summary)
+	}
+	fmt.Fprintln(c.out, "  quit")
+}
+
+func (c *console) runFrames(args []string) error {
+	frames := 1
+	if len(args) > 1 {
+		var err error
+		if frames, err = strconv.Atoi(args[1]); err != nil || frames < 1 {
+			return errors.New("Frames must be a positive number")
+		}
+	}
+
+	for i := 0; i < frames; i++ {
+		if err := c.cheats.Freeze(c.computer); err != nil {
+			return err
+		}
+		frameDirty, err := runFrame(c.computer, c.computer.Tick, c.cycles)
+		if err != nil {
+			return err
+		}
+		c.display.Update(c.computer.GetScreen(), &frameDirty)
+	}
+
+	return nil
+}
+
+func (c *console) press(args []string) error {
+	return c.setKey(args, true)
+}
+
+func (c *console) release(args []string) error {
+	return c.setKey(args, false)
+}
+
+func (c *console) setKey(args []string, down bool) error {
+	if len(args) != 2 {
+		return errors.New("Give a key from 0 to F")
+	}
+
+	key, err := strconv.ParseUint(args[1], 16, 8)
+	if err != nil || key > 0xF {
+		return errors.New("Give a key from 0 to F")
+	}
+
+	c.display.SetKey(uint8(key), down)
+	return nil
+}
+
+func (c *console) newSearch(args []string) error {
+	snapshot, err := cheat.Snapshot(c.computer)
+	if err != nil {
+		return err
+	}
+
+	c.search = cheat.NewSearch(snapshot)
+	fmt.Fprintf(c.out, "%d addresses\n", len(c.search.Candidates()))
+	return nil
+}
+
+func (c *console) narrow(args []string) error {
+	if c.search == nil {
+		return errors.New("Start a search with new first")
+	}
+
+	comparison, err := cheat.ParseComparison(args[0])
+	if err != nil {
+		return err
+	}
+
+	value := uint64(0)
+	if comparison == cheat.CompareValue {
+		if len(args) != 2 {
+			return errors.New("Give the value to search for")
+		}
+		if value, err = strconv.ParseUint(args[1], 0, 8); err != nil {
+			return errors.New("Value must be from 0 to 255")
+		}
+	}
+
+	snapshot, err := cheat.Snapshot(c.computer)
+	if err != nil {
+		return err
+	}
+
+	left, err := c.search.Narrow(snapshot, comparison, uint8(value))
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(c.out, "%d addresses left\n", left)
+	return nil
+}
+
+func (c *console) list(args []string) error {
+	if c.search == nil {
+		return errors.New("Start a search with new first")
+	}
+
+	candidates := c.search.Candidates()
+	for i, address := range candidates {
+		if i == maxListed {
+			fmt.Fprintf(c.out, "... and %d more\n", len(candidates)-maxListed)
+			break
+		}
+		value := c.search.Value(address)
+		fmt.Fprintf(c.out, "0x%03X  %3d  0x%02X\n", address, value, value)
+	}
+
+	return nil
+}
+
+func (c *console) peek(args []string) error {
+	if len(args) < 2 {
+		return errors.New("Give an address to show")
+	}
+
+	address, err := symbols.ParseAddress(args[1])
+	if err != nil {
+		return err
+	}
+	length := 16
+	if len(args) > 2 {
+		if length, err = strconv.Atoi(args[2]); err != nil || length < 1 {
+			return errors.New("Length must be a positive number")
+		}
+	}
+
+	memory, err := c.computer.ReadMemory(address, length)
+	if err != nil {
+		return err
+	}
+	for i := 0; i < len(memory); i += 16 {
+		end := i + 16
+		if end > len(memory) {
+			end = len(memory)
+		}
+		fmt.Fprintf(c.out, "0x%03X  % X\n", int(address)+i, memory[i:end])
+	}
+
+	return nil
+}
+
+func (c *console) poke(args []string) error {
+	if len(args) != 3 {
+		return errors.New("Give an address and the bytes to write")
+	}
+
+	address, err := symbols.ParseAddress(args[1])
+	if err != nil {
+		return err
+	}
+	bytes, err := cheat.ParseBytes(args[2])
+	if err != nil {
+		return err
+	}
+
+	return c.computer.WriteMemory(address, bytes)
+}
+
+func (c *console) add(args []string) error {
+	if len(args) < 4 {
+		return errors.New("Give an address, the bytes to write and a name")
+	}
+
+	address, err := symbols.ParseAddress(args[1])
+	if err != nil {
+		return err
+	}
+	bytes, err := cheat.ParseBytes(args[2])
+	if err != nil {
+		return err
+	}
+
+	added := cheat.Cheat{Name: strings.Join(args[3:], " "), Kind: cheat.Kind(args[0]), Address: address, Bytes: bytes}
+	if err := c.cheats.Add(added); err != nil {
+		return err
+	}
+	if added.Kind == cheat.KindPatch {
+		// Patches are normally applied on load, so write this one now to try it out
+		return c.computer.WriteMemory(address, bytes)
+	}
+
+	return nil
+}
+
+func (c *console) listCheats(args []string) error {
+	if len(c.cheats.Cheats) == 0 {
+		fmt.Fprintln(c.out, "No cheats")
+	}
+	for i, ch := range c.cheats.Cheats {
+		state := "on"
+		if ch.Disabled {
+			state = "off"
+		}
+		fmt.Fprintf(c.out, "%d  %-3s  %-6s  0x%03X  % X  %s\n", i+1, state, ch.Kind, ch.Address, []uint8(ch.Bytes), ch.Name)
+	}
+
+	return nil
+}
+
+// cheatIndex reads the number of a cheat as shown by cheats
+func (c *console) cheatIndex(args []string) (int, error) {
+	if len(args) != 2 {
+		return 0, errors.New("Give the number of a cheat")
+	}
+
+	n, err := strconv.Atoi(args[1])
+	if err != nil || n < 1 || n > len(c.cheats.Cheats) {
+		return 0, errors.New("No cheat " + args[1])
+	}
+
+	return n - 1, nil
+}
+
+func (c *console) toggle(args []string) error {
+	i, err := c.cheatIndex(args)
+	if err != nil {
+		return err
+	}
+
+	c.cheats.Cheats[i].Disabled = !c.cheats.Cheats[i].Disabled
+	return nil
+}
+
+func (c *console) remove(args []string) error {
+	i, err := c.cheatIndex(args)
+	if err != nil {
+		return err
+	}
+
+	c.cheats.Cheats = append(c.cheats.Cheats[:i], c.cheats.Cheats[i+1:]...)
+	return nil
+}
+
+func (c *console) save(args []string) error {
+	if err := c.cheats.Save(c.dir); err != nil {
+		return err
+	}
+
+	fmt.Fprintln(c.out, "Saved to", cheat.Path(c.dir, c.cheats.ROM))
+	return nil
+}
@@ -44,6 +44,16 @@ var Fonts = [...]uint8{
 	0xF0, 0x80, 0xF0, 0x80, 0x80,
 }
 
+// Errors returned from Tick when a ROM does something that can't be executed. The CPU is paused when any of these happen.
+var (
+	ErrStackOverflow             = errors.New("Stack overflow")
+	ErrStackUnderflow            = errors.New("Return with an empty stack")
+	ErrFontOutOfBounds           = errors.New("Font location out of bounds")
+	ErrMemoryOutOfBounds         = errors.New("Memory access out of bounds")
+	ErrProgramCounterOutOfBounds = errors.New("Program counter out of bounds")
+	ErrInvalidKey                = errors.New("Key out of range")
+)
+
 // Represents a Chip8 CPU
 type Chip8 struct {
 	display        Display
@@ -58,6 +68,10 @@ type Chip8 struct {
 	delayTimer     uint8
 	soundTimer     uint8
 	halted         bool
+	quirks         Quirks
+	cycles         uint64
+	tracer         Tracer
+	watcher        MemoryWatcher
 }
 
 // New creates a new Chip8 CPU.
@@ -107,21 +121,30 @@ func parseInstruction(val uint16) (*Instruction, error) {
 }
 
 // Loads a ROM in from a file.
-// Will return an error if the file could not be loaded (for example it doesn't exist).
-func (c8 *Chip8) LoadROM(rom string) error {
+// Will return an error if the file could not be loaded (for example it doesn't exist) or isn't a valid ROM.
+func (c8 *Chip8) LoadROM(rom string) (*ROMInfo, error) {
 	bytes, err := ioutil.ReadFile(rom)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	copy(c8.memory[0x200:], bytes[:])
-	return nil
+	return c8.LoadFromMemory(bytes)
 }
 
 // Loads a file in directly from a byte array.
-func (c8 *Chip8) LoadFromMemory(data []uint8) error {
-	copy(c8.memory[0x200:], data[:])
-	return nil
+// Will return an error if the ROM is empty or too large to fit in memory; nothing is loaded in that case.
+func (c8 *Chip8) LoadFromMemory(data []uint8) (*ROMInfo, error) {
+	info, err := NewROMInfo(data)
+	if err != nil {
+		return nil, err
+	}
+
+	// Clear what's left of any ROM loaded before, so a smaller one doesn't end up with the tail of the last
+	for i := ProgramStart; i < MemorySize; i++ {
+		c8.memory[i] = 0
+	}
+	copy(c8.memory[ProgramStart:], data[:])
+	return info, nil
 }
 
 // next moves onto the next instruction
@@ -131,10 +154,24 @@ func (c8 *Chip8) next() {
 
 // Read the current instruction from the program counter
 func (c8 *Chip8) readInstruction() (*Instruction, error) {
+	if int(c8.programCounter)+2 > MemorySize {
+		return nil, ErrProgramCounterOutOfBounds
+	}
+	c8.watch(AccessFetch, c8.programCounter, 2)
+
 	val := (uint16(c8.memory[c8.programCounter]) << 8) | uint16(c8.memory[c8.programCounter+1])
 	return parseInstruction(val)
 }
 
+// checkMemoryRange makes sure length bytes starting at address are all inside memory
+func checkMemoryRange(address uint16, length int) error {
+	if int(address)+length > MemorySize {
+		return ErrMemoryOutOfBounds
+	}
+
+	return nil
+}
+
 func (c8 *Chip8) setRegister(register uint16, value uint8) {
 	c8.registers[register] = value
 }
@@ -148,13 +185,27 @@ func (c8 *Chip8) jump(position uint16) {
 }
 
 func (c8 *Chip8) jumpV0AndAddr(addr uint16) {
-	c8.programCounter = uint16(c8.registers[0]) + addr
+	register := uint16(0)
+	if c8.quirks.JumpUsesVX {
+		register = (addr & 0x0F00) >> 8
+	}
+	c8.programCounter = uint16(c8.registers[register]) + addr
 }
 
-func (c8 *Chip8) drawSprite(register1 uint16, register2 uint16, nibble uint16) {
+func (c8 *Chip8) drawSprite(register1 uint16, register2 uint16, nibble uint16) error {
+	if err := checkMemoryRange(c8.memoryRegister, int(nibble)); err != nil {
+		return err
+	}
+
 	x := c8.registers[register1]
 	y := c8.registers[register2]
+	if c8.quirks.ClipSprites {
+		// Only the starting position wraps; anything drawn past the edge is dropped below
+		x %= 64
+		y %= 32
+	}
 	bytes := c8.memory[c8.memoryRegister : c8.memoryRegister+nibble]
+	c8.watch(AccessSprite, c8.memoryRegister, int(nibble))
 
 	// Reset the collision flag to 0
 	c8.registers[0xF] = 0
@@ -167,7 +218,11 @@ func (c8 *Chip8) drawSprite(register1 uint16, register2 uint16, nibble uint16) {
 				pixelSet = uint8(1)
 			}
 
-			x2 := x + uint8(j)%64
+			if c8.quirks.ClipSprites && (int(x)+j >= 64 || int(y)+i >= 32) {
+				continue
+			}
+
+			x2 := (x + uint8(j)) % 64
 			y2 := (y + uint8(i)) % 32
 
 			if pixelSet != 0 {
@@ -180,51 +235,107 @@ func (c8 *Chip8) drawSprite(register1 uint16, register2 uint16, nibble uint16) {
 			}
 		}
 	}
+
+	return nil
 }
 
-func (c8 *Chip8) callSubroutine(address uint16) {
-	c8.stackPointer += 1
-	if c8.stackPointer > 15 {
-		panic("Stack overflow")
+// clearScreen turns every pixel off, marking the ones that were on as dirty
+func (c8 *Chip8) clearScreen() {
+	for x := range c8.screen {
+		for y := range c8.screen[x] {
+			if c8.screen[x][y] != 0 {
+				c8.screen[x][y] = 0
+				c8.dirty[x][y] = true
+			}
+		}
+	}
+}
+
+func (c8 *Chip8) callSubroutine(address uint16) error {
+	if int(c8.stackPointer) >= len(c8.stack)-1 {
+		return ErrStackOverflow
 	}
+	c8.stackPointer += 1
 	c8.stack[c8.stackPointer] = c8.programCounter
 	c8.programCounter = address
+
+	return nil
 }
 
-func (c8 *Chip8) storeBCD(register uint16) {
+func (c8 *Chip8) storeBCD(register uint16) error {
+	if err := checkMemoryRange(c8.memoryRegister, 3); err != nil {
+		return err
+	}
+
+	c8.watch(AccessBCD, c8.memoryRegister, 3)
 	value := c8.registers[register]
 	c8.memory[c8.memoryRegister] = uint8((value / 100) % 10)
 	c8.memory[c8.memoryRegister+1] = uint8((value / 10) % 10)
 	c8.memory[c8.memoryRegister+2] = uint8(value % 10)
+
+	return nil
 }
 
-func (c8 *Chip8) readMemoryRange(num uint16) {
+// readMemoryRange loads V0 to Vx, inclusive, from memory at I
+func (c8 *Chip8) readMemoryRange(x uint16) error {
+	num := x + 1
+	if err := checkMemoryRange(c8.memoryRegister, int(num)); err != nil {
+		return err
+	}
+
+	c8.watch(AccessLoad, c8.memoryRegister, int(num))
 	for i := 0; i < int(num); i++ {
 		c8.registers[i] = c8.memory[int(c8.memoryRegister)+i]
 	}
+
+	if c8.quirks.LoadStoreIncrementsI {
+		c8.memoryRegister += num
+	}
+
+	return nil
 }
 
-func (c8 *Chip8) readRegisterRange(num uint16) {
+// readRegisterRange stores V0 to Vx, inclusive, in memory at I
+func (c8 *Chip8) readRegisterRange(x uint16) error {
+	num := x + 1
+	if err := checkMemoryRange(c8.memoryRegister, int(num)); err != nil {
+		return err
+	}
+
+	c8.watch(AccessStore, c8.memoryRegister, int(num))
 	for i := 0; i < int(num); i++ {
 		c8.memory[int(c8.memoryRegister)+i] = c8.registers[i]
 	}
+
+	if c8.quirks.LoadStoreIncrementsI {
+		c8.memoryRegister += num
+	}
+
+	return nil
 }
 
-func (c8 *Chip8) setLocationToFont(register uint16) {
+func (c8 *Chip8) setLocationToFont(register uint16) error {
 	value := uint16(c8.registers[register])
 	if value > 0xF {
-		panic("Font location out of bounds")
+		return ErrFontOutOfBounds
 	}
 	c8.memoryRegister = uint16(c8.registers[register]) * 5
+
+	return nil
 }
 
 func (c8 *Chip8) addToRegister(register uint16, value uint8) {
 	c8.registers[register] += value
 }
 
-func (c8 *Chip8) returnFromSubroutine() {
+func (c8 *Chip8) returnFromSubroutine() error {
+	if c8.stackPointer < 0 {
+		return ErrStackUnderflow
+	}
 	c8.programCounter = c8.stack[c8.stackPointer]
 	c8.stackPointer--
+
+	return nil
 }
 
 func (c8 *Chip8) setDelayTimer(register uint16) {
@@ -268,10 +379,15 @@ func (c8 *Chip8) random(register uint16, value uint8) {
 	c8.registers[register] = random & value
 }
 
-func (c8 *Chip8) skipIfKeyPressed(register uint16, keyPressed bool) {
+func (c8 *Chip8) skipIfKeyPressed(register uint16, keyPressed bool) error {
+	if c8.registers[register] > 0xF {
+		return ErrInvalidKey
+	}
 	if c8.display.KeyDown(c8.registers[register]) == keyPressed {
 		c8.programCounter += 2
 	}
+
+	return nil
 }
 
 func (c8 *Chip8) waitForKey(register uint16) bool {
@@ -287,22 +403,37 @@ func (c8 *Chip8) waitForKey(register uint16) bool {
 
 func (c8 *Chip8) and(register1 uint16, register2 uint16) {
 	c8.registers[register1] &= c8.registers[register2]
+	if c8.quirks.ResetVF {
+		c8.registers[0xF] = 0
+	}
 }
 
 func (c8 *Chip8) or(register1 uint16, register2 uint16) {
 	c8.registers[register1] |= c8.registers[register2]
+	if c8.quirks.ResetVF {
+		c8.registers[0xF] = 0
+	}
 }
 
 func (c8 *Chip8) xor(register1 uint16, register2 uint16) {
 	c8.registers[register1] ^= c8.registers[register2]
+	if c8.quirks.ResetVF {
+		c8.registers[0xF] = 0
+	}
 }
 
-func (c8 *Chip8) shiftRight(register1 uint16) {
+func (c8 *Chip8) shiftRight(register1 uint16, register2 uint16) {
+	if c8.quirks.ShiftUsesVY {
+		c8.registers[register1] = c8.registers[register2]
+	}
 	c8.registers[0xF] = c8.registers[register1] & 0x1
 	c8.registers[register1] /= 2
 }
 
-func (c8 *Chip8) shiftLeft(register1 uint16) {
+func (c8 *Chip8) shiftLeft(register1 uint16, register2 uint16) {
+	if c8.quirks.ShiftUsesVY {
+		c8.registers[register1] = c8.registers[register2]
+	}
 	c8.registers[0xF] = (c8.registers[register1] & 0x80) >> 7
 	c8.registers[register1] *= 2
 }
@@ -367,30 +498,37 @@ func (c8 *Chip8) Tick() error {
 		return err
 	}
 
+	if c8.tracer != nil {
+		c8.trace(instruction)
+	}
+	c8.cycles++
+
 	goToNextInstruction := true
 
 	switch instruction.Command {
+	case CmdClear:
+		c8.clearScreen()
 	case CmdSetRegister:
 		c8.setRegister(instruction.Arguments[0], uint8(instruction.Arguments[1]))
 	case CmdSetI:
 		c8.setI(instruction.Arguments[0])
 	case CmdDisplaySprite:
-		c8.drawSprite(instruction.Arguments[0], instruction.Arguments[1], instruction.Arguments[2])
+		err = c8.drawSprite(instruction.Arguments[0], instruction.Arguments[1], instruction.Arguments[2])
 	case CmdCallSubRoutine:
-		c8.callSubroutine(instruction.Arguments[0])
+		err = c8.callSubroutine(instruction.Arguments[0])
 		goToNextInstruction = false
 	case CmdStoreBCD:
-		c8.storeBCD(instruction.Arguments[0])
+		err = c8.storeBCD(instruction.Arguments[0])
 	case CmdReadMemoryRange:
-		c8.readMemoryRange(instruction.Arguments[0])
+		err = c8.readMemoryRange(instruction.Arguments[0])
 	case CmdReadRegisterRange:
-		c8.readRegisterRange(instruction.Arguments[0])
+		err = c8.readRegisterRange(instruction.Arguments[0])
 	case CmdSetIToFont:
-		c8.setLocationToFont(instruction.Arguments[0])
+		err = c8.setLocationToFont(instruction.Arguments[0])
 	case CmdAddToRegister:
 		c8.addToRegister(instruction.Arguments[0], uint8(instruction.Arguments[1]))
 	case CmdReturn:
-		c8.returnFromSubroutine()
+		err = c8.returnFromSubroutine()
 	case CmdSetDelayTimer:
 		c8.setDelayTimer(instruction.Arguments[0])
 	case CmdSetSoundTimer:
@@ -411,9 +549,9 @@ func (c8 *Chip8) Tick() error {
 	case CmdRandom:
 		c8.random(instruction.Arguments[0], uint8(instruction.Arguments[1]))
 	case CmdSkipIfKeyNotPressed:
-		c8.skipIfKeyPressed(instruction.Arguments[0], false)
+		err = c8.skipIfKeyPressed(instruction.Arguments[0], false)
 	case CmdSkipIfKeyPressed:
-		c8.skipIfKeyPressed(instruction.Arguments[0], true)
+		err = c8.skipIfKeyPressed(instruction.Arguments[0], true)
 	case CmdAnd:
 		c8.and(instruction.Arguments[0], instruction.Arguments[1])
 	case CmdOr:
@@ -429,9 +567,9 @@ func (c8 *Chip8) Tick() error {
 	case CmdCopyRegister:
 		c8.copyRegister(instruction.Arguments[0], instruction.Arguments[1])
 	case CmdShiftRight:
-		c8.shiftRight(instruction.Arguments[0])
+		c8.shiftRight(instruction.Arguments[0], instruction.Arguments[1])
 	case CmdShiftLeft:
-		c8.shiftLeft(instruction.Arguments[0])
+		c8.shiftLeft(instruction.Arguments[0], instruction.Arguments[1])
 	case CmdJumpV0Addr:
 		c8.jumpV0AndAddr(instruction.Arguments[0])
 		goToNextInstruction = false
@@ -441,6 +579,13 @@ func (c8 *Chip8) Tick() error {
 		goToNextInstruction = c8.waitForKey(instruction.Arguments[0])
 	}
 
+	// Leave the program counter on the failed instruction so it can be inspected
+	if err != nil {
+		log.Println(err)
+		c8.Pause()
+		return err
+	}
+
 	if c8.delayTimer > 0 {
 		c8.delayTimer--
 	}
@@ -463,6 +608,10 @@ func (c8 *Chip8) Pause() {
 	c8.halted = true
 }
 
+func (c8 *Chip8) Resume() {
+	c8.halted = false
+}
+
 func (c8 *Chip8) GetScreen() *[64][32]uint8 {
 	return &c8.screen
 }
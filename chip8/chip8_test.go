@@ -60,18 +60,9 @@ func Test2nnnSubroutineCanCallSubRoutine(t *testing.T) {
 	}
 }
 
-func Test2nnnSubroutineWillThrowStackOverflows(t *testing.T) {
-	// Our subroutine should be entered and returned from, avoiding any 0x0000 which would throw errors
+func Test2nnnSubroutineReturnsStackOverflowError(t *testing.T) {
 	// 200   CALL 202
 	// 202   CALL 200
-
-	// Will be called at end to check we panicked
-	defer func() {
-		if r := recover(); r == nil {
-			t.Errorf("The code did not panic")
-		}
-	}()
-
 	chip8, _ := createTestChip8([]uint8{0x22, 0x02, 0x22, 0x00})
 
 	var err error = nil
@@ -79,8 +70,42 @@ func Test2nnnSubroutineWillThrowStackOverflows(t *testing.T) {
 		err = chip8.Tick()
 	}
 
-	if err != nil {
-		t.Error("Should not get an error, should panic")
+	if err != ErrStackOverflow {
+		t.Errorf("Expected a stack overflow error, got %v", err)
+	}
+	if !chip8.IsHalted() {
+		t.Errorf("CPU should be paused after an error")
+	}
+}
+
+func Test00EEReturnWithEmptyStackReturnsError(t *testing.T) {
+	chip8, _ := createTestChip8([]uint8{0x00, 0xEE})
+
+	if err := chip8.Tick(); err != ErrStackUnderflow {
+		t.Errorf("Expected a stack underflow error, got %v", err)
+	}
+	if chip8.programCounter != 0x200 {
+		t.Errorf("programCounter was not set correctly. Expected %d, got %d", 0x200, chip8.programCounter)
+	}
+}
+
+// 00E0 - Clear the screen
+func Test00E0ClearsScreen(t *testing.T) {
+	chip8, _ := createTestChip8([]uint8{0x00, 0xE0})
+	chip8.screen[3][4] = 1
+
+	if err := chip8.Tick(); err != nil {
+		t.Fatal(err)
+	}
+
+	if chip8.screen != ([64][32]uint8{}) {
+		t.Errorf("Screen was not cleared")
+	}
+	if !chip8.dirty[3][4] || chip8.dirty[0][0] {
+		t.Errorf("Only the cleared pixel should be dirty")
+	}
+	if chip8.programCounter != 0x202 {
+		t.Errorf("PC was not set correctly. Expected 0x%X, got 0x%X", 0x202, chip8.programCounter)
 	}
 }
 
@@ -951,6 +976,45 @@ func TestDxynDrawCollision(t *testing.T) {
 	}
 }
 
+func TestDxynWrapsSpritePastRightEdge(t *testing.T) {
+	chip8, _ := createTestChip8([]uint8{0xD0, 0x11})
+	chip8.memory[0] = 0xFF
+	chip8.registers[0] = 60
+	chip8.registers[1] = 10
+
+	if err := chip8.Tick(); err != nil {
+		t.Fatalf("Should not get an error, got %v", err)
+	}
+
+	for _, x := range []int{60, 63, 0, 3} {
+		if chip8.screen[x][10] != 1 {
+			t.Errorf("Pixel %d 10 was unexpectedly not set", x)
+		}
+	}
+}
+
+func TestDxynLargeXWraps(t *testing.T) {
+	chip8, _ := createTestChip8([]uint8{0xD0, 0x11})
+	chip8.memory[0] = 0x80
+	chip8.registers[0] = 0xFF
+
+	if err := chip8.Tick(); err != nil {
+		t.Fatalf("Should not get an error, got %v", err)
+	}
+	if chip8.screen[63][0] != 1 {
+		t.Errorf("Pixel 63 0 was unexpectedly not set")
+	}
+}
+
+func TestDxynSpriteOutOfMemoryReturnsError(t *testing.T) {
+	chip8, _ := createTestChip8([]uint8{0xD0, 0x15})
+	chip8.memoryRegister = 0xFFE
+
+	if err := chip8.Tick(); err != ErrMemoryOutOfBounds {
+		t.Errorf("Expected a memory out of bounds error, got %v", err)
+	}
+}
+
 // Ex9E
 func TestEx9ECommandSkippedIfKeyPressed(t *testing.T) {
 	chip8, display := createTestChip8([]uint8{0xE0, 0x9E, 0x00, 0x00, 0x60, 0x23})
@@ -977,19 +1041,14 @@ func TestEx9ECommandSkippedIfKeyPressedKey2(t *testing.T) {
 	}
 }
 
-func TestEx9EPanicsIfCheckingInvalidKey(t *testing.T) {
-	defer func() {
-		if r := recover(); r == nil {
-			t.Errorf("The code did not panic")
-		}
-	}()
-
+func TestEx9EReturnsErrorIfCheckingInvalidKey(t *testing.T) {
 	chip8, display := createTestChip8([]uint8{0xE1, 0x9E, 0x00, 0x00, 0x60, 0x23})
 	chip8.registers[1] = 0xFF // doesn't exist
 	display.keysDown[2] = true
 
-	chip8.Tick()
-	chip8.Tick()
+	if err := chip8.Tick(); err != ErrInvalidKey {
+		t.Errorf("Expected an invalid key error, got %v", err)
+	}
 }
 
 func TestEx9ECommandNotSkippedIfKeyNotPressed(t *testing.T) {
@@ -1039,19 +1098,14 @@ func TestExA1CommandSkippedIfKeyNotPressedKey2(t *testing.T) {
 	}
 }
 
-func TestExA1PanicsIfCheckingInvalidKey(t *testing.T) {
-	defer func() {
-		if r := recover(); r == nil {
-			t.Errorf("The code did not panic")
-		}
-	}()
-
+func TestExA1ReturnsErrorIfCheckingInvalidKey(t *testing.T) {
 	chip8, display := createTestChip8([]uint8{0xE1, 0xA1, 0x00, 0x00, 0x60, 0x23})
 	chip8.registers[1] = 0xFF // doesn't exist
 	display.keysDown[2] = true
 
-	chip8.Tick()
-	chip8.Tick()
+	if err := chip8.Tick(); err != ErrInvalidKey {
+		t.Errorf("Expected an invalid key error, got %v", err)
+	}
 }
 
 func TestExA1CommandNotSkippedIfKeyPressed(t *testing.T) {
@@ -1252,16 +1306,12 @@ func TestFx29GoToSpriteF(t *testing.T) {
 }
 
 func TestFx29GoToSpriteOutOfBounds(t *testing.T) {
-	defer func() {
-		if r := recover(); r == nil {
-			t.Errorf("The code did not panic")
-		}
-	}()
-
 	chip8, _ := createTestChip8([]uint8{0xF0, 0x29})
 	chip8.registers[0] = 0x10
 
-	chip8.Tick()
+	if err := chip8.Tick(); err != ErrFontOutOfBounds {
+		t.Errorf("Expected a font out of bounds error, got %v", err)
+	}
 }
 
 // Fx33
@@ -1350,33 +1400,52 @@ func TestFx33GetBCDINon0(t *testing.T) {
 	}
 }
 
+func TestFx33OutOfMemoryReturnsError(t *testing.T) {
+	chip8, _ := createTestChip8([]uint8{0xF0, 0x33})
+	chip8.memoryRegister = 0xFFE
+
+	if err := chip8.Tick(); err != ErrMemoryOutOfBounds {
+		t.Errorf("Expected a memory out of bounds error, got %v", err)
+	}
+}
+
 // Fx55
-func TestFx55Store0RegistersInMemory(t *testing.T) {
+func TestFx55StoreV0InMemory(t *testing.T) {
 	chip8, _ := createTestChip8([]uint8{0xF0, 0x55})
 	chip8.memory[0] = 10
+	chip8.memory[1] = 10
 	chip8.registers[0] = 7
+	chip8.registers[1] = 8
 
 	chip8.Tick()
 
-	if chip8.memory[0] != 10 {
-		t.Errorf("memory[0] was not set correctly. Expected %d, got %d", 10, chip8.memory[0])
+	if chip8.memory[0] != 7 {
+		t.Errorf("memory[0] was not set correctly. Expected %d, got %d", 7, chip8.memory[0])
+	}
+	if chip8.memory[1] != 10 {
+		t.Errorf("memory[1] was not set correctly. Expected %d, got %d", 10, chip8.memory[1])
 	}
 }
 
-func TestFx55Store1RegistersInMemory(t *testing.T) {
+func TestFx55StoreV0AndV1InMemory(t *testing.T) {
 	chip8, _ := createTestChip8([]uint8{0xF1, 0x55})
 	chip8.memory[0] = 10
 	chip8.memory[1] = 10
+	chip8.memory[2] = 10
 	chip8.registers[0] = 7
 	chip8.registers[1] = 8
+	chip8.registers[2] = 9
 
 	chip8.Tick()
 
 	if chip8.memory[0] != 7 {
 		t.Errorf("memory[0] was not set correctly. Expected %d, got %d", 7, chip8.memory[0])
 	}
-	if chip8.memory[1] != 10 {
-		t.Errorf("memory[1] was not set correctly. Expected %d, got %d", 10, chip8.memory[1])
+	if chip8.memory[1] != 8 {
+		t.Errorf("memory[1] was not set correctly. Expected %d, got %d", 8, chip8.memory[1])
+	}
+	if chip8.memory[2] != 10 {
+		t.Errorf("memory[2] was not set correctly. Expected %d, got %d", 10, chip8.memory[2])
 	}
 }
 
@@ -1387,36 +1456,50 @@ func TestFx55StoreAllRegistersInMemory(t *testing.T) {
 
 	chip8.Tick()
 
-	for i := 0; i < 0xF; i++ {
+	for i := 0; i <= 0xF; i++ {
 		if chip8.memory[i] != uint8(i+1) {
 			t.Errorf("memory[%d] was not set correctly. Expected %d, got %d", i, i+1, chip8.memory[i])
 		}
 	}
 }
 
-// FxFx65
-func TestFx65Read0RegistersFromMemory(t *testing.T) {
+// Fx65
+func TestFx65ReadV0FromMemory(t *testing.T) {
 	chip8, _ := createTestChip8([]uint8{0xF0, 0x65})
 	chip8.memory[0] = 10
+	chip8.memory[1] = 11
 	chip8.registers[0] = 7
+	chip8.registers[1] = 8
 
 	chip8.Tick()
 
-	if chip8.registers[0] != 7 {
-		t.Errorf("registers[0] was not set correctly. Expected %d, got %d", 7, chip8.registers[0])
+	if chip8.registers[0] != 10 {
+		t.Errorf("registers[0] was not set correctly. Expected %d, got %d", 10, chip8.registers[0])
+	}
+	if chip8.registers[1] != 8 {
+		t.Errorf("registers[1] was not set correctly. Expected %d, got %d", 8, chip8.registers[1])
 	}
 }
 
-func TestFx65Read1RegistersFromMemory(t *testing.T) {
+func TestFx65ReadV0AndV1FromMemory(t *testing.T) {
 	chip8, _ := createTestChip8([]uint8{0xF1, 0x65})
 	chip8.memory[0] = 10
+	chip8.memory[1] = 11
+	chip8.memory[2] = 12
 	chip8.registers[0] = 7
+	chip8.registers[2] = 9
 
 	chip8.Tick()
 
 	if chip8.registers[0] != 10 {
 		t.Errorf("registers[0] was not set correctly. Expected %d, got %d", 10, chip8.registers[0])
 	}
+	if chip8.registers[1] != 11 {
+		t.Errorf("registers[1] was not set correctly. Expected %d, got %d", 11, chip8.registers[1])
+	}
+	if chip8.registers[2] != 9 {
+		t.Errorf("registers[2] was not set correctly. Expected %d, got %d", 9, chip8.registers[2])
+	}
 }
 
 func TestFx65ReadAllRegistersFromMemory(t *testing.T) {
@@ -1426,13 +1509,35 @@ func TestFx65ReadAllRegistersFromMemory(t *testing.T) {
 
 	chip8.Tick()
 
-	for i := 0; i < 0xF; i++ {
+	for i := 0; i <= 0xF; i++ {
 		if chip8.registers[i] != uint8(i+1) {
 			t.Errorf("registers[%d] was not set correctly. Expected %d, got %d", i, i+1, chip8.registers[i])
 		}
 	}
 }
 
+func TestFx55AndFx65OutOfMemoryReturnError(t *testing.T) {
+	for _, op := range []uint8{0x55, 0x65} {
+		chip8, _ := createTestChip8([]uint8{0xFF, op})
+		chip8.memoryRegister = 0xFF8
+
+		if err := chip8.Tick(); err != ErrMemoryOutOfBounds {
+			t.Errorf("Expected a memory out of bounds error for 0x%02X, got %v", op, err)
+		}
+	}
+}
+
+func TestProgramCounterOutOfMemoryReturnsError(t *testing.T) {
+	// JP V0, 0xFFF
+	chip8, _ := createTestChip8([]uint8{0xBF, 0xFF})
+	chip8.registers[0] = 1
+
+	chip8.Tick()
+	if err := chip8.Tick(); err != ErrProgramCounterOutOfBounds {
+		t.Errorf("Expected a program counter out of bounds error, got %v", err)
+	}
+}
+
 func TestTickDecaysDelayTimer(t *testing.T) {
 	// Endless loop
 	chip8, _ := createTestChip8([]uint8{0x12, 0x00})
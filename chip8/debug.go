@@ -0,0 +1,70 @@
+package chip8
+
+import (
+	"errors"
+)
+
+// Registers is a copy of the CPU registers, used by debuggers to inspect and change state
+type Registers struct {
+	V  [16]uint8
+	I  uint16
+	PC uint16
+	SP uint8 // Number of return addresses on the stack
+	DT uint8
+	ST uint8
+}
+
+// Registers returns a copy of the current registers
+func (c8 *Chip8) Registers() Registers {
+	return Registers{
+		V:  c8.registers,
+		I:  c8.memoryRegister,
+		PC: c8.programCounter,
+		SP: uint8(c8.stackPointer + 1),
+		DT: c8.delayTimer,
+		ST: c8.soundTimer,
+	}
+}
+
+// SetRegisters overwrites all the registers.
+// Will return an error if SP is deeper than the stack; nothing is changed in that case.
+func (c8 *Chip8) SetRegisters(registers Registers) error {
+	if int(registers.SP) > len(c8.stack) {
+		return errors.New("SP is larger than the stack")
+	}
+
+	c8.registers = registers.V
+	c8.memoryRegister = registers.I
+	c8.programCounter = registers.PC
+	c8.stackPointer = int8(registers.SP) - 1
+	c8.delayTimer = registers.DT
+	c8.soundTimer = registers.ST
+
+	return nil
+}
+
+// ReadMemory copies length bytes of memory starting at address.
+// Will return an error if the range goes past the end of memory.
+func (c8 *Chip8) ReadMemory(address uint16, length int) ([]uint8, error) {
+	if err := checkMemoryRange(address, length); err != nil || length < 0 {
+		return nil, ErrMemoryOutOfBounds
+	}
+
+	return append([]uint8{}, c8.memory[address:int(address)+length]...), nil
+}
+
+// WriteMemory copies data into memory starting at address.
+// Will return an error if the data doesn't fit; nothing is written in that case.
+func (c8 *Chip8) WriteMemory(address uint16, data []uint8) error {
+	if err := checkMemoryRange(address, len(data)); err != nil {
+		return err
+	}
+
+	copy(c8.memory[address:], data)
+	return nil
+}
+
+// Stack returns the return addresses on the stack, oldest first. Each is the address of the CALL that pushed it.
+func (c8 *Chip8) Stack() []uint16 {
+	return append([]uint16{}, c8.stack[:c8.stackPointer+1]...)
+}
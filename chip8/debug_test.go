@@ -0,0 +1,66 @@
+package chip8
+
+import (
+	"testing"
+)
+
+func TestSetRegistersRoundTrip(t *testing.T) {
+	chip8, _ := createTestChip8([]uint8{})
+	registers := Registers{I: 0x300, PC: 0x210, SP: 2, DT: 5, ST: 6}
+	registers.V[0xA] = 7
+
+	if err := chip8.SetRegisters(registers); err != nil {
+		t.Fatalf("Should not get an error, got %v", err)
+	}
+
+	if chip8.Registers() != registers {
+		t.Errorf("Registers were not set correctly. Expected %+v, got %+v", registers, chip8.Registers())
+	}
+	if chip8.stackPointer != 1 {
+		t.Errorf("stackPointer was not set correctly. Expected %d, got %d", 1, chip8.stackPointer)
+	}
+}
+
+func TestSetRegistersRejectsDeepStack(t *testing.T) {
+	chip8, _ := createTestChip8([]uint8{})
+
+	if err := chip8.SetRegisters(Registers{SP: 17}); err == nil {
+		t.Errorf("Expected an error for SP past the end of the stack")
+	}
+}
+
+func TestReadWriteMemory(t *testing.T) {
+	chip8, _ := createTestChip8([]uint8{})
+
+	if err := chip8.WriteMemory(0xFFE, []uint8{1, 2}); err != nil {
+		t.Fatalf("Should not get an error, got %v", err)
+	}
+	data, err := chip8.ReadMemory(0xFFE, 2)
+	if err != nil || data[0] != 1 || data[1] != 2 {
+		t.Errorf("Memory was not read correctly. Got %v, %v", data, err)
+	}
+
+	if err := chip8.WriteMemory(0xFFF, []uint8{1, 2}); err != ErrMemoryOutOfBounds {
+		t.Errorf("Expected a memory out of bounds error, got %v", err)
+	}
+	if _, err := chip8.ReadMemory(0xFFF, 2); err != ErrMemoryOutOfBounds {
+		t.Errorf("Expected a memory out of bounds error, got %v", err)
+	}
+}
+
+func TestStack(t *testing.T) {
+	// CALL 0x204; (skipped); CALL 0x208; (skipped)
+	chip8, _ := createTestChip8([]uint8{0x22, 0x04, 0x00, 0x00, 0x22, 0x08})
+
+	if len(chip8.Stack()) != 0 {
+		t.Errorf("Stack should start empty, got %v", chip8.Stack())
+	}
+
+	chip8.Tick()
+	chip8.Tick()
+
+	stack := chip8.Stack()
+	if len(stack) != 2 || stack[0] != 0x200 || stack[1] != 0x204 {
+		t.Errorf("Stack was not set correctly. Expected [200 204], got %X", stack)
+	}
+}
@@ -0,0 +1,188 @@
+package chip8
+
+import (
+	"chip8/chip8/internal/reference"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"math/rand"
+	"path/filepath"
+	"testing"
+)
+
+// lockstep runs a ROM on Chip8 and the reference interpreter together, comparing their whole state after every instruction. keys gives
+// the keypad state before each step. Returns a description of the first divergence, or "" if they agree until either stops with an
+// error or the steps run out.
+func lockstep(data []uint8, steps int, keys func(step int) [16]bool) string {
+	display := MockDisplay{}
+	c8 := New(&display)
+	if _, err := c8.LoadFromMemory(data); err != nil {
+		return ""
+	}
+	ref, err := reference.New(data)
+	if err != nil {
+		return "reference could not load a ROM that Chip8 did: " + err.Error()
+	}
+	// Random numbers can't be lined up, so the reference is given whatever Chip8 produced. Chip8 has already applied the mask, which
+	// makes no difference when the reference applies it again.
+	ref.Random = func() uint8 {
+		return c8.registers[ref.Memory[ref.PC]&0xF]
+	}
+
+	for step := 0; step < steps; step++ {
+		pc := c8.programCounter
+		op := uint16(0)
+		if int(pc)+1 < MemorySize {
+			op = uint16(c8.memory[pc])<<8 | uint16(c8.memory[pc+1])
+		}
+		where := fmt.Sprintf("step %d at 0x%03X (%04X %s)", step, pc, op, Disassemble(op))
+
+		display.keysDown = keys(step)
+		ref.Keys = display.keysDown
+
+		c8Err := c8.Tick()
+		refErr := ref.Step()
+
+		if c8Err != nil || refErr != nil {
+			if (c8Err == nil) != (refErr == nil) {
+				return fmt.Sprintf("%s: Chip8 returned %v but the reference returned %v", where, c8Err, refErr)
+			}
+			return ""
+		}
+		ref.TickTimers()
+
+		if diff := compareState(c8, ref); diff != "" {
+			return where + ": " + diff
+		}
+	}
+
+	return ""
+}
+
+// compareState describes the first difference between Chip8 and the reference, or returns ""
+func compareState(c8 *Chip8, ref *reference.Machine) string {
+	if c8.registers != ref.V {
+		return fmt.Sprintf("registers differ: Chip8 % X, reference % X", c8.registers, ref.V)
+	}
+	if c8.memoryRegister != ref.I {
+		return fmt.Sprintf("I differs: Chip8 0x%03X, reference 0x%03X", c8.memoryRegister, ref.I)
+	}
+	if c8.programCounter != ref.PC {
+		return fmt.Sprintf("PC differs: Chip8 0x%03X, reference 0x%03X", c8.programCounter, ref.PC)
+	}
+	if stack := c8.stack[:c8.stackPointer+1]; fmt.Sprint(stack) != fmt.Sprint(ref.Stack) {
+		return fmt.Sprintf("stack differs: Chip8 %X, reference %X", stack, ref.Stack)
+	}
+	if c8.delayTimer != ref.DT || c8.soundTimer != ref.ST {
+		return fmt.Sprintf("timers differ: Chip8 DT=%d ST=%d, reference DT=%d ST=%d", c8.delayTimer, c8.soundTimer, ref.DT, ref.ST)
+	}
+	for i := range c8.memory {
+		if c8.memory[i] != ref.Memory[i] {
+			return fmt.Sprintf("memory differs at 0x%03X: Chip8 0x%02X, reference 0x%02X", i, c8.memory[i], ref.Memory[i])
+		}
+	}
+	for x := 0; x < 64; x++ {
+		for y := 0; y < 32; y++ {
+			if (c8.screen[x][y] != 0) != ref.Screen[y][x] {
+				return fmt.Sprintf("screen differs at %d,%d: Chip8 %d, reference %t", x, y, c8.screen[x][y], ref.Screen[y][x])
+			}
+		}
+	}
+
+	return ""
+}
+
+// randomKeys holds down a changing handful of keys so key handling gets exercised
+func randomKeys(seed int64) func(step int) [16]bool {
+	r := rand.New(rand.NewSource(seed))
+	keys := [16]bool{}
+
+	return func(step int) [16]bool {
+		if step%50 == 0 {
+			for i := range keys {
+				keys[i] = r.Intn(4) == 0
+			}
+		}
+		return keys
+	}
+}
+
+func TestDifferentialBundledROMs(t *testing.T) {
+	defer log.SetOutput(log.Writer())
+	log.SetOutput(ioutil.Discard)
+
+	paths, _ := filepath.Glob("../roms/*")
+	more, _ := filepath.Glob("../conformance/testdata/*.ch8")
+	for _, path := range append(paths, more...) {
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if diff := lockstep(data, 20000, randomKeys(1)); diff != "" {
+			t.Errorf("%s: %s", filepath.Base(path), diff)
+		}
+	}
+}
+
+// randomROM generates a program made only of valid instructions, with jumps and calls kept inside it, so runs get a long way before
+// hitting something that stops them
+func randomROM(r *rand.Rand, size int) []uint8 {
+	data := []uint8{}
+
+	for len(data) < size {
+		op := uint16(r.Intn(0x10000))
+		instr, err := parseInstruction(op)
+		if err != nil {
+			continue
+		}
+
+		switch instr.Command {
+		case CmdJump, CmdCallSubRoutine, CmdJumpV0Addr:
+			op = op&0xF000 | uint16(ProgramStart+r.Intn(size/2)*2)
+		}
+		data = append(data, uint8(op>>8), uint8(op))
+	}
+
+	return data
+}
+
+func TestDifferentialRandomROMs(t *testing.T) {
+	defer log.SetOutput(log.Writer())
+	log.SetOutput(ioutil.Discard)
+
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 500; i++ {
+		data := make([]uint8, 256)
+		r.Read(data)
+		if i%2 == 0 {
+			data = randomROM(r, 256)
+		}
+
+		if diff := lockstep(data, 1000, randomKeys(int64(i))); diff != "" {
+			t.Errorf("Random ROM %d: %s", i, diff)
+		}
+	}
+}
+
+func TestDifferentialReportsDivergences(t *testing.T) {
+	// LD V1, 5; LD [I], V1
+	data := []uint8{0x61, 0x05, 0xF1, 0x55}
+	noKeys := func(int) [16]bool { return [16]bool{} }
+
+	if diff := lockstep(data, 2, noKeys); diff != "" {
+		t.Errorf("Expected no divergence, got %s", diff)
+	}
+
+	c8, _ := createTestChip8(data)
+	ref, err := reference.New(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c8.memory[0x300] = 0x90
+
+	expected := "memory differs at 0x300: Chip8 0x90, reference 0x00"
+	if diff := compareState(c8, ref); diff != expected {
+		t.Errorf("Divergence was not reported correctly. Expected %q, got %q", expected, diff)
+	}
+}
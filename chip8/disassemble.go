@@ -0,0 +1,125 @@
+package chip8
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Formats for each command using the mnemonics from Cowgod's technical reference. Arguments are substituted in the same order they
+// are parsed in from the instruction definition.
+var disassemblyFormats = map[Command]string{
+	CmdAddToRegister:          "ADD V%X, 0x%02X",
+	CmdAdd:                    "ADD V%X, V%X",
+	CmdAddToI:                 "ADD I, V%X",
+	CmdAnd:                    "AND V%X, V%X",
+	CmdCall:                   "SYS 0x%03X",
+	CmdCallSubRoutine:         "CALL 0x%03X",
+	CmdCopyRegister:           "LD V%X, V%X",
+	CmdClear:                  "CLS",
+	CmdDisplaySprite:          "DRW V%X, V%X, %d",
+	CmdGetDelayTimer:          "LD V%X, DT",
+	CmdJump:                   "JP 0x%03X",
+	CmdJumpV0Addr:             "JP V0, 0x%03X",
+	CmdOr:                     "OR V%X, V%X",
+	CmdRandom:                 "RND V%X, 0x%02X",
+	CmdReadMemoryRange:        "LD V%X, [I]",
+	CmdReadRegisterRange:      "LD [I], V%X",
+	CmdReturn:                 "RET",
+	CmdSetDelayTimer:          "LD DT, V%X",
+	CmdSetI:                   "LD I, 0x%03X",
+	CmdSetIToFont:             "LD F, V%X",
+	CmdSetRegister:            "LD V%X, 0x%02X",
+	CmdSetSoundTimer:          "LD ST, V%X",
+	CmdShiftLeft:              "SHL V%X, V%X",
+	CmdShiftRight:             "SHR V%X, V%X",
+	CmdSkipIfEqual:            "SE V%X, 0x%02X",
+	CmdSkipIfEqualRegister:    "SE V%X, V%X",
+	CmdSkipIfKeyPressed:       "SKP V%X",
+	CmdSkipIfNotEqual:         "SNE V%X, 0x%02X",
+	CmdSkipIfNotEqualRegister: "SNE V%X, V%X",
+	CmdSkipIfKeyNotPressed:    "SKNP V%X",
+	CmdStoreBCD:               "LD B, V%X",
+	CmdSub:                    "SUB V%X, V%X",
+	CmdSubN:                   "SUBN V%X, V%X",
+	CmdWaitForKey:             "LD V%X, K",
+	CmdXOr:                    "XOR V%X, V%X",
+}
+
+// String returns the mnemonic for a command, e.g. "LD" or "DRW"
+func (cmd Command) String() string {
+	format, ok := disassemblyFormats[cmd]
+	if !ok {
+		return "???"
+	}
+
+	return strings.SplitN(format, " ", 2)[0]
+}
+
+// Syntax returns the general form of a command in the notation of Cowgod's technical reference, e.g. "DRW Vx, Vy, nibble"
+func (cmd Command) Syntax() string {
+	format, ok := disassemblyFormats[cmd]
+	if !ok {
+		return "???"
+	}
+
+	format = strings.Replace(format, "V%X", "Vx", 1)
+	return strings.NewReplacer("V%X", "Vy", "0x%02X", "byte", "0x%03X", "addr", "%d", "nibble").Replace(format)
+}
+
+// String returns the instruction in assembly form, e.g. "DRW V0, V1, 5"
+func (instr *Instruction) String() string {
+	format, ok := disassemblyFormats[instr.Command]
+	if !ok {
+		return "???"
+	}
+
+	args := []interface{}{}
+	for _, v := range instr.Arguments {
+		args = append(args, v)
+	}
+
+	return fmt.Sprintf(format, args...)
+}
+
+// Decode parses an instruction bytecode without running it, for tools that analyse ROMs.
+// Will return an error if the bytecode isn't a valid instruction.
+func Decode(val uint16) (*Instruction, error) {
+	return parseInstruction(val)
+}
+
+// Disassemble converts a single instruction bytecode into assembly. Anything that isn't a valid instruction is shown as raw data so a
+// listing of a ROM which mixes code and sprites can still be produced.
+func Disassemble(val uint16) string {
+	instr, err := parseInstruction(val)
+	if err != nil {
+		return fmt.Sprintf("DW 0x%04X", val)
+	}
+
+	return instr.String()
+}
+
+// DisassembleLine is a single line of a ROM listing
+type DisassembleLine struct {
+	Address uint16
+	Opcode  uint16
+	Text    string
+}
+
+// DisassembleROM produces a listing for a whole ROM, assuming it is loaded at the standard 0x200 start address. An odd trailing byte is
+// shown on its own as data.
+func DisassembleROM(data []uint8) []DisassembleLine {
+	lines := []DisassembleLine{}
+
+	for i := 0; i < len(data); i += 2 {
+		address := uint16(0x200 + i)
+		if i+1 >= len(data) {
+			lines = append(lines, DisassembleLine{Address: address, Opcode: uint16(data[i]), Text: fmt.Sprintf("DB 0x%02X", data[i])})
+			break
+		}
+
+		val := (uint16(data[i]) << 8) | uint16(data[i+1])
+		lines = append(lines, DisassembleLine{Address: address, Opcode: val, Text: Disassemble(val)})
+	}
+
+	return lines
+}
@@ -0,0 +1,75 @@
+package chip8
+
+import (
+	"testing"
+)
+
+func TestDisassembleInstructions(t *testing.T) {
+	cases := map[uint16]string{
+		0x00EE: "RET",
+		0x1204: "JP 0x204",
+		0x2ABC: "CALL 0xABC",
+		0x3A12: "SE VA, 0x12",
+		0x5120: "SE V1, V2",
+		0x6F0A: "LD VF, 0x0A",
+		0x8126: "SHR V1, V2",
+		0xA2F0: "LD I, 0x2F0",
+		0xB300: "JP V0, 0x300",
+		0xD125: "DRW V1, V2, 5",
+		0xE39E: "SKP V3",
+		0xF40A: "LD V4, K",
+		0xF533: "LD B, V5",
+		0xF655: "LD [I], V6",
+		0xF765: "LD V7, [I]",
+	}
+
+	for val, expected := range cases {
+		if got := Disassemble(val); got != expected {
+			t.Errorf("0x%04X was not disassembled correctly. Expected %q, got %q", val, expected, got)
+		}
+	}
+}
+
+func TestDisassembleUnknownInstructionIsData(t *testing.T) {
+	if got := Disassemble(0x0000); got != "DW 0x0000" {
+		t.Errorf("Unknown instruction was not disassembled correctly. Expected %q, got %q", "DW 0x0000", got)
+	}
+}
+
+func TestDisassembleROMAddressesAndTrailingByte(t *testing.T) {
+	lines := DisassembleROM([]uint8{0x60, 0x23, 0x12, 0x00, 0xFF})
+
+	if len(lines) != 3 {
+		t.Fatalf("Wrong number of lines. Expected %d, got %d", 3, len(lines))
+	}
+	if lines[1].Address != 0x202 || lines[1].Text != "JP 0x200" {
+		t.Errorf("Second line was not correct. Got 0x%03X %q", lines[1].Address, lines[1].Text)
+	}
+	if lines[2].Address != 0x204 || lines[2].Text != "DB 0xFF" {
+		t.Errorf("Trailing byte was not correct. Got 0x%03X %q", lines[2].Address, lines[2].Text)
+	}
+}
+
+func TestCommandString(t *testing.T) {
+	if CmdDisplaySprite.String() != "DRW" {
+		t.Errorf("Mnemonic was not correct. Expected %q, got %q", "DRW", CmdDisplaySprite.String())
+	}
+	if CmdUndefined.String() != "???" {
+		t.Errorf("Mnemonic was not correct. Expected %q, got %q", "???", CmdUndefined.String())
+	}
+}
+
+func TestCommandSyntax(t *testing.T) {
+	tests := map[Command]string{
+		CmdDisplaySprite: "DRW Vx, Vy, nibble",
+		CmdAddToRegister: "ADD Vx, byte",
+		CmdJumpV0Addr:    "JP V0, addr",
+		CmdClear:         "CLS",
+		CmdUndefined:     "???",
+	}
+	for cmd, expected := range tests {
+		if cmd.Syntax() != expected {
+			t.Errorf("Syntax was not correct. Expected %q, got %q", expected, cmd.Syntax())
+		}
+	}
+}
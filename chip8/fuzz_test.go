@@ -0,0 +1,123 @@
+package chip8
+
+import (
+	"io/ioutil"
+	"log"
+	"path/filepath"
+	"testing"
+)
+
+// Enough to get well into most ROMs while keeping each fuzz run quick
+const fuzzTicks = 2000
+
+// addSeedROMs adds the bundled ROMs to a fuzz corpus
+func addSeedROMs(f *testing.F, add func(data []uint8)) {
+	paths := []string{}
+	for _, pattern := range []string{"../roms/*", "../conformance/testdata/*.ch8"} {
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			f.Fatal(err)
+		}
+		paths = append(paths, matches...)
+	}
+
+	for _, path := range paths {
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			f.Fatal(err)
+		}
+		add(data)
+	}
+}
+
+// quirksFromBits turns fuzzed bits into a set of quirks so every combination gets exercised
+func quirksFromBits(bits uint8) Quirks {
+	return Quirks{
+		ShiftUsesVY:          bits&0x01 != 0,
+		LoadStoreIncrementsI: bits&0x02 != 0,
+		JumpUsesVX:           bits&0x04 != 0,
+		ResetVF:              bits&0x08 != 0,
+		ClipSprites:          bits&0x10 != 0,
+	}
+}
+
+// FuzzTick runs random ROMs. Whatever the ROM does the CPU must only ever return errors, never panic.
+func FuzzTick(f *testing.F) {
+	addSeedROMs(f, func(data []uint8) {
+		f.Add(data, uint8(0), uint16(0))
+		f.Add(data, uint8(0xFF), uint16(0xFFFF))
+	})
+	// Programs that used to panic
+	f.Add([]uint8{0x22, 0x02, 0x22, 0x00}, uint8(0), uint16(0)) // Stack overflow
+	f.Add([]uint8{0x60, 0x10, 0xF0, 0x29}, uint8(0), uint16(0)) // Font out of range
+	f.Add([]uint8{0x60, 0xFF, 0xD0, 0x05}, uint8(0), uint16(0)) // Sprite X out of range
+	f.Add([]uint8{0xAF, 0xFF, 0xFF, 0x65}, uint8(0), uint16(0)) // Read past the end of memory
+	f.Add([]uint8{0x00, 0xEE}, uint8(0), uint16(0))             // Return with an empty stack
+
+	defer log.SetOutput(log.Writer())
+	log.SetOutput(ioutil.Discard)
+	f.Fuzz(func(t *testing.T, data []uint8, quirks uint8, keys uint16) {
+		display := MockDisplay{}
+		for i := range display.keysDown {
+			display.keysDown[i] = keys&(1<<i) != 0
+		}
+
+		chip8 := New(&display)
+		chip8.SetQuirks(quirksFromBits(quirks))
+		if _, err := chip8.LoadFromMemory(data); err != nil {
+			return
+		}
+
+		for i := 0; i < fuzzTicks; i++ {
+			if err := chip8.Tick(); err != nil {
+				if !chip8.IsHalted() {
+					t.Errorf("CPU should be paused after an error")
+				}
+				return
+			}
+		}
+	})
+}
+
+// FuzzParseInstruction checks every opcode either parses into something that can be disassembled or returns an error
+func FuzzParseInstruction(f *testing.F) {
+	addSeedROMs(f, func(data []uint8) {
+		for i := 0; i+1 < len(data); i += 2 {
+			f.Add(uint16(data[i])<<8 | uint16(data[i+1]))
+		}
+	})
+
+	f.Fuzz(func(t *testing.T, val uint16) {
+		instr, err := parseInstruction(val)
+		if err != nil {
+			if instr != nil {
+				t.Errorf("Expected no instruction with an error for 0x%04X", val)
+			}
+			return
+		}
+
+		if instr.String() == "???" {
+			t.Errorf("0x%04X parsed to a command with no disassembly", val)
+		}
+		for _, arg := range instr.Arguments {
+			if arg > 0xFFF {
+				t.Errorf("0x%04X parsed to an argument larger than 12 bits: 0x%X", val, arg)
+			}
+		}
+	})
+}
+
+// FuzzDifferential runs random ROMs on Chip8 and the reference interpreter in lockstep, see differential_test.go
+func FuzzDifferential(f *testing.F) {
+	addSeedROMs(f, func(data []uint8) {
+		f.Add(data, int64(0))
+	})
+
+	defer log.SetOutput(log.Writer())
+	log.SetOutput(ioutil.Discard)
+	f.Fuzz(func(t *testing.T, data []uint8, seed int64) {
+		if diff := lockstep(data, fuzzTicks, randomKeys(seed)); diff != "" {
+			t.Error(diff)
+		}
+	})
+}
@@ -18,6 +18,11 @@ type InstructionArgument struct {
 }
 
 var Instructions = []InstructionDefinition{
+	{
+		Command: CmdClear,
+		Mask:    0xFFFF,
+		Match:   0x00E0,
+	},
 	{
 		Command: CmdReturn,
 		Mask:    0xFFFF,
@@ -0,0 +1,291 @@
+// Package reference is a deliberately simple chip8 interpreter written straight from Cowgod's technical reference
+// (http://devernay.free.fr/hacks/chip8/C8TECH10.HTM). It shares no code with the real interpreter and favours being obviously correct
+// over being fast or flexible, so the two can be run side by side to catch mistakes in either. It is only used by tests.
+package reference
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Where the font is loaded, and where programs start
+const (
+	FontStart    = 0x000
+	ProgramStart = 0x200
+)
+
+var font = []uint8{
+	0xF0, 0x90, 0x90, 0x90, 0xF0, // 0
+	0x20, 0x60, 0x20, 0x20, 0x70, // 1
+	0xF0, 0x10, 0xF0, 0x80, 0xF0, // 2
+	0xF0, 0x10, 0xF0, 0x10, 0xF0, // 3
+	0x90, 0x90, 0xF0, 0x10, 0x10, // 4
+	0xF0, 0x80, 0xF0, 0x10, 0xF0, // 5
+	0xF0, 0x80, 0xF0, 0x90, 0xF0, // 6
+	0xF0, 0x10, 0x20, 0x40, 0x40, // 7
+	0xF0, 0x90, 0xF0, 0x90, 0xF0, // 8
+	0xF0, 0x90, 0xF0, 0x10, 0xF0, // 9
+	0xF0, 0x90, 0xF0, 0x90, 0x90, // A
+	0xE0, 0x90, 0xE0, 0x90, 0xE0, // B
+	0xF0, 0x80, 0x80, 0x80, 0xF0, // C
+	0xE0, 0x90, 0x90, 0x90, 0xE0, // D
+	0xF0, 0x80, 0xF0, 0x80, 0xF0, // E
+	0xF0, 0x80, 0xF0, 0x80, 0x80, // F
+}
+
+// Machine is the whole state of the interpreter. Everything is exported so tests can inspect it and line it up with another interpreter.
+type Machine struct {
+	Memory [4096]uint8
+	V      [16]uint8
+	I      uint16
+	PC     uint16
+	Stack  []uint16 // Return addresses, most recent last
+	DT     uint8
+	ST     uint8
+	Screen [32][64]bool // Indexed by row then column
+	Keys   [16]bool
+
+	// Random returns the random byte used by Cxkk
+	Random func() uint8
+}
+
+// New creates a machine with the font loaded and the program counter at the start of the program
+func New(program []uint8) (*Machine, error) {
+	if len(program) > len(Machine{}.Memory)-ProgramStart {
+		return nil, errors.New("Program is too large")
+	}
+
+	m := Machine{PC: ProgramStart, Random: func() uint8 { return 0 }}
+	copy(m.Memory[FontStart:], font)
+	copy(m.Memory[ProgramStart:], program)
+
+	return &m, nil
+}
+
+// Step fetches and executes a single instruction. Timers are not touched; call TickTimers at whatever rate they should run.
+// Will return an error for anything the spec doesn't define, in which case nothing is changed.
+func (m *Machine) Step() error {
+	if int(m.PC)+1 >= len(m.Memory) {
+		return errors.New("PC out of memory")
+	}
+
+	op := uint16(m.Memory[m.PC])<<8 | uint16(m.Memory[m.PC+1])
+	nnn := op & 0x0FFF
+	kk := uint8(op & 0x00FF)
+	n := int(op & 0x000F)
+	x := int(op>>8) & 0xF
+	y := int(op>>4) & 0xF
+	next := m.PC + 2
+
+	switch {
+	case op == 0x00E0: // CLS
+		m.Screen = [32][64]bool{}
+
+	case op == 0x00EE: // RET
+		if len(m.Stack) == 0 {
+			return errors.New("RET with empty stack")
+		}
+		next = m.Stack[len(m.Stack)-1]
+		m.Stack = m.Stack[:len(m.Stack)-1]
+		// Execution carries on after the CALL
+		next += 2
+
+	case op&0xF000 == 0x1000: // JP addr
+		next = nnn
+
+	case op&0xF000 == 0x2000: // CALL addr
+		if len(m.Stack) == 16 {
+			return errors.New("Stack overflow")
+		}
+		m.Stack = append(m.Stack, m.PC)
+		next = nnn
+
+	case op&0xF000 == 0x3000: // SE Vx, byte
+		if m.V[x] == kk {
+			next += 2
+		}
+
+	case op&0xF000 == 0x4000: // SNE Vx, byte
+		if m.V[x] != kk {
+			next += 2
+		}
+
+	case op&0xF00F == 0x5000: // SE Vx, Vy
+		if m.V[x] == m.V[y] {
+			next += 2
+		}
+
+	case op&0xF000 == 0x6000: // LD Vx, byte
+		m.V[x] = kk
+
+	case op&0xF000 == 0x7000: // ADD Vx, byte
+		m.V[x] += kk
+
+	case op&0xF00F == 0x8000: // LD Vx, Vy
+		m.V[x] = m.V[y]
+
+	case op&0xF00F == 0x8001: // OR Vx, Vy
+		m.V[x] |= m.V[y]
+
+	case op&0xF00F == 0x8002: // AND Vx, Vy
+		m.V[x] &= m.V[y]
+
+	case op&0xF00F == 0x8003: // XOR Vx, Vy
+		m.V[x] ^= m.V[y]
+
+	case op&0xF00F == 0x8004: // ADD Vx, Vy
+		sum := int(m.V[x]) + int(m.V[y])
+		m.V[0xF] = flag(sum > 255)
+		m.V[x] = uint8(sum)
+
+	case op&0xF00F == 0x8005: // SUB Vx, Vy
+		vx, vy := m.V[x], m.V[y]
+		m.V[0xF] = flag(vx > vy)
+		m.V[x] = vx - vy
+
+	case op&0xF00F == 0x8006: // SHR Vx
+		vx := m.V[x]
+		m.V[0xF] = vx & 1
+		m.V[x] = vx >> 1
+
+	case op&0xF00F == 0x8007: // SUBN Vx, Vy
+		vx, vy := m.V[x], m.V[y]
+		m.V[0xF] = flag(vy > vx)
+		m.V[x] = vy - vx
+
+	case op&0xF00F == 0x800E: // SHL Vx
+		vx := m.V[x]
+		m.V[0xF] = vx >> 7
+		m.V[x] = vx << 1
+
+	case op&0xF00F == 0x9000: // SNE Vx, Vy
+		if m.V[x] != m.V[y] {
+			next += 2
+		}
+
+	case op&0xF000 == 0xA000: // LD I, addr
+		m.I = nnn
+
+	case op&0xF000 == 0xB000: // JP V0, addr
+		next = nnn + uint16(m.V[0])
+
+	case op&0xF000 == 0xC000: // RND Vx, byte
+		m.V[x] = m.Random() & kk
+
+	case op&0xF000 == 0xD000: // DRW Vx, Vy, nibble
+		if int(m.I)+n > len(m.Memory) {
+			return errors.New("Sprite out of memory")
+		}
+		// Read the position before VF is reset in case it is one of the coordinates
+		vx, vy := int(m.V[x]), int(m.V[y])
+		m.V[0xF] = 0
+		for row := 0; row < n; row++ {
+			sprite := m.Memory[int(m.I)+row]
+			for col := 0; col < 8; col++ {
+				if sprite&(0x80>>col) == 0 {
+					continue
+				}
+				// Sprites wrap around to the opposite side of the screen
+				px := (vx + col) % 64
+				py := (vy + row) % 32
+				if m.Screen[py][px] {
+					m.V[0xF] = 1
+				}
+				m.Screen[py][px] = !m.Screen[py][px]
+			}
+		}
+
+	case op&0xF0FF == 0xE09E: // SKP Vx
+		if m.V[x] > 0xF {
+			return errors.New("Key out of range")
+		}
+		if m.Keys[m.V[x]] {
+			next += 2
+		}
+
+	case op&0xF0FF == 0xE0A1: // SKNP Vx
+		if m.V[x] > 0xF {
+			return errors.New("Key out of range")
+		}
+		if !m.Keys[m.V[x]] {
+			next += 2
+		}
+
+	case op&0xF0FF == 0xF007: // LD Vx, DT
+		m.V[x] = m.DT
+
+	case op&0xF0FF == 0xF00A: // LD Vx, K
+		// Execution stops until a key is pressed, so keep running this instruction until one is
+		next = m.PC
+		for key, down := range m.Keys {
+			if down {
+				m.V[x] = uint8(key)
+				next = m.PC + 2
+				break
+			}
+		}
+
+	case op&0xF0FF == 0xF015: // LD DT, Vx
+		m.DT = m.V[x]
+
+	case op&0xF0FF == 0xF018: // LD ST, Vx
+		m.ST = m.V[x]
+
+	case op&0xF0FF == 0xF01E: // ADD I, Vx
+		m.I += uint16(m.V[x])
+
+	case op&0xF0FF == 0xF029: // LD F, Vx
+		if m.V[x] > 0xF {
+			return errors.New("No font character for digit")
+		}
+		m.I = FontStart + uint16(m.V[x])*5
+
+	case op&0xF0FF == 0xF033: // LD B, Vx
+		if int(m.I)+3 > len(m.Memory) {
+			return errors.New("BCD out of memory")
+		}
+		m.Memory[m.I] = m.V[x] / 100
+		m.Memory[m.I+1] = m.V[x] / 10 % 10
+		m.Memory[m.I+2] = m.V[x] % 10
+
+	case op&0xF0FF == 0xF055: // LD [I], Vx
+		if int(m.I)+x+1 > len(m.Memory) {
+			return errors.New("Registers out of memory")
+		}
+		for r := 0; r <= x; r++ {
+			m.Memory[int(m.I)+r] = m.V[r]
+		}
+
+	case op&0xF0FF == 0xF065: // LD Vx, [I]
+		if int(m.I)+x+1 > len(m.Memory) {
+			return errors.New("Registers out of memory")
+		}
+		for r := 0; r <= x; r++ {
+			m.V[r] = m.Memory[int(m.I)+r]
+		}
+
+	default:
+		return fmt.Errorf("Unknown instruction %04X", op)
+	}
+
+	m.PC = next
+	return nil
+}
+
+// TickTimers counts the delay and sound timers down by one
+func (m *Machine) TickTimers() {
+	if m.DT > 0 {
+		m.DT--
+	}
+	if m.ST > 0 {
+		m.ST--
+	}
+}
+
+func flag(set bool) uint8 {
+	if set {
+		return 1
+	}
+
+	return 0
+}
@@ -0,0 +1,72 @@
+package chip8
+
+import (
+	"errors"
+	"sort"
+)
+
+// Quirks toggles the behaviours that differ between chip8 interpreters. The zero value matches the behaviour this implementation has
+// always had, so existing ROMs and tests are unaffected unless a preset is explicitly chosen.
+type Quirks struct {
+	// 8xy6/8xyE shift Vy into Vx instead of shifting Vx in place (original COSMAC VIP behaviour)
+	ShiftUsesVY bool
+	// Fx55/Fx65 leave I pointing after the last register accessed
+	LoadStoreIncrementsI bool
+	// Bnnn jumps to xnn + Vx instead of nnn + V0 (SCHIP behaviour)
+	JumpUsesVX bool
+	// 8xy1/8xy2/8xy3 reset VF to 0
+	ResetVF bool
+	// Sprites drawn past the edge of the screen are clipped instead of wrapping around
+	ClipSprites bool
+}
+
+// QuirkPresets are the named quirk sets that can be selected from a frontend
+var QuirkPresets = map[string]Quirks{
+	"default": {},
+	"cosmac": {
+		ShiftUsesVY:          true,
+		LoadStoreIncrementsI: true,
+		ResetVF:              true,
+		ClipSprites:          true,
+	},
+	"schip": {
+		JumpUsesVX:  true,
+		ClipSprites: true,
+	},
+	"xochip": {
+		ShiftUsesVY:          true,
+		LoadStoreIncrementsI: true,
+	},
+}
+
+// QuirkPreset looks up a preset by name.
+// Will return an error if there is no preset with that name.
+func QuirkPreset(name string) (Quirks, error) {
+	quirks, ok := QuirkPresets[name]
+	if !ok {
+		return Quirks{}, errors.New("Unknown quirk preset " + name)
+	}
+
+	return quirks, nil
+}
+
+// QuirkPresetNames returns the names of all presets in a stable order, useful for help text
+func QuirkPresetNames() []string {
+	names := []string{}
+	for name := range QuirkPresets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// SetQuirks changes the quirks used by the CPU. Can be called at any point, though it is normally done before loading a ROM.
+func (c8 *Chip8) SetQuirks(quirks Quirks) {
+	c8.quirks = quirks
+}
+
+// GetQuirks returns the quirks currently in use
+func (c8 *Chip8) GetQuirks() Quirks {
+	return c8.quirks
+}
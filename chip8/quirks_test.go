@@ -0,0 +1,81 @@
+package chip8
+
+import (
+	"testing"
+)
+
+func TestQuirkPresetUnknown(t *testing.T) {
+	if _, err := QuirkPreset("nope"); err == nil {
+		t.Error("Expected an error for an unknown preset")
+	}
+}
+
+func TestQuirkShiftUsesVY(t *testing.T) {
+	chip8, _ := createTestChip8([]uint8{0x80, 0x16})
+	chip8.SetQuirks(Quirks{ShiftUsesVY: true})
+	chip8.registers[0] = 0x10
+	chip8.registers[1] = 0x03
+
+	chip8.Tick()
+
+	if chip8.registers[0] != 0x01 {
+		t.Errorf("registers[0] was not set correctly. Expected %d, got %d", 0x01, chip8.registers[0])
+	}
+	if chip8.registers[0xF] != 1 {
+		t.Errorf("registers[0xF] was not set correctly. Expected %d, got %d", 1, chip8.registers[0xF])
+	}
+}
+
+func TestQuirkLoadStoreIncrementsI(t *testing.T) {
+	chip8, _ := createTestChip8([]uint8{0xF3, 0x55})
+	chip8.SetQuirks(Quirks{LoadStoreIncrementsI: true})
+	chip8.memoryRegister = 0x300
+
+	chip8.Tick()
+
+	if chip8.memoryRegister != 0x304 {
+		t.Errorf("I was not set correctly. Expected %d, got %d", 0x304, chip8.memoryRegister)
+	}
+}
+
+func TestQuirkJumpUsesVX(t *testing.T) {
+	chip8, _ := createTestChip8([]uint8{0xB3, 0x00})
+	chip8.SetQuirks(Quirks{JumpUsesVX: true})
+	chip8.registers[0] = 0x10
+	chip8.registers[3] = 0x04
+
+	chip8.Tick()
+
+	if chip8.programCounter != 0x304 {
+		t.Errorf("PC was not set correctly. Expected %d, got %d", 0x304, chip8.programCounter)
+	}
+}
+
+func TestQuirkResetVF(t *testing.T) {
+	chip8, _ := createTestChip8([]uint8{0x80, 0x11})
+	chip8.SetQuirks(Quirks{ResetVF: true})
+	chip8.registers[0xF] = 1
+
+	chip8.Tick()
+
+	if chip8.registers[0xF] != 0 {
+		t.Errorf("registers[0xF] was not set correctly. Expected %d, got %d", 0, chip8.registers[0xF])
+	}
+}
+
+func TestQuirkClipSprites(t *testing.T) {
+	// Draw the 0 font sprite at the bottom right corner; only the top left pixel of it is on screen
+	chip8, _ := createTestChip8([]uint8{0xD0, 0x15})
+	chip8.SetQuirks(Quirks{ClipSprites: true})
+	chip8.registers[0] = 63
+	chip8.registers[1] = 31
+
+	chip8.Tick()
+
+	if chip8.screen[63][31] != 1 {
+		t.Errorf("screen[63][31] was not set correctly. Expected %d, got %d", 1, chip8.screen[63][31])
+	}
+	if chip8.screen[63][0] != 0 {
+		t.Errorf("Sprite wrapped vertically when it should have been clipped")
+	}
+}
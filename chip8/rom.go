@@ -0,0 +1,118 @@
+package chip8
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"strconv"
+)
+
+const (
+	// Total addressable memory
+	MemorySize = 4096
+	// Where programs are loaded and start executing from
+	ProgramStart = 0x200
+	// Largest ROM that fits in memory after the reserved interpreter area
+	MaxROMSize = MemorySize - ProgramStart
+)
+
+var ErrEmptyROM = errors.New("ROM is empty")
+
+// Platform is the chip8 variant a ROM appears to have been written for
+type Platform string
+
+const (
+	PlatformCHIP8  Platform = "chip-8"
+	PlatformSCHIP  Platform = "schip"
+	PlatformXOCHIP Platform = "xo-chip"
+)
+
+// QuirkPreset is the name of the quirk preset most likely to run ROMs for this platform
+func (p Platform) QuirkPreset() string {
+	switch p {
+	case PlatformSCHIP:
+		return "schip"
+	case PlatformXOCHIP:
+		return "xochip"
+	}
+
+	return "default"
+}
+
+// ROMInfo describes a ROM that has been loaded
+type ROMInfo struct {
+	Size       int
+	SHA1       string // Hex encoded
+	Platform   Platform
+	EntryPoint uint16
+}
+
+// NewROMInfo validates a ROM and works out its metadata without loading it.
+// Will return an error if the ROM is empty or is too large to fit in memory.
+func NewROMInfo(data []uint8) (*ROMInfo, error) {
+	if len(data) == 0 {
+		return nil, ErrEmptyROM
+	}
+	if len(data) > MaxROMSize {
+		return nil, errors.New("ROM is " + strconv.Itoa(len(data)) + " bytes but at most " + strconv.Itoa(MaxROMSize) + " fit in memory")
+	}
+
+	hash := sha1.Sum(data)
+	info := ROMInfo{
+		Size:       len(data),
+		SHA1:       hex.EncodeToString(hash[:]),
+		Platform:   DetectPlatform(data),
+		EntryPoint: ProgramStart,
+	}
+
+	return &info, nil
+}
+
+// DetectPlatform scans a ROM for opcodes that only exist in the SCHIP or XO-CHIP extensions. This is a heuristic: sprite data can
+// happen to look like an extended opcode, so the result should be treated as a hint rather than a guarantee.
+func DetectPlatform(data []uint8) Platform {
+	platform := PlatformCHIP8
+
+	for i := 0; i+1 < len(data); i += 2 {
+		val := (uint16(data[i]) << 8) | uint16(data[i+1])
+
+		if isXOCHIPOpcode(val) {
+			return PlatformXOCHIP
+		}
+		if isSCHIPOpcode(val) {
+			platform = PlatformSCHIP
+		}
+	}
+
+	return platform
+}
+
+func isSCHIPOpcode(val uint16) bool {
+	switch {
+	case val&0xFFF0 == 0x00C0 && val != 0x00C0: // SCD n
+		return true
+	case val >= 0x00FB && val <= 0x00FF: // SCR, SCL, EXIT, LOW, HIGH
+		return true
+	case val&0xF00F == 0xD000: // DRW Vx, Vy, 0
+		return true
+	case val&0xF0FF == 0xF030, val&0xF0FF == 0xF075, val&0xF0FF == 0xF085: // LD HF, LD R, LD Vx, R
+		return true
+	}
+
+	return false
+}
+
+func isXOCHIPOpcode(val uint16) bool {
+	switch {
+	case val&0xFFF0 == 0x00D0 && val != 0x00D0: // SCU n
+		return true
+	case val&0xF00F == 0x5002, val&0xF00F == 0x5003: // Save/load register ranges
+		return true
+	case val == 0xF000, val == 0xF002: // Long I, audio pattern
+		return true
+	case val&0xF0FF == 0xF001, val&0xF0FF == 0xF03A: // Plane select, pitch
+		return true
+	}
+
+	return false
+}
@@ -0,0 +1,102 @@
+package chip8
+
+import (
+	"testing"
+)
+
+func TestLoadFromMemoryRejectsEmptyROM(t *testing.T) {
+	chip8, _ := createTestChip8([]uint8{})
+
+	if _, err := chip8.LoadFromMemory([]uint8{}); err != ErrEmptyROM {
+		t.Errorf("Expected ErrEmptyROM, got %v", err)
+	}
+}
+
+func TestLoadFromMemoryRejectsOversizedROM(t *testing.T) {
+	chip8, _ := createTestChip8([]uint8{})
+
+	if _, err := chip8.LoadFromMemory(make([]uint8, MaxROMSize+1)); err == nil {
+		t.Error("Expected an error for a ROM that doesn't fit in memory")
+	}
+	if chip8.memory[MemorySize-1] != 0 {
+		t.Error("Memory was changed by a failed load")
+	}
+}
+
+func TestLoadFromMemoryClearsPreviousROM(t *testing.T) {
+	chip8, _ := createTestChip8([]uint8{})
+
+	if _, err := chip8.LoadFromMemory([]uint8{0x11, 0x22, 0x33, 0x44}); err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	if _, err := chip8.LoadFromMemory([]uint8{0xAA, 0xBB}); err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+
+	memory, _ := chip8.ReadMemory(ProgramStart, 4)
+	if memory[0] != 0xAA || memory[1] != 0xBB || memory[2] != 0 || memory[3] != 0 {
+		t.Errorf("Memory was not set correctly. Expected [AA BB 0 0], got %X", memory)
+	}
+}
+
+func TestLoadFromMemoryAcceptsFullSizeROM(t *testing.T) {
+	chip8, _ := createTestChip8([]uint8{})
+	data := make([]uint8, MaxROMSize)
+	data[len(data)-1] = 0xAB
+
+	info, err := chip8.LoadFromMemory(data)
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	if chip8.memory[MemorySize-1] != 0xAB {
+		t.Errorf("memory[0xFFF] was not set correctly. Expected %d, got %d", 0xAB, chip8.memory[MemorySize-1])
+	}
+	if info.Size != MaxROMSize {
+		t.Errorf("Size was not set correctly. Expected %d, got %d", MaxROMSize, info.Size)
+	}
+}
+
+func TestROMInfoMetadata(t *testing.T) {
+	info, err := NewROMInfo([]uint8{0x12, 0x00})
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+
+	if info.SHA1 != "92a5652d382a18e89c4881ec57041fc7d885ca80" {
+		t.Errorf("SHA1 was not set correctly. Expected %s, got %s", "92a5652d382a18e89c4881ec57041fc7d885ca80", info.SHA1)
+	}
+	if info.EntryPoint != 0x200 {
+		t.Errorf("Entry point was not set correctly. Expected %d, got %d", 0x200, info.EntryPoint)
+	}
+	if info.Platform != PlatformCHIP8 {
+		t.Errorf("Platform was not detected correctly. Expected %s, got %s", PlatformCHIP8, info.Platform)
+	}
+}
+
+func TestDetectPlatformSCHIP(t *testing.T) {
+	// HIGH; JP 0x202
+	if platform := DetectPlatform([]uint8{0x00, 0xFF, 0x12, 0x02}); platform != PlatformSCHIP {
+		t.Errorf("Platform was not detected correctly. Expected %s, got %s", PlatformSCHIP, platform)
+	}
+}
+
+func TestDetectPlatformXOCHIPWinsOverSCHIP(t *testing.T) {
+	// HIGH; LD I, long 0x0300
+	if platform := DetectPlatform([]uint8{0x00, 0xFF, 0xF0, 0x00, 0x03, 0x00}); platform != PlatformXOCHIP {
+		t.Errorf("Platform was not detected correctly. Expected %s, got %s", PlatformXOCHIP, platform)
+	}
+}
+
+func TestDetectPlatformBundledROMs(t *testing.T) {
+	for _, rom := range []string{"../roms/pong.rom", "../roms/test_opcode.ch8"} {
+		display := MockDisplay{}
+		chip8 := New(&display)
+		info, err := chip8.LoadROM(rom)
+		if err != nil {
+			t.Fatalf("Could not load %s: %v", rom, err)
+		}
+		if info.Platform != PlatformCHIP8 {
+			t.Errorf("Platform for %s was not detected correctly. Expected %s, got %s", rom, PlatformCHIP8, info.Platform)
+		}
+	}
+}
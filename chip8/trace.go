@@ -0,0 +1,59 @@
+package chip8
+
+// TraceRecord describes an instruction about to be executed along with the state of the CPU before it runs
+type TraceRecord struct {
+	Cycle       uint64 // Number of instructions executed before this one
+	PC          uint16
+	Opcode      uint16
+	Instruction *Instruction
+	V           [16]uint8
+	I           uint16
+	SP          uint8 // Number of return addresses on the stack
+	DT          uint8
+	ST          uint8
+}
+
+// Tracer is given a record for every instruction executed. The record is only valid for the duration of the call.
+type Tracer interface {
+	Trace(record *TraceRecord)
+}
+
+type multiTracer []Tracer
+
+func (mt multiTracer) Trace(record *TraceRecord) {
+	for _, tracer := range mt {
+		tracer.Trace(record)
+	}
+}
+
+// MultiTracer sends every record to each of the tracers in turn, so for example a trace can be written while profiling
+func MultiTracer(tracers ...Tracer) Tracer {
+	return multiTracer(append([]Tracer{}, tracers...))
+}
+
+// SetTracer starts sending every executed instruction to a tracer, or stops tracing if it is nil. When no tracer is set the only cost is
+// a nil check per instruction.
+func (c8 *Chip8) SetTracer(tracer Tracer) {
+	c8.tracer = tracer
+}
+
+// Cycles returns the number of instructions executed so far
+func (c8 *Chip8) Cycles() uint64 {
+	return c8.cycles
+}
+
+func (c8 *Chip8) trace(instruction *Instruction) {
+	record := TraceRecord{
+		Cycle:       c8.cycles,
+		PC:          c8.programCounter,
+		Opcode:      uint16(c8.memory[c8.programCounter])<<8 | uint16(c8.memory[c8.programCounter+1]),
+		Instruction: instruction,
+		V:           c8.registers,
+		I:           c8.memoryRegister,
+		SP:          uint8(c8.stackPointer + 1),
+		DT:          c8.delayTimer,
+		ST:          c8.soundTimer,
+	}
+
+	c8.tracer.Trace(&record)
+}
@@ -0,0 +1,88 @@
+package chip8
+
+import (
+	"testing"
+)
+
+type recordingTracer struct {
+	records []TraceRecord
+}
+
+func (rt *recordingTracer) Trace(record *TraceRecord) {
+	rt.records = append(rt.records, *record)
+}
+
+func TestTracerGetsStateBeforeEachInstruction(t *testing.T) {
+	// LD V0, 3; LD DT, V0; CALL 0x208; (skipped) ; ADD V0, 1
+	chip8, _ := createTestChip8([]uint8{0x60, 0x03, 0xF0, 0x15, 0x22, 0x08, 0x00, 0x00, 0x70, 0x01})
+	tracer := recordingTracer{}
+	chip8.SetTracer(&tracer)
+
+	for i := 0; i < 4; i++ {
+		chip8.Tick()
+	}
+
+	if len(tracer.records) != 4 {
+		t.Fatalf("Expected %d records, got %d", 4, len(tracer.records))
+	}
+
+	last := tracer.records[3]
+	if last.Cycle != 3 {
+		t.Errorf("Cycle was not set correctly. Expected %d, got %d", 3, last.Cycle)
+	}
+	if last.PC != 0x208 || last.Opcode != 0x7001 {
+		t.Errorf("PC/opcode was not set correctly. Expected 0x208/0x7001, got 0x%X/0x%X", last.PC, last.Opcode)
+	}
+	if last.Instruction.String() != "ADD V0, 0x01" {
+		t.Errorf("Instruction was not set correctly. Got %s", last.Instruction.String())
+	}
+	if last.V[0] != 3 {
+		t.Errorf("V0 should be recorded before the instruction runs. Expected %d, got %d", 3, last.V[0])
+	}
+	if last.SP != 1 {
+		t.Errorf("SP was not set correctly. Expected %d, got %d", 1, last.SP)
+	}
+	// Set to 3 then decremented at the end of the 2nd and 3rd ticks
+	if last.DT != 1 {
+		t.Errorf("DT was not set correctly. Expected %d, got %d", 1, last.DT)
+	}
+	if chip8.Cycles() != 4 {
+		t.Errorf("Cycles was not set correctly. Expected %d, got %d", 4, chip8.Cycles())
+	}
+}
+
+func TestTracerCanBeRemoved(t *testing.T) {
+	chip8, _ := createTestChip8([]uint8{0x60, 0x03, 0x60, 0x04})
+	tracer := recordingTracer{}
+	chip8.SetTracer(&tracer)
+
+	chip8.Tick()
+	chip8.SetTracer(nil)
+	chip8.Tick()
+
+	if len(tracer.records) != 1 {
+		t.Errorf("Expected %d records, got %d", 1, len(tracer.records))
+	}
+}
+
+func BenchmarkTickWithoutTracer(b *testing.B) {
+	// JP 0x200
+	chip8, _ := createTestChip8([]uint8{0x12, 0x00})
+
+	for i := 0; i < b.N; i++ {
+		chip8.Tick()
+	}
+}
+
+func TestMultiTracer(t *testing.T) {
+	chip8, _ := createTestChip8([]uint8{0x60, 0x03, 0x70, 0x01})
+	first, second := recordingTracer{}, recordingTracer{}
+	chip8.SetTracer(MultiTracer(&first, &second))
+
+	chip8.Tick()
+	chip8.Tick()
+
+	if len(first.records) != 2 || len(second.records) != 2 {
+		t.Errorf("Records were not sent to every tracer. Expected %d each, got %d and %d", 2, len(first.records), len(second.records))
+	}
+}
@@ -0,0 +1,47 @@
+package chip8
+
+// AccessKind is what a memory access was for
+type AccessKind int
+
+const (
+	AccessFetch  AccessKind = iota // Reading an instruction
+	AccessSprite                   // DRW reading sprite data
+	AccessBCD                      // Fx33 storing a number's digits
+	AccessStore                    // Fx55 storing registers
+	AccessLoad                     // Fx65 loading registers
+)
+
+// AccessKinds is the number of kinds of access, for tools that keep a count of each
+const AccessKinds = 5
+
+var accessKindNames = [AccessKinds]string{"fetch", "sprite", "bcd", "store", "load"}
+
+func (k AccessKind) String() string {
+	if k >= 0 && k < AccessKinds {
+		return accessKindNames[k]
+	}
+
+	return "unknown"
+}
+
+// IsWrite reports whether the kind of access changes memory
+func (k AccessKind) IsWrite() bool {
+	return k == AccessBCD || k == AccessStore
+}
+
+// MemoryWatcher is told about every access instructions make to memory. Debugger reads and writes aren't included.
+type MemoryWatcher interface {
+	MemoryAccess(kind AccessKind, address uint16, length int)
+}
+
+// SetMemoryWatcher starts reporting memory accesses to a watcher, or stops if it is nil. When no watcher is set the only cost is a nil
+// check per access.
+func (c8 *Chip8) SetMemoryWatcher(watcher MemoryWatcher) {
+	c8.watcher = watcher
+}
+
+func (c8 *Chip8) watch(kind AccessKind, address uint16, length int) {
+	if c8.watcher != nil && length > 0 {
+		c8.watcher.MemoryAccess(kind, address, length)
+	}
+}
@@ -0,0 +1,67 @@
+package chip8
+
+import (
+	"testing"
+)
+
+type access struct {
+	kind    AccessKind
+	address uint16
+	length  int
+}
+
+type recordingWatcher struct {
+	accesses []access
+}
+
+func (rw *recordingWatcher) MemoryAccess(kind AccessKind, address uint16, length int) {
+	rw.accesses = append(rw.accesses, access{kind, address, length})
+}
+
+func TestMemoryWatcher(t *testing.T) {
+	// LD I, 0x300; LD V0, 123; LD B, V0; LD [I], V3; LD V2, [I]; DRW V0, V1, 1
+	chip8, _ := createTestChip8([]uint8{0xA3, 0x00, 0x60, 0x7B, 0xF0, 0x33, 0xF3, 0x55, 0xF2, 0x65, 0xD0, 0x11})
+	watcher := recordingWatcher{}
+	chip8.SetMemoryWatcher(&watcher)
+
+	for i := 0; i < 6; i++ {
+		if err := chip8.Tick(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	data := []access{}
+	fetch := uint16(0x200)
+	for _, a := range watcher.accesses {
+		if a.kind == AccessFetch {
+			if a.address != fetch || a.length != 2 {
+				t.Errorf("Fetch was not reported correctly. Expected 0x%03X, got %+v", fetch, a)
+			}
+			fetch += 2
+			continue
+		}
+		data = append(data, a)
+	}
+
+	expected := []access{{AccessBCD, 0x300, 3}, {AccessStore, 0x300, 4}, {AccessLoad, 0x300, 3}, {AccessSprite, 0x300, 1}}
+	if len(data) != len(expected) {
+		t.Fatalf("Expected %d data accesses, got %+v", len(expected), data)
+	}
+	for i := range expected {
+		if data[i] != expected[i] {
+			t.Errorf("Access was not reported correctly. Expected %+v, got %+v", expected[i], data[i])
+		}
+	}
+	if len(watcher.accesses) != 10 {
+		t.Errorf("Expected %d accesses including fetches, got %d", 10, len(watcher.accesses))
+	}
+}
+
+func TestAccessKind(t *testing.T) {
+	if AccessSprite.String() != "sprite" || AccessKind(AccessKinds).String() != "unknown" {
+		t.Errorf("Access kind names were not set correctly. Got %s and %s", AccessSprite, AccessKind(AccessKinds))
+	}
+	if !AccessStore.IsWrite() || AccessLoad.IsWrite() {
+		t.Error("Expected only stores to be writes")
+	}
+}
@@ -0,0 +1,158 @@
+// Package conformance runs a directory of test ROMs, such as the Timendus test suite, under every quirk profile and checks the screen
+// each one ends on against a golden. Each ROM has a JSON manifest next to it:
+//
+//	{
+//		"rom": "flags.ch8",
+//		"quirks": "cosmac",
+//		"frames": 120,
+//		"cyclesPerFrame": 20,
+//		"keys": "flags.keys",
+//		"golden": "flags.txt"
+//	}
+//
+// Paths are relative to the manifest. The quirk preset (or the preset for "platform" if no preset is given) is the profile the golden
+// was made with, so it is the one the test has to pass. Running the other profiles shows which behaviours a ROM is sensitive to.
+package conformance
+
+import (
+	"chip8/chip8"
+	"chip8/headless"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Used when a manifest doesn't say how fast to run
+const DefaultCyclesPerFrame = 10
+
+// Manifest describes how to run a test ROM and what it should show at the end
+type Manifest struct {
+	Name           string         `json:"name,omitempty"` // Defaults to the manifest file name
+	ROM            string         `json:"rom"`
+	Quirks         string         `json:"quirks,omitempty"`
+	Platform       chip8.Platform `json:"platform,omitempty"`
+	Frames         int            `json:"frames"`
+	CyclesPerFrame int            `json:"cyclesPerFrame,omitempty"`
+	Keys           string         `json:"keys,omitempty"` // Headless input script
+	Golden         string         `json:"golden"`         // ASCII or PNG golden, see the screentest package
+
+	dir string
+}
+
+// LoadManifest reads and validates a manifest
+func LoadManifest(path string) (*Manifest, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	manifest := Manifest{}
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, err
+	}
+
+	manifest.dir = filepath.Dir(path)
+	if manifest.Name == "" {
+		manifest.Name = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	}
+	if manifest.CyclesPerFrame == 0 {
+		manifest.CyclesPerFrame = DefaultCyclesPerFrame
+	}
+
+	switch {
+	case manifest.ROM == "":
+		return nil, errors.New(manifest.Name + ": manifest has no ROM")
+	case manifest.Golden == "":
+		return nil, errors.New(manifest.Name + ": manifest has no golden")
+	case manifest.Frames < 1:
+		return nil, errors.New(manifest.Name + ": manifest must run for at least one frame")
+	case manifest.CyclesPerFrame < 1:
+		return nil, errors.New(manifest.Name + ": cyclesPerFrame must be at least 1")
+	}
+	if _, err := chip8.QuirkPreset(manifest.Profile()); err != nil {
+		return nil, errors.New(manifest.Name + ": " + err.Error())
+	}
+
+	return &manifest, nil
+}
+
+// LoadSuite reads every manifest in a directory, sorted by name
+func LoadSuite(dir string) ([]*Manifest, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	if len(paths) == 0 {
+		return nil, errors.New("No manifests found in " + dir)
+	}
+
+	manifests := []*Manifest{}
+	for _, path := range paths {
+		manifest, err := LoadManifest(path)
+		if err != nil {
+			return nil, err
+		}
+		manifests = append(manifests, manifest)
+	}
+
+	sort.Slice(manifests, func(i, j int) bool {
+		return manifests[i].Name < manifests[j].Name
+	})
+
+	return manifests, nil
+}
+
+// Profile is the name of the quirk preset the golden was made with
+func (m *Manifest) Profile() string {
+	if m.Quirks != "" {
+		return m.Quirks
+	}
+	if m.Platform != "" {
+		return m.Platform.QuirkPreset()
+	}
+
+	return "default"
+}
+
+// Path resolves a path from the manifest
+func (m *Manifest) Path(name string) string {
+	if name == "" || filepath.IsAbs(name) {
+		return name
+	}
+
+	return filepath.Join(m.dir, name)
+}
+
+// Run plays the ROM with the given quirks and returns the screen it ends on.
+// Will return an error if the ROM or key script can't be loaded, or the ROM hits an invalid instruction.
+func (m *Manifest) Run(quirks chip8.Quirks) (*[64][32]uint8, error) {
+	display := headless.New()
+	if m.Keys != "" {
+		script, err := headless.LoadScript(m.Path(m.Keys))
+		if err != nil {
+			return nil, err
+		}
+		display.SetScript(script)
+	}
+
+	computer := chip8.New(display)
+	computer.SetQuirks(quirks)
+	if _, err := computer.LoadROM(m.Path(m.ROM)); err != nil {
+		return nil, err
+	}
+
+	for frame := 0; frame < m.Frames && !computer.IsHalted(); frame++ {
+		for i := 0; i < m.CyclesPerFrame; i++ {
+			if err := computer.Tick(); err != nil {
+				return computer.GetScreen(), err
+			}
+		}
+
+		display.Update(computer.GetScreen(), computer.GetDirtyFlags())
+	}
+
+	return computer.GetScreen(), nil
+}
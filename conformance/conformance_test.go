@@ -0,0 +1,115 @@
+package conformance
+
+import (
+	"bytes"
+	"chip8/chip8"
+	"chip8/screentest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSuite(t *testing.T) {
+	tests, err := LoadSuite("testdata")
+	if err != nil {
+		t.Fatalf("Could not load suite: %v", err)
+	}
+
+	if screentest.Updating() {
+		for _, test := range tests {
+			quirks, _ := chip8.QuirkPreset(test.Profile())
+			screen, err := test.Run(quirks)
+			if err != nil {
+				t.Fatalf("Could not run %s: %v", test.Name, err)
+			}
+			if err := screentest.Write(test.Path(test.Golden), screen); err != nil {
+				t.Fatalf("Could not update golden for %s: %v", test.Name, err)
+			}
+		}
+	}
+
+	report, err := RunSuite(tests, nil)
+	if err != nil {
+		t.Fatalf("Could not run suite: %v", err)
+	}
+
+	buf := bytes.Buffer{}
+	report.Write(&buf)
+	t.Log("\n" + buf.String())
+
+	for _, test := range report.Failures() {
+		t.Errorf("%s does not pass with the %s quirks it was made for", test.Name, test.Profile())
+	}
+}
+
+func TestQuirkProfilesDiverge(t *testing.T) {
+	tests, err := LoadSuite("testdata")
+	if err != nil {
+		t.Fatalf("Could not load suite: %v", err)
+	}
+
+	report, err := RunSuite(tests, []string{"default", "cosmac"})
+	if err != nil {
+		t.Fatalf("Could not run suite: %v", err)
+	}
+
+	if report.Result("shift_quirk", "default").Passed {
+		t.Errorf("shift_quirk should only pass when 8xy6 shifts Vy")
+	}
+	if !report.Result("keypad", "cosmac").Passed {
+		t.Errorf("keypad should not depend on quirks")
+	}
+}
+
+func TestRunSuiteRejectsUnknownProfiles(t *testing.T) {
+	if _, err := RunSuite([]*Manifest{}, []string{"nope"}); err != nil {
+		t.Errorf("Expected no error when there are no tests, got %v", err)
+	}
+
+	tests, _ := LoadSuite("testdata")
+	if _, err := RunSuite(tests, []string{"nope"}); err == nil {
+		t.Errorf("Expected an error for an unknown profile")
+	}
+}
+
+func TestLoadManifestValidates(t *testing.T) {
+	manifests := map[string]string{
+		"norom":     `{"frames": 1, "golden": "x.txt"}`,
+		"nogolden":  `{"rom": "x.ch8", "frames": 1}`,
+		"noframes":  `{"rom": "x.ch8", "golden": "x.txt"}`,
+		"badquirks": `{"rom": "x.ch8", "frames": 1, "golden": "x.txt", "quirks": "nope"}`,
+	}
+
+	dir := t.TempDir()
+	for name, text := range manifests {
+		path := filepath.Join(dir, name+".json")
+		os.WriteFile(path, []byte(text), 0644)
+
+		if _, err := LoadManifest(path); err == nil {
+			t.Errorf("Expected an error loading %s", name)
+		} else if !strings.HasPrefix(err.Error(), name) {
+			t.Errorf("Error for %s should name the test, got %v", name, err)
+		}
+	}
+}
+
+func TestManifestDefaults(t *testing.T) {
+	manifest, err := LoadManifest("testdata/keypad.json")
+	if err != nil {
+		t.Fatalf("Could not load manifest: %v", err)
+	}
+
+	if manifest.Name != "keypad" {
+		t.Errorf("Name was not set correctly. Expected keypad, got %s", manifest.Name)
+	}
+	if manifest.CyclesPerFrame != DefaultCyclesPerFrame {
+		t.Errorf("CyclesPerFrame was not set correctly. Expected %d, got %d", DefaultCyclesPerFrame, manifest.CyclesPerFrame)
+	}
+	if manifest.Profile() != "default" {
+		t.Errorf("Profile was not set correctly. Expected default, got %s", manifest.Profile())
+	}
+	if manifest.Path("keypad.ch8") != filepath.Join("testdata", "keypad.ch8") {
+		t.Errorf("Path was not resolved correctly. Got %s", manifest.Path("keypad.ch8"))
+	}
+}
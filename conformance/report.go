@@ -0,0 +1,120 @@
+package conformance
+
+import (
+	"chip8/chip8"
+	"chip8/screentest"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+)
+
+// Result is the outcome of running one test under one quirk profile
+type Result struct {
+	Passed bool
+	Err    error // Set if the test couldn't be run to the end
+	Screen *[64][32]uint8
+}
+
+// Report holds the results of a suite for every profile it was run with
+type Report struct {
+	Tests    []*Manifest
+	Profiles []string
+	results  map[string]map[string]Result
+}
+
+// RunSuite runs every test under each of the quirk profiles given. Profiles default to all the presets.
+// Will return an error if a profile isn't a known preset.
+func RunSuite(tests []*Manifest, profiles []string) (*Report, error) {
+	if len(profiles) == 0 {
+		profiles = chip8.QuirkPresetNames()
+	}
+
+	report := Report{Tests: tests, Profiles: profiles, results: map[string]map[string]Result{}}
+	for _, test := range tests {
+		expected, goldenErr := screentest.Read(test.Path(test.Golden))
+
+		report.results[test.Name] = map[string]Result{}
+		for _, profile := range profiles {
+			quirks, err := chip8.QuirkPreset(profile)
+			if err != nil {
+				return nil, err
+			}
+
+			report.results[test.Name][profile] = runTest(test, quirks, expected, goldenErr)
+		}
+	}
+
+	return &report, nil
+}
+
+func runTest(test *Manifest, quirks chip8.Quirks, expected *[64][32]uint8, goldenErr error) Result {
+	if goldenErr != nil {
+		return Result{Err: goldenErr}
+	}
+
+	screen, err := test.Run(quirks)
+	if err != nil {
+		return Result{Err: err, Screen: screen}
+	}
+
+	return Result{Passed: *screen == *expected, Screen: screen}
+}
+
+// Result looks up the result of a test under a profile
+func (r *Report) Result(test string, profile string) Result {
+	return r.results[test][profile]
+}
+
+// Failures lists the tests that don't pass under the profile their golden was made with
+func (r *Report) Failures() []*Manifest {
+	failures := []*Manifest{}
+	for _, test := range r.Tests {
+		if result, ok := r.results[test.Name][test.Profile()]; ok && !result.Passed {
+			failures = append(failures, test)
+		}
+	}
+
+	return failures
+}
+
+// Write prints the pass/fail matrix with a row per test and a column per profile. The profile each test is expected to pass under is
+// marked with a *. Errors that stopped a test from running are listed underneath.
+func (r *Report) Write(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(tw, "TEST\t%s\n", strings.Join(r.Profiles, "\t"))
+
+	errs := []string{}
+	for _, test := range r.Tests {
+		cells := []string{}
+		for _, profile := range r.Profiles {
+			result := r.results[test.Name][profile]
+
+			cell := "fail"
+			switch {
+			case result.Err != nil:
+				cell = "error"
+				errs = append(errs, fmt.Sprintf("%s (%s): %v", test.Name, profile, result.Err))
+			case result.Passed:
+				cell = "pass"
+			}
+			if profile == test.Profile() {
+				cell += "*"
+			}
+			cells = append(cells, cell)
+		}
+
+		fmt.Fprintf(tw, "%s\t%s\n", test.Name, strings.Join(cells, "\t"))
+	}
+
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	for _, e := range errs {
+		if _, err := fmt.Fprintln(w, e); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"chip8/conformance"
+	"fmt"
+	"os"
+	"strings"
+)
+
+func conformanceCommand(args []string) int {
+	fs := newFlagSet("conformance")
+	profiles := fs.String("profiles", "", "comma separated quirk presets to run each test with (default all)")
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Usage: chip8 conformance [flags] <directory of test manifests>")
+		fs.PrintDefaults()
+	}
+	dir, err := parseROMArgs(fs, args)
+	if err != nil {
+		return usageExitCode(err)
+	}
+
+	tests, err := conformance.LoadSuite(dir)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Could not load tests:", err)
+		return exitUsage
+	}
+
+	names := []string{}
+	if *profiles != "" {
+		names = strings.Split(*profiles, ",")
+	}
+	report, err := conformance.RunSuite(tests, names)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitUsage
+	}
+
+	report.Write(os.Stdout)
+
+	if failures := report.Failures(); len(failures) > 0 {
+		fmt.Fprintf(os.Stderr, "%d of %d tests failed with the quirks they were made for\n", len(failures), len(tests))
+		return exitError
+	}
+
+	return exitOK
+}
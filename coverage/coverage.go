@@ -0,0 +1,162 @@
+// Package coverage records which instructions of a ROM ran, and for each skip instruction (SE, SNE, SKP and SKNP) how often the skip
+// was taken and not taken. Attach a Coverage to a CPU with Chip8.SetTracer. Coverage is saved as JSON and runs of the same ROM can be
+// merged, so a set of test ROMs or input scripts can build up coverage between them.
+package coverage
+
+import (
+	"chip8/chip8"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"sort"
+)
+
+// Branch counts which way a skip instruction went
+type Branch struct {
+	Taken    uint64 `json:"taken"`
+	NotTaken uint64 `json:"notTaken"`
+}
+
+// Coverage is the coverage of one ROM, over one or more runs
+type Coverage struct {
+	ROM      string             `json:"rom"` // SHA-1 of the ROM, so coverage of different ROMs isn't merged
+	Runs     int                `json:"runs"`
+	Hits     map[uint16]uint64  `json:"hits"`
+	Branches map[uint16]*Branch `json:"branches"`
+
+	// A skip that has run, waiting for the next instruction to show whether it skipped
+	pending   bool
+	pendingPC uint16
+}
+
+// New creates empty coverage for a ROM, identified by its SHA-1 as given by chip8.ROMInfo
+func New(rom string) *Coverage {
+	return &Coverage{ROM: rom, Runs: 1, Hits: map[uint16]uint64{}, Branches: map[uint16]*Branch{}}
+}
+
+// IsSkip reports whether a command is one of the conditional skips
+func IsSkip(command chip8.Command) bool {
+	switch command {
+	case chip8.CmdSkipIfEqual, chip8.CmdSkipIfNotEqual, chip8.CmdSkipIfEqualRegister, chip8.CmdSkipIfNotEqualRegister,
+		chip8.CmdSkipIfKeyPressed, chip8.CmdSkipIfKeyNotPressed:
+		return true
+	}
+
+	return false
+}
+
+// Trace records an instruction. Which way a skip went is worked out from where the next instruction is, so the direction of a skip
+// that is the very last instruction of a run isn't counted.
+func (c *Coverage) Trace(record *chip8.TraceRecord) {
+	if c.pending {
+		c.pending = false
+		switch record.PC {
+		case c.pendingPC + 4:
+			c.branch(c.pendingPC).Taken++
+		case c.pendingPC + 2:
+			c.branch(c.pendingPC).NotTaken++
+		}
+	}
+
+	c.Hits[record.PC]++
+	if record.Instruction != nil && IsSkip(record.Instruction.Command) {
+		c.branch(record.PC)
+		c.pending = true
+		c.pendingPC = record.PC
+	}
+}
+
+func (c *Coverage) branch(address uint16) *Branch {
+	branch, ok := c.Branches[address]
+	if !ok {
+		branch = &Branch{}
+		c.Branches[address] = branch
+	}
+
+	return branch
+}
+
+// Merge adds the counts from another run of the same ROM.
+// Will return an error if the coverage is for a different ROM.
+func (c *Coverage) Merge(other *Coverage) error {
+	if other.ROM != c.ROM {
+		return errors.New("Coverage is for a different ROM")
+	}
+
+	c.Runs += other.Runs
+	for address, hits := range other.Hits {
+		c.Hits[address] += hits
+	}
+	for address, branch := range other.Branches {
+		merged := c.branch(address)
+		merged.Taken += branch.Taken
+		merged.NotTaken += branch.NotTaken
+	}
+
+	return nil
+}
+
+// Addresses returns every address that ran, in order
+func (c *Coverage) Addresses() []uint16 {
+	addresses := []uint16{}
+	for address := range c.Hits {
+		addresses = append(addresses, address)
+	}
+
+	sort.Slice(addresses, func(i, j int) bool {
+		return addresses[i] < addresses[j]
+	})
+
+	return addresses
+}
+
+// Read reads coverage written by Write
+func Read(r io.Reader) (*Coverage, error) {
+	c := Coverage{}
+	if err := json.NewDecoder(r).Decode(&c); err != nil {
+		return nil, err
+	}
+	if c.Hits == nil {
+		c.Hits = map[uint16]uint64{}
+	}
+	if c.Branches == nil {
+		c.Branches = map[uint16]*Branch{}
+	}
+
+	return &c, nil
+}
+
+// Write writes the coverage as JSON
+func (c *Coverage) Write(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "\t")
+
+	return encoder.Encode(c)
+}
+
+// Load reads coverage from a file
+func Load(path string) (*Coverage, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return Read(file)
+}
+
+// Save writes coverage to a file
+func (c *Coverage) Save(path string) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	if err := c.Write(file); err != nil {
+		file.Close()
+		return err
+	}
+
+	return file.Close()
+}
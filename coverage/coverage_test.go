@@ -0,0 +1,154 @@
+package coverage
+
+import (
+	"bytes"
+	"chip8/chip8"
+	"chip8/symbols"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+type display struct{}
+
+func (d *display) Update(*[64][32]uint8, *[64][32]bool) {}
+func (d *display) Closed() bool                         { return false }
+func (d *display) KeyDown(key uint8) bool               { return false }
+
+var program = []uint8{
+	0x60, 0x01, // 200: LD V0, 1
+	0x30, 0x01, // 202: SE V0, 1 (taken)
+	0x61, 0x05, // 204: LD V1, 5 (skipped)
+	0x40, 0x01, // 206: SNE V0, 1 (not taken)
+	0x12, 0x08, // 208: JP 208
+}
+
+// run records the coverage of 5 instructions of the program, ending with the loop running twice
+func run(t *testing.T) *Coverage {
+	computer := chip8.New(&display{})
+	info, err := computer.LoadFromMemory(program)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c := New(info.SHA1)
+	computer.SetTracer(c)
+	for i := 0; i < 5; i++ {
+		computer.Tick()
+	}
+
+	return c
+}
+
+func TestHitsAndBranches(t *testing.T) {
+	c := run(t)
+
+	expected := map[uint16]uint64{0x200: 1, 0x202: 1, 0x206: 1, 0x208: 2}
+	if len(c.Hits) != len(expected) {
+		t.Errorf("Hits were not recorded correctly. Expected %v, got %v", expected, c.Hits)
+	}
+	for address, hits := range expected {
+		if c.Hits[address] != hits {
+			t.Errorf("Hits for 0x%03X were not recorded correctly. Expected %d, got %d", address, hits, c.Hits[address])
+		}
+	}
+
+	if branch := c.Branches[0x202]; branch == nil || branch.Taken != 1 || branch.NotTaken != 0 {
+		t.Errorf("Taken skip was not recorded correctly. Got %+v", branch)
+	}
+	if branch := c.Branches[0x206]; branch == nil || branch.Taken != 0 || branch.NotTaken != 1 {
+		t.Errorf("Skip that wasn't taken was not recorded correctly. Got %+v", branch)
+	}
+}
+
+func TestMerge(t *testing.T) {
+	c := run(t)
+	if err := c.Merge(run(t)); err != nil {
+		t.Fatal(err)
+	}
+
+	if c.Runs != 2 || c.Hits[0x208] != 4 || c.Branches[0x202].Taken != 2 {
+		t.Errorf("Coverage was not merged correctly. Got %d runs, %d hits and %+v", c.Runs, c.Hits[0x208], c.Branches[0x202])
+	}
+
+	if err := c.Merge(New("another rom")); err == nil {
+		t.Error("Expected an error merging coverage of a different ROM")
+	}
+}
+
+func TestSaveAndLoad(t *testing.T) {
+	c := run(t)
+	path := filepath.Join(t.TempDir(), "coverage.json")
+	if err := c.Save(path); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.ROM != c.ROM || loaded.Hits[0x208] != 2 || loaded.Branches[0x206].NotTaken != 1 {
+		t.Errorf("Coverage was not loaded correctly. Got %+v", loaded)
+	}
+}
+
+func TestListing(t *testing.T) {
+	lines := run(t).Listing(program, nil)
+
+	if len(lines) != 5 {
+		t.Fatalf("Expected %d lines, got %d", 5, len(lines))
+	}
+	if lines[2].Hits != 0 || lines[2].Text != "LD V1, 0x05" {
+		t.Errorf("Line that didn't run was not listed correctly. Got %+v", lines[2])
+	}
+	if lines[1].Covered() || !lines[0].Covered() {
+		t.Errorf("Lines were not marked covered correctly. Expected the one way skip to be uncovered")
+	}
+
+	summary := Summarise(lines)
+	expected := Summary{Lines: 5, Executed: 4, Skips: 2, SkipsTaken: 1, SkipsPassed: 1}
+	if summary != expected {
+		t.Errorf("Summary was not counted correctly. Expected %+v, got %+v", expected, summary)
+	}
+}
+
+func TestListingRealignsOddCode(t *testing.T) {
+	c := New("")
+	c.Hits[0x201] = 1
+
+	lines := c.Listing([]uint8{0xFF, 0x60, 0x01, 0x00}, nil)
+	if len(lines) != 3 || lines[0].Text != "DB 0xFF" || lines[1].Address != 0x201 || lines[1].Text != "LD V0, 0x01" {
+		t.Errorf("Listing did not realign to code at an odd address. Got %+v", lines)
+	}
+}
+
+func TestWriteListing(t *testing.T) {
+	table := symbols.New()
+	table.AddLabel(0x208, "loop")
+
+	buf := bytes.Buffer{}
+	if err := run(t).WriteListing(&buf, program, table); err != nil {
+		t.Fatal(err)
+	}
+
+	listing := buf.String()
+	for _, expected := range []string{"loop:\n", "    #####  0x204  6105  LD V1, 0x05", "taken 1, not taken 0", "4 of 5 lines executed (80.0%)"} {
+		if !strings.Contains(listing, expected) {
+			t.Errorf("Listing is missing %q:\n%s", expected, listing)
+		}
+	}
+}
+
+func TestWriteHTML(t *testing.T) {
+	buf := bytes.Buffer{}
+	if err := run(t).WriteHTML(&buf, "<test>", program, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	page := buf.String()
+	for _, expected := range []string{"Coverage of &lt;test&gt;", `<tr class="missed"><td class="hits">0</td><td>0x204</td>`, `class="partial"`} {
+		if !strings.Contains(page, expected) {
+			t.Errorf("Page is missing %q", expected)
+		}
+	}
+}
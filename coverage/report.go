@@ -0,0 +1,196 @@
+package coverage
+
+import (
+	"chip8/chip8"
+	"chip8/symbols"
+	"fmt"
+	"html/template"
+	"io"
+)
+
+// Line is a line of an annotated listing
+type Line struct {
+	Address uint16
+	Opcode  uint16
+	Text    string
+	Label   string // Set if a label starts at the address
+	Hits    uint64
+	Branch  *Branch // Set for skip instructions
+}
+
+// Covered reports whether the line ran, and for a skip whether it went both ways
+func (l Line) Covered() bool {
+	if l.Branch != nil {
+		return l.Branch.Taken > 0 && l.Branch.NotTaken > 0
+	}
+
+	return l.Hits > 0
+}
+
+// Summary totals up a listing
+type Summary struct {
+	Lines       int // Lines of the listing, which includes any data in the ROM
+	Executed    int
+	Skips       int
+	SkipsTaken  int // Skips that were taken at least once
+	SkipsPassed int // Skips that were not taken at least once
+}
+
+func (s Summary) String() string {
+	return fmt.Sprintf("%d of %d lines executed (%s), skips taken %d of %d (%s), not taken %d of %d (%s)", s.Executed, s.Lines,
+		percent(s.Executed, s.Lines), s.SkipsTaken, s.Skips, percent(s.SkipsTaken, s.Skips), s.SkipsPassed, s.Skips,
+		percent(s.SkipsPassed, s.Skips))
+}
+
+func percent(n int, total int) string {
+	if total == 0 {
+		return "-"
+	}
+
+	return fmt.Sprintf("%.1f%%", float64(n)*100/float64(total))
+}
+
+// Listing disassembles a ROM with the coverage of each instruction. Listings go two bytes at a time like chip8.DisassembleROM, but
+// realign when code that ran starts at an odd address so it can still be annotated. The symbol table may be nil.
+func (c *Coverage) Listing(rom []uint8, table *symbols.Table) []Line {
+	lines := []Line{}
+
+	for i := 0; i < len(rom); {
+		address := uint16(chip8.ProgramStart + i)
+		if c.Hits[address] == 0 && c.Hits[address+1] > 0 || i+1 >= len(rom) {
+			lines = append(lines, Line{Address: address, Opcode: uint16(rom[i]), Text: fmt.Sprintf("DB 0x%02X", rom[i])})
+			i++
+			continue
+		}
+
+		line := Line{Address: address, Opcode: uint16(rom[i])<<8 | uint16(rom[i+1]), Hits: c.Hits[address]}
+		line.Text = chip8.Disassemble(line.Opcode)
+		if symbol, ok := table.Symbol(address); ok {
+			if _, isLabel := table.Label(symbol); isLabel {
+				line.Label = symbol
+			}
+		}
+		if branch, ok := c.Branches[address]; ok {
+			line.Branch = branch
+		} else if instruction, err := chip8.Decode(line.Opcode); err == nil && IsSkip(instruction.Command) {
+			line.Branch = &Branch{}
+		}
+
+		lines = append(lines, line)
+		i += 2
+	}
+
+	return lines
+}
+
+// Summarise totals up a listing
+func Summarise(lines []Line) Summary {
+	summary := Summary{Lines: len(lines)}
+	for _, line := range lines {
+		if line.Hits > 0 {
+			summary.Executed++
+		}
+		if line.Branch != nil {
+			summary.Skips++
+			if line.Branch.Taken > 0 {
+				summary.SkipsTaken++
+			}
+			if line.Branch.NotTaken > 0 {
+				summary.SkipsPassed++
+			}
+		}
+	}
+
+	return summary
+}
+
+// WriteListing prints an annotated disassembly, with the hit count of each line and the taken/not taken counts of each skip. Lines
+// that never ran are marked with #####, in the style of gcov.
+func (c *Coverage) WriteListing(w io.Writer, rom []uint8, table *symbols.Table) error {
+	lines := c.Listing(rom, table)
+
+	for _, line := range lines {
+		if line.Label != "" {
+			if _, err := fmt.Fprintf(w, "%s:\n", line.Label); err != nil {
+				return err
+			}
+		}
+
+		hits := "#####"
+		if line.Hits > 0 {
+			hits = fmt.Sprint(line.Hits)
+		}
+		text := fmt.Sprintf("%9s  0x%03X  %04X  %s", hits, line.Address, line.Opcode, line.Text)
+		if line.Branch != nil {
+			text = fmt.Sprintf("%-40s  taken %d, not taken %d", text, line.Branch.Taken, line.Branch.NotTaken)
+		}
+
+		if _, err := fmt.Fprintln(w, text); err != nil {
+			return err
+		}
+	}
+
+	_, err := fmt.Fprintf(w, "\n%d runs: %s\n", c.Runs, Summarise(lines))
+	return err
+}
+
+var htmlTemplate = template.Must(template.New("coverage").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Coverage of {{.Name}}</title>
+<style>
+body { font-family: monospace; }
+table { border-collapse: collapse; }
+td { padding: 0 1em; white-space: pre; }
+td.hits { text-align: right; }
+tr.covered { background: #d7f5d7; }
+tr.partial { background: #f8eec0; }
+tr.missed { background: #f8d7d7; }
+tr.label td { font-weight: bold; background: none; }
+</style>
+</head>
+<body>
+<h1>Coverage of {{.Name}}</h1>
+<p>{{.Runs}} runs: {{.Summary}}</p>
+<table>
+<tr><th>Hits</th><th>Address</th><th>Opcode</th><th>Instruction</th><th>Skip</th></tr>
+{{range .Lines}}{{if .Label}}<tr class="label"><td colspan="5">{{.Label}}:</td></tr>
+{{end}}<tr class="{{.Class}}"><td class="hits">{{.Hits}}</td><td>0x{{printf "%03X" .Address}}</td><td>{{printf "%04X" .Opcode}}</td><td>{{.Text}}</td><td>{{if .Branch}}taken {{.Branch.Taken}}, not taken {{.Branch.NotTaken}}{{end}}</td></tr>
+{{end}}</table>
+</body>
+</html>
+`))
+
+// htmlLine adds the row class to a line
+type htmlLine struct {
+	Line
+	Class string
+}
+
+// WriteHTML writes the annotated listing as a web page, with lines that ran in green, skips that only went one way in yellow and
+// lines that never ran in red. Sprite data never runs, so it shows up in red too.
+func (c *Coverage) WriteHTML(w io.Writer, name string, rom []uint8, table *symbols.Table) error {
+	lines := c.Listing(rom, table)
+
+	rows := []htmlLine{}
+	for _, line := range lines {
+		var class string
+		switch {
+		case line.Covered():
+			class = "covered"
+		case line.Hits > 0:
+			class = "partial"
+		default:
+			class = "missed"
+		}
+		rows = append(rows, htmlLine{Line: line, Class: class})
+	}
+
+	return htmlTemplate.Execute(w, map[string]interface{}{
+		"Name":    name,
+		"Runs":    c.Runs,
+		"Summary": Summarise(lines),
+		"Lines":   rows,
+	})
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"chip8/chip8"
+	"chip8/coverage"
+	"chip8/symbols"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// coverageOutput is coverage being recorded during a run, and the file it is merged into at the end
+type coverageOutput struct {
+	coverage *coverage.Coverage
+	path     string
+}
+
+// startCoverage starts recording coverage, or returns nil if it wasn't asked for
+func startCoverage(path string, info *chip8.ROMInfo) *coverageOutput {
+	if path == "" {
+		return nil
+	}
+
+	return &coverageOutput{coverage: coverage.New(info.SHA1), path: path}
+}
+
+// save merges the run into the coverage file, creating it if it doesn't exist yet. Safe to call on nil coverage.
+func (co *coverageOutput) save() error {
+	if co == nil {
+		return nil
+	}
+
+	merged := co.coverage
+	if previous, err := coverage.Load(co.path); err == nil {
+		if err := previous.Merge(co.coverage); err != nil {
+			return err
+		}
+		merged = previous
+	} else if !os.IsNotExist(err) {
+		return err
+	}
+
+	return merged.Save(co.path)
+}
+
+func coverageCommand(args []string) int {
+	fs := newFlagSet("coverage")
+	html := fs.String("html", "", "write the report as a web page to this file instead of printing a listing")
+	symbolFile := fs.String("symbols", "", "symbol file naming addresses in the report (default the ROM with a .sym extension if it exists)")
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Usage: chip8 coverage [flags] <rom> <coverage file>...")
+		fmt.Fprintln(os.Stderr, "Coverage files are written by 'chip8 run -coverage' and are merged for the report.")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return usageExitCode(err)
+	}
+	if fs.NArg() < 2 {
+		fs.Usage()
+		return exitUsage
+	}
+
+	rom := fs.Arg(0)
+	data, err := ioutil.ReadFile(rom)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Could not load ROM:", err)
+		return exitROM
+	}
+	info, err := chip8.NewROMInfo(data)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Invalid ROM:", err)
+		return exitROM
+	}
+
+	table, err := symbols.LoadForROM(rom, *symbolFile)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Could not load symbols:", err)
+		return exitUsage
+	}
+
+	merged := coverage.New(info.SHA1)
+	merged.Runs = 0
+	for _, path := range fs.Args()[1:] {
+		c, err := coverage.Load(path)
+		if err == nil {
+			err = merged.Merge(c)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Could not load %s: %v\n", path, err)
+			return exitUsage
+		}
+	}
+
+	if *html == "" {
+		if err := merged.WriteListing(os.Stdout, data, table); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return exitError
+		}
+		return exitOK
+	}
+
+	file, err := os.Create(*html)
+	if err == nil {
+		err = merged.WriteHTML(file, filepath.Base(rom), data, table)
+		if closeErr := file.Close(); err == nil {
+			err = closeErr
+		}
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Could not write report:", err)
+		return exitError
+	}
+
+	return exitOK
+}
@@ -0,0 +1,99 @@
+package dap
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/textproto"
+	"strconv"
+	"sync"
+)
+
+// Request is a request from the client
+type Request struct {
+	Seq       int             `json:"seq"`
+	Type      string          `json:"type"`
+	Command   string          `json:"command"`
+	Arguments json.RawMessage `json:"arguments,omitempty"`
+}
+
+// Response answers a request
+type Response struct {
+	Seq        int         `json:"seq"`
+	Type       string      `json:"type"`
+	RequestSeq int         `json:"request_seq"`
+	Success    bool        `json:"success"`
+	Command    string      `json:"command"`
+	Message    string      `json:"message,omitempty"`
+	Body       interface{} `json:"body,omitempty"`
+}
+
+// Event tells the client something happened
+type Event struct {
+	Seq   int         `json:"seq"`
+	Type  string      `json:"type"`
+	Event string      `json:"event"`
+	Body  interface{} `json:"body,omitempty"`
+}
+
+// readMessage reads the body of a message framed with a Content-Length header
+func readMessage(r *bufio.Reader) ([]byte, error) {
+	headers, err := textproto.NewReader(r).ReadMIMEHeader()
+	if err != nil {
+		return nil, err
+	}
+
+	length, err := strconv.Atoi(headers.Get("Content-Length"))
+	if err != nil || length < 0 {
+		return nil, errors.New("Missing or invalid Content-Length header")
+	}
+
+	body := make([]byte, length)
+	if _, err := io.ReadFull(r, body); err != nil {
+		return nil, err
+	}
+
+	return body, nil
+}
+
+// writer sends messages, numbering them in order. Safe to use from several goroutines.
+type writer struct {
+	mu  sync.Mutex
+	w   io.Writer
+	seq int
+}
+
+// write sends a message built with the next sequence number
+func (mw *writer) write(build func(seq int) interface{}) error {
+	mw.mu.Lock()
+	defer mw.mu.Unlock()
+
+	mw.seq++
+	body, err := json.Marshal(build(mw.seq))
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(mw.w, "Content-Length: %d\r\n\r\n%s", len(body), body)
+	return err
+}
+
+func (mw *writer) respond(request *Request, body interface{}) error {
+	return mw.write(func(seq int) interface{} {
+		return Response{Seq: seq, Type: "response", RequestSeq: request.Seq, Success: true, Command: request.Command, Body: body}
+	})
+}
+
+func (mw *writer) fail(request *Request, err error) error {
+	return mw.write(func(seq int) interface{} {
+		return Response{Seq: seq, Type: "response", RequestSeq: request.Seq, Command: request.Command, Message: err.Error()}
+	})
+}
+
+func (mw *writer) event(event string, body interface{}) error {
+	return mw.write(func(seq int) interface{} {
+		return Event{Seq: seq, Type: "event", Event: event, Body: body}
+	})
+}
@@ -0,0 +1,534 @@
+// Package dap is a Debug Adapter Protocol server, so editors can launch ROMs and step through them. With a symbol file from the
+// assembler, breakpoints are set on source lines, stack frames point back into the source and are named after labels. Breakpoints can
+// also be set on instruction addresses, given in hex or as a label such as draw_paddle+0x4.
+//
+// The launch request takes:
+//
+//	{
+//		"program": "game.ch8",
+//		"symbols": "game.sym",     // Optional; defaults to the program with a .sym extension if it exists
+//		"quirks": "cosmac",        // Optional quirk preset; defaults to the one for the detected platform
+//		"cyclesPerFrame": 10,      // Optional
+//		"stopOnEntry": true        // Optional
+//	}
+package dap
+
+import (
+	"bufio"
+	"chip8/chip8"
+	"chip8/headless"
+	"chip8/symbols"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Used when the launch request doesn't say how fast to run
+const DefaultCyclesPerFrame = 10
+
+// There is only ever one thread
+const threadID = 1
+
+// Variable references for the scopes
+const (
+	registersReference = 1
+	timersReference    = 2
+)
+
+// LaunchArguments are the arguments of the launch request
+type LaunchArguments struct {
+	Program        string `json:"program"`
+	Symbols        string `json:"symbols,omitempty"`
+	Quirks         string `json:"quirks,omitempty"`
+	CyclesPerFrame int    `json:"cyclesPerFrame,omitempty"`
+	StopOnEntry    bool   `json:"stopOnEntry,omitempty"`
+}
+
+// session is a single debugging session with one ROM
+type session struct {
+	w *writer
+
+	mu             sync.Mutex
+	computer       *chip8.Chip8
+	display        *headless.HeadlessDisplay
+	symbols        *symbols.Table
+	cyclesPerFrame int
+	stopOnEntry    bool
+	started        bool // Configuration is done and the CPU has its goroutine
+	running        bool
+	// Breakpoints set on each source file, and on instruction addresses
+	sourceBreakpoints      map[string][]uint16
+	instructionBreakpoints []uint16
+	// Set when resuming so a breakpoint on the instruction the CPU is stopped at doesn't stop it again straight away
+	skipBreakpoint bool
+	// While stepping, reports when the step is finished
+	stepDone func() bool
+	stop     chan struct{}
+}
+
+// Serve runs a session over a reader and writer, such as stdin and stdout, until the client disconnects
+func Serve(r io.Reader, w io.Writer) error {
+	s := &session{w: &writer{w: w}, sourceBreakpoints: map[string][]uint16{}, stop: make(chan struct{})}
+	defer close(s.stop)
+
+	reader := bufio.NewReader(r)
+	for {
+		body, err := readMessage(reader)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		request := Request{}
+		if err := json.Unmarshal(body, &request); err != nil {
+			return err
+		}
+		if request.Type != "request" {
+			continue
+		}
+
+		done, err := s.handle(&request)
+		if err != nil {
+			return err
+		}
+		if done {
+			return nil
+		}
+	}
+}
+
+// ListenAndServe listens on a TCP address and runs a session for each client in turn. Errors from a session are written to stderr.
+func ListenAndServe(addr string) error {
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	defer l.Close()
+
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			return err
+		}
+
+		// A client that breaks the protocol only ends its own session, so report it and wait for the next one
+		if err := Serve(conn, conn); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+		}
+		conn.Close()
+	}
+}
+
+// handle runs a request and returns true once the session is over. Errors are only returned if the client can't be written to; failed
+// requests are reported to the client instead.
+func (s *session) handle(request *Request) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.computer == nil {
+		switch request.Command {
+		case "initialize", "launch", "disconnect", "terminate":
+		default:
+			return false, s.w.fail(request, errors.New("No program has been launched"))
+		}
+	}
+
+	switch request.Command {
+	case "initialize":
+		return false, s.w.respond(request, map[string]interface{}{
+			"supportsConfigurationDoneRequest": true,
+			"supportsInstructionBreakpoints":   true,
+			"supportsReadMemoryRequest":        true,
+			"supportsTerminateRequest":         true,
+		})
+
+	case "launch":
+		args := LaunchArguments{}
+		if err := json.Unmarshal(request.Arguments, &args); err != nil {
+			return false, s.w.fail(request, err)
+		}
+		if err := s.launch(args); err != nil {
+			return false, s.w.fail(request, err)
+		}
+		if err := s.w.respond(request, nil); err != nil {
+			return false, err
+		}
+		return false, s.w.event("initialized", nil)
+
+	case "configurationDone":
+		if s.started {
+			return false, s.w.fail(request, errors.New("Configuration is already done"))
+		}
+		if err := s.w.respond(request, nil); err != nil {
+			return false, err
+		}
+		s.started = true
+		go s.run()
+		if s.stopOnEntry {
+			return false, s.stopped("entry", "")
+		}
+		s.resume(nil)
+		return false, nil
+
+	case "setBreakpoints":
+		return false, s.setBreakpoints(request)
+
+	case "setInstructionBreakpoints":
+		return false, s.setInstructionBreakpoints(request)
+
+	case "threads":
+		return false, s.w.respond(request, map[string]interface{}{
+			"threads": []map[string]interface{}{{"id": threadID, "name": "CHIP-8"}},
+		})
+
+	case "stackTrace":
+		return false, s.w.respond(request, s.stackTrace())
+
+	case "scopes":
+		return false, s.w.respond(request, map[string]interface{}{
+			"scopes": []map[string]interface{}{
+				{"name": "Registers", "variablesReference": registersReference, "expensive": false},
+				{"name": "Timers", "variablesReference": timersReference, "expensive": false},
+			},
+		})
+
+	case "variables":
+		args := struct {
+			VariablesReference int `json:"variablesReference"`
+		}{}
+		json.Unmarshal(request.Arguments, &args)
+		return false, s.w.respond(request, map[string]interface{}{"variables": s.variables(args.VariablesReference)})
+
+	case "readMemory":
+		return false, s.readMemory(request)
+
+	case "continue":
+		s.resume(nil)
+		return false, s.w.respond(request, map[string]interface{}{"allThreadsContinued": true})
+
+	case "next", "stepIn", "stepOut":
+		s.resume(s.stepCondition(request.Command))
+		return false, s.w.respond(request, nil)
+
+	case "pause":
+		if err := s.w.respond(request, nil); err != nil {
+			return false, err
+		}
+		// Clients refresh everything on a stopped event, so don't send another if the CPU is already stopped
+		if !s.running {
+			return false, nil
+		}
+		return false, s.stopped("pause", "")
+
+	case "disconnect", "terminate":
+		s.running = false
+		if err := s.w.respond(request, nil); err != nil {
+			return true, err
+		}
+		return true, s.w.event("terminated", nil)
+	}
+
+	return false, s.w.fail(request, errors.New("Unsupported request "+request.Command))
+}
+
+// launch loads the ROM and symbols
+func (s *session) launch(args LaunchArguments) error {
+	if s.computer != nil {
+		return errors.New("A program has already been launched")
+	}
+	if args.Program == "" {
+		return errors.New("Launch needs a program")
+	}
+
+	data, err := os.ReadFile(args.Program)
+	if err != nil {
+		return err
+	}
+	info, err := chip8.NewROMInfo(data)
+	if err != nil {
+		return err
+	}
+
+	preset := args.Quirks
+	if preset == "" {
+		preset = info.Platform.QuirkPreset()
+	}
+	quirks, err := chip8.QuirkPreset(preset)
+	if err != nil {
+		return err
+	}
+
+	if s.symbols, err = symbols.LoadForROM(args.Program, args.Symbols); err != nil {
+		return err
+	}
+
+	s.display = headless.New()
+	s.computer = chip8.New(s.display)
+	s.computer.SetQuirks(quirks)
+	if _, err := s.computer.LoadFromMemory(data); err != nil {
+		return err
+	}
+
+	s.cyclesPerFrame = args.CyclesPerFrame
+	if s.cyclesPerFrame < 1 {
+		s.cyclesPerFrame = DefaultCyclesPerFrame
+	}
+	s.stopOnEntry = args.StopOnEntry
+
+	return nil
+}
+
+// run executes frames at 60Hz while the CPU isn't stopped, until the session ends
+func (s *session) run() {
+	ticker := time.NewTicker(time.Second / 60)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.stop:
+			return
+		case <-ticker.C:
+		}
+
+		s.mu.Lock()
+		err := s.runFrame()
+		s.mu.Unlock()
+		if err != nil {
+			return
+		}
+	}
+}
+
+// runFrame runs a frame of instructions, stopping at breakpoints, errors and the end of a step. Must be called with the lock held.
+func (s *session) runFrame() error {
+	for i := 0; i < s.cyclesPerFrame && s.running; i++ {
+		if s.isBreakpoint(s.computer.Registers().PC) && !s.skipBreakpoint {
+			return s.stopped("breakpoint", "")
+		}
+		s.skipBreakpoint = false
+
+		if err := s.computer.Tick(); err != nil {
+			return s.stopped("exception", err.Error())
+		}
+		if s.stepDone != nil && s.stepDone() {
+			return s.stopped("step", "")
+		}
+	}
+
+	s.display.Update(s.computer.GetScreen(), s.computer.GetDirtyFlags())
+	return nil
+}
+
+// resume lets the CPU run, until stepDone reports the step is over if it is set. Must be called with the lock held.
+func (s *session) resume(stepDone func() bool) {
+	s.running = true
+	s.skipBreakpoint = true
+	s.stepDone = stepDone
+}
+
+// stopped stops the CPU and tells the client. Must be called with the lock held.
+func (s *session) stopped(reason string, text string) error {
+	s.running = false
+	s.stepDone = nil
+
+	body := map[string]interface{}{"reason": reason, "threadId": threadID, "allThreadsStopped": true}
+	if text != "" {
+		body["text"] = text
+		body["description"] = text
+	}
+
+	return s.w.event("stopped", body)
+}
+
+// stepCondition works out when a step request is finished. Steps go by source line when the current instruction has one, otherwise by
+// instruction. next and stepOut run whole subroutines rather than stopping inside them.
+func (s *session) stepCondition(command string) func() bool {
+	start := s.computer.Registers()
+	startLine, hasLine := s.symbols.Line(start.PC)
+
+	return func() bool {
+		now := s.computer.Registers()
+		switch command {
+		case "stepOut":
+			return now.SP < start.SP
+		case "next":
+			if now.SP > start.SP {
+				return false
+			}
+		}
+
+		if !hasLine {
+			return true
+		}
+		line, ok := s.symbols.Line(now.PC)
+		return !ok || line != startLine
+	}
+}
+
+func (s *session) isBreakpoint(address uint16) bool {
+	for _, addresses := range s.sourceBreakpoints {
+		for _, a := range addresses {
+			if a == address {
+				return true
+			}
+		}
+	}
+	for _, a := range s.instructionBreakpoints {
+		if a == address {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (s *session) setBreakpoints(request *Request) error {
+	args := struct {
+		Source struct {
+			Path string `json:"path"`
+		} `json:"source"`
+		Breakpoints []struct {
+			Line int `json:"line"`
+		} `json:"breakpoints"`
+	}{}
+	if err := json.Unmarshal(request.Arguments, &args); err != nil {
+		return s.w.fail(request, err)
+	}
+
+	addresses := []uint16{}
+	breakpoints := []map[string]interface{}{}
+	for _, b := range args.Breakpoints {
+		breakpoint := map[string]interface{}{"verified": false, "line": b.Line}
+		if found := s.symbols.Addresses(args.Source.Path, b.Line); len(found) > 0 {
+			addresses = append(addresses, found[0])
+			breakpoint["verified"] = true
+			breakpoint["instructionReference"] = formatAddress(found[0])
+		} else {
+			breakpoint["message"] = "No code for this line in the symbol file"
+		}
+		breakpoints = append(breakpoints, breakpoint)
+	}
+	s.sourceBreakpoints[args.Source.Path] = addresses
+
+	return s.w.respond(request, map[string]interface{}{"breakpoints": breakpoints})
+}
+
+func (s *session) setInstructionBreakpoints(request *Request) error {
+	args := struct {
+		Breakpoints []struct {
+			InstructionReference string `json:"instructionReference"`
+			Offset               int    `json:"offset"`
+		} `json:"breakpoints"`
+	}{}
+	if err := json.Unmarshal(request.Arguments, &args); err != nil {
+		return s.w.fail(request, err)
+	}
+
+	s.instructionBreakpoints = []uint16{}
+	breakpoints := []map[string]interface{}{}
+	for _, b := range args.Breakpoints {
+		address, err := s.symbols.ResolveAddress(b.InstructionReference)
+		if err != nil || int(address)+b.Offset < 0 || int(address)+b.Offset >= chip8.MemorySize {
+			breakpoints = append(breakpoints, map[string]interface{}{"verified": false, "message": "Invalid address"})
+			continue
+		}
+
+		address = uint16(int(address) + b.Offset)
+		s.instructionBreakpoints = append(s.instructionBreakpoints, address)
+		breakpoints = append(breakpoints, map[string]interface{}{"verified": true, "instructionReference": formatAddress(address)})
+	}
+
+	return s.w.respond(request, map[string]interface{}{"breakpoints": breakpoints})
+}
+
+// stackTrace builds the frames from the PC and the CALL stack, innermost first
+func (s *session) stackTrace() map[string]interface{} {
+	frames := []map[string]interface{}{}
+	for i, f := range s.symbols.CallStack(s.computer.Registers().PC, s.computer.Stack()) {
+		frame := map[string]interface{}{
+			"id":                          i,
+			"name":                        s.symbols.Format(f.Address),
+			"line":                        0,
+			"column":                      0,
+			"instructionPointerReference": formatAddress(f.Address),
+		}
+		if f.Location != nil {
+			frame["source"] = map[string]interface{}{"name": filepath.Base(f.Location.File), "path": f.Location.File}
+			frame["line"] = f.Location.Line
+			frame["column"] = 1
+		}
+		frames = append(frames, frame)
+	}
+
+	return map[string]interface{}{"stackFrames": frames, "totalFrames": len(frames)}
+}
+
+func (s *session) variables(reference int) []map[string]interface{} {
+	registers := s.computer.Registers()
+	variables := []map[string]interface{}{}
+	add := func(name string, value string) {
+		variables = append(variables, map[string]interface{}{"name": name, "value": value, "variablesReference": 0})
+	}
+
+	switch reference {
+	case registersReference:
+		for i, v := range registers.V {
+			add(fmt.Sprintf("V%X", i), fmt.Sprintf("0x%02X (%d)", v, v))
+		}
+		add("I", formatAddress(registers.I))
+		add("PC", formatAddress(registers.PC))
+		add("SP", strconv.Itoa(int(registers.SP)))
+	case timersReference:
+		add("DT", strconv.Itoa(int(registers.DT)))
+		add("ST", strconv.Itoa(int(registers.ST)))
+	}
+
+	return variables
+}
+
+func (s *session) readMemory(request *Request) error {
+	args := struct {
+		MemoryReference string `json:"memoryReference"`
+		Offset          int    `json:"offset"`
+		Count           int    `json:"count"`
+	}{}
+	if err := json.Unmarshal(request.Arguments, &args); err != nil {
+		return s.w.fail(request, err)
+	}
+
+	address, err := s.symbols.ResolveAddress(args.MemoryReference)
+	start := int(address) + args.Offset
+	if err != nil || start < 0 || start >= chip8.MemorySize || args.Count < 0 {
+		return s.w.fail(request, errors.New("Invalid memory reference"))
+	}
+
+	// Reads past the end of memory are cut short rather than failing
+	count := args.Count
+	if start+count > chip8.MemorySize {
+		count = chip8.MemorySize - start
+	}
+	data, err := s.computer.ReadMemory(uint16(start), count)
+	if err != nil {
+		return s.w.fail(request, err)
+	}
+
+	return s.w.respond(request, map[string]interface{}{
+		"address":         formatAddress(uint16(start)),
+		"data":            base64.StdEncoding.EncodeToString(data),
+		"unreadableBytes": args.Count - count,
+	})
+}
+
+func formatAddress(address uint16) string {
+	return fmt.Sprintf("0x%03X", address)
+}
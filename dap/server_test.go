@@ -0,0 +1,378 @@
+package dap
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// A main routine that calls a subroutine and then loops forever
+var program = []uint8{
+	0x60, 0x01, // 200: LD V0, 1     game.s:1
+	0x22, 0x08, // 202: CALL 208     game.s:2
+	0x70, 0x01, // 204: ADD V0, 1    game.s:3
+	0x12, 0x04, // 206: JP 204       game.s:4
+	0x61, 0x05, // 208: LD V1, 5     game.s:6
+	0x00, 0xEE, // 20A: RET          game.s:7
+}
+
+const symbolFile = `label 200 main
+label 208 set_v1
+line 200 1 game.s
+line 202 2 game.s
+line 204 3 game.s
+line 206 4 game.s
+line 208 6 game.s
+line 20A 7 game.s
+`
+
+// message is any message from the server
+type message struct {
+	Type       string          `json:"type"`
+	Event      string          `json:"event"`
+	RequestSeq int             `json:"request_seq"`
+	Success    bool            `json:"success"`
+	Message    string          `json:"message"`
+	Body       json.RawMessage `json:"body"`
+}
+
+// client is a scripted editor
+type client struct {
+	t      *testing.T
+	w      io.Writer
+	reader *bufio.Reader
+	seq    int
+	events []message
+}
+
+// startSession writes the program and symbols to a temporary directory and runs a session for them over pipes
+func startSession(t *testing.T) (*client, string) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "game.ch8"), program, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "game.sym"), []byte(symbolFile), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	clientReader, serverWriter := io.Pipe()
+	serverReader, clientWriter := io.Pipe()
+	go func() {
+		Serve(serverReader, serverWriter)
+		serverWriter.Close()
+	}()
+	t.Cleanup(func() {
+		clientWriter.Close()
+	})
+
+	return &client{t: t, w: clientWriter, reader: bufio.NewReader(clientReader)}, dir
+}
+
+// receive reads the next message, failing the test if nothing arrives in time
+func (c *client) receive() message {
+	c.t.Helper()
+
+	type result struct {
+		body []byte
+		err  error
+	}
+	received := make(chan result, 1)
+	go func() {
+		body, err := readMessage(c.reader)
+		received <- result{body, err}
+	}()
+
+	select {
+	case r := <-received:
+		if r.err != nil {
+			c.t.Fatal(r.err)
+		}
+		m := message{}
+		if err := json.Unmarshal(r.body, &m); err != nil {
+			c.t.Fatal(err)
+		}
+		return m
+	case <-time.After(5 * time.Second):
+		c.t.Fatal("Timed out waiting for a message")
+	}
+
+	return message{}
+}
+
+// request sends a request and waits for its response, keeping any events that arrive first
+func (c *client) request(command string, arguments interface{}, body interface{}) message {
+	c.t.Helper()
+
+	c.seq++
+	data, _ := json.Marshal(map[string]interface{}{"seq": c.seq, "type": "request", "command": command, "arguments": arguments})
+	if _, err := fmt.Fprintf(c.w, "Content-Length: %d\r\n\r\n%s", len(data), data); err != nil {
+		c.t.Fatal(err)
+	}
+
+	for {
+		m := c.receive()
+		if m.Type == "event" {
+			c.events = append(c.events, m)
+			continue
+		}
+		if m.RequestSeq != c.seq {
+			c.t.Fatalf("Response was for the wrong request. Expected %d, got %d", c.seq, m.RequestSeq)
+		}
+		if body != nil && m.Success {
+			json.Unmarshal(m.Body, body)
+		}
+		return m
+	}
+}
+
+// event waits for an event, returning its body
+func (c *client) event(name string, body interface{}) {
+	c.t.Helper()
+
+	for {
+		var m message
+		if len(c.events) > 0 {
+			m, c.events = c.events[0], c.events[1:]
+		} else {
+			m = c.receive()
+		}
+		if m.Type == "event" && m.Event == name {
+			if body != nil {
+				json.Unmarshal(m.Body, body)
+			}
+			return
+		}
+	}
+}
+
+type stopped struct {
+	Reason string `json:"reason"`
+	Text   string `json:"text"`
+}
+
+type stackTrace struct {
+	StackFrames []struct {
+		Name   string `json:"name"`
+		Line   int    `json:"line"`
+		Source *struct {
+			Path string `json:"path"`
+		} `json:"source"`
+	} `json:"stackFrames"`
+}
+
+// launch starts the program, setting breakpoints on the lines given before configuration is done
+func (c *client) launch(dir string, stopOnEntry bool, lines ...int) {
+	c.t.Helper()
+
+	if m := c.request("initialize", map[string]interface{}{"adapterID": "chip8"}, nil); !m.Success {
+		c.t.Fatalf("initialize failed: %s", m.Message)
+	}
+	launch := map[string]interface{}{"program": filepath.Join(dir, "game.ch8"), "stopOnEntry": stopOnEntry}
+	if m := c.request("launch", launch, nil); !m.Success {
+		c.t.Fatalf("launch failed: %s", m.Message)
+	}
+	c.event("initialized", nil)
+
+	if len(lines) > 0 {
+		breakpoints := []map[string]int{}
+		for _, line := range lines {
+			breakpoints = append(breakpoints, map[string]int{"line": line})
+		}
+		arguments := map[string]interface{}{"source": map[string]string{"path": filepath.Join(dir, "game.s")}, "breakpoints": breakpoints}
+		c.request("setBreakpoints", arguments, nil)
+	}
+
+	c.request("configurationDone", nil, nil)
+}
+
+// line returns the source line of the top stack frame
+func (c *client) line() int {
+	c.t.Helper()
+
+	trace := stackTrace{}
+	c.request("stackTrace", map[string]int{"threadId": 1}, &trace)
+	if len(trace.StackFrames) == 0 {
+		c.t.Fatal("No stack frames")
+	}
+
+	return trace.StackFrames[0].Line
+}
+
+func TestBreakpointStackTrace(t *testing.T) {
+	c, dir := startSession(t)
+	c.launch(dir, false, 6)
+
+	event := stopped{}
+	c.event("stopped", &event)
+	if event.Reason != "breakpoint" {
+		t.Errorf("Stop reason was not set correctly. Expected breakpoint, got %s", event.Reason)
+	}
+
+	trace := stackTrace{}
+	c.request("stackTrace", map[string]int{"threadId": 1}, &trace)
+	if len(trace.StackFrames) != 2 {
+		t.Fatalf("Stack trace was not built correctly. Expected 2 frames, got %d", len(trace.StackFrames))
+	}
+	if trace.StackFrames[0].Line != 6 || trace.StackFrames[1].Line != 2 {
+		t.Errorf("Frame lines were not set correctly. Expected 6 and 2, got %d and %d", trace.StackFrames[0].Line, trace.StackFrames[1].Line)
+	}
+	if trace.StackFrames[0].Name != "set_v1" || trace.StackFrames[1].Name != "main+0x2" {
+		t.Errorf("Frame names were not set correctly. Expected set_v1 and main+0x2, got %s and %s", trace.StackFrames[0].Name, trace.StackFrames[1].Name)
+	}
+	if trace.StackFrames[0].Source == nil || filepath.Base(trace.StackFrames[0].Source.Path) != "game.s" {
+		t.Errorf("Frame source was not set correctly. Expected game.s, got %v", trace.StackFrames[0].Source)
+	}
+
+	variables := struct {
+		Variables []struct {
+			Name  string `json:"name"`
+			Value string `json:"value"`
+		} `json:"variables"`
+	}{}
+	c.request("variables", map[string]int{"variablesReference": registersReference}, &variables)
+	if len(variables.Variables) != 19 {
+		t.Fatalf("Registers were not listed correctly. Expected 19, got %d", len(variables.Variables))
+	}
+	if variables.Variables[0].Name != "V0" || variables.Variables[0].Value != "0x01 (1)" {
+		t.Errorf("V0 was not shown correctly. Expected 0x01 (1), got %s %s", variables.Variables[0].Name, variables.Variables[0].Value)
+	}
+	if variables.Variables[17].Value != "0x208" {
+		t.Errorf("PC was not shown correctly. Expected 0x208, got %s", variables.Variables[17].Value)
+	}
+
+	c.request("disconnect", nil, nil)
+	c.event("terminated", nil)
+}
+
+func TestUnverifiedBreakpoint(t *testing.T) {
+	c, dir := startSession(t)
+	c.launch(dir, true)
+	c.event("stopped", nil)
+
+	response := struct {
+		Breakpoints []struct {
+			Verified bool `json:"verified"`
+		} `json:"breakpoints"`
+	}{}
+	arguments := map[string]interface{}{
+		"source":      map[string]string{"path": filepath.Join(dir, "game.s")},
+		"breakpoints": []map[string]int{{"line": 3}, {"line": 5}},
+	}
+	c.request("setBreakpoints", arguments, &response)
+	if len(response.Breakpoints) != 2 || !response.Breakpoints[0].Verified || response.Breakpoints[1].Verified {
+		t.Errorf("Breakpoints were not verified correctly. Expected line 3 only, got %+v", response.Breakpoints)
+	}
+}
+
+func TestStepping(t *testing.T) {
+	c, dir := startSession(t)
+	c.launch(dir, true)
+
+	event := stopped{}
+	c.event("stopped", &event)
+	if event.Reason != "entry" {
+		t.Errorf("Stop reason was not set correctly. Expected entry, got %s", event.Reason)
+	}
+
+	steps := []struct {
+		command string
+		line    int
+	}{
+		{"next", 2},
+		{"stepIn", 6},
+		{"stepOut", 3},
+		{"next", 4},
+		{"next", 3},
+	}
+	for _, step := range steps {
+		c.request(step.command, map[string]int{"threadId": 1}, nil)
+		c.event("stopped", &event)
+		if event.Reason != "step" {
+			t.Errorf("Stop reason after %s was not set correctly. Expected step, got %s", step.command, event.Reason)
+		}
+		if line := c.line(); line != step.line {
+			t.Errorf("Line after %s was not set correctly. Expected %d, got %d", step.command, step.line, line)
+		}
+	}
+}
+
+func TestStepOverCall(t *testing.T) {
+	c, dir := startSession(t)
+	c.launch(dir, true)
+	c.event("stopped", nil)
+
+	c.request("next", map[string]int{"threadId": 1}, nil)
+	c.event("stopped", nil)
+	c.request("next", map[string]int{"threadId": 1}, nil)
+	c.event("stopped", nil)
+
+	if line := c.line(); line != 3 {
+		t.Errorf("Line after stepping over the call was not set correctly. Expected 3, got %d", line)
+	}
+}
+
+func TestInstructionBreakpointAndPause(t *testing.T) {
+	c, dir := startSession(t)
+	c.launch(dir, true)
+	c.event("stopped", nil)
+
+	c.request("setInstructionBreakpoints", map[string]interface{}{"breakpoints": []map[string]string{{"instructionReference": "set_v1+0x2"}}}, nil)
+	c.request("continue", map[string]int{"threadId": 1}, nil)
+
+	event := stopped{}
+	c.event("stopped", &event)
+	if event.Reason != "breakpoint" || c.line() != 7 {
+		t.Errorf("Instruction breakpoint was not hit correctly. Expected line 7, got %s at %d", event.Reason, c.line())
+	}
+
+	c.request("setInstructionBreakpoints", map[string]interface{}{"breakpoints": []interface{}{}}, nil)
+	c.request("continue", map[string]int{"threadId": 1}, nil)
+	c.request("pause", map[string]int{"threadId": 1}, nil)
+	c.event("stopped", &event)
+	if event.Reason != "pause" {
+		t.Errorf("Stop reason was not set correctly. Expected pause, got %s", event.Reason)
+	}
+
+	memory := struct {
+		Address string `json:"address"`
+		Data    string `json:"data"`
+	}{}
+	c.request("readMemory", map[string]interface{}{"memoryReference": "0x200", "count": 2}, &memory)
+	if memory.Address != "0x200" || memory.Data != "YAE=" {
+		t.Errorf("Memory was not read correctly. Expected YAE= at 0x200, got %s at %s", memory.Data, memory.Address)
+	}
+}
+
+func TestRepeatedConfigurationAndPause(t *testing.T) {
+	c, dir := startSession(t)
+	c.launch(dir, true)
+	c.event("stopped", nil)
+
+	if m := c.request("configurationDone", nil, nil); m.Success {
+		t.Error("Expected a second configurationDone to fail")
+	}
+
+	c.request("pause", map[string]int{"threadId": 1}, nil)
+	c.request("threads", nil, nil)
+	for _, m := range c.events {
+		if m.Event == "stopped" {
+			t.Error("Pausing a stopped CPU sent a stopped event")
+		}
+	}
+}
+
+func TestRequestsNeedLaunch(t *testing.T) {
+	c, _ := startSession(t)
+
+	if m := c.request("stackTrace", map[string]int{"threadId": 1}, nil); m.Success {
+		t.Error("Expected stackTrace to fail before launch")
+	}
+	if m := c.request("launch", map[string]string{"program": "missing.ch8"}, nil); m.Success {
+		t.Error("Expected launching a missing program to fail")
+	}
+}
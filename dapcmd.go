@@ -0,0 +1,36 @@
+package main
+
+import (
+	"chip8/dap"
+	"fmt"
+	"os"
+)
+
+func dapCommand(args []string) int {
+	fs := newFlagSet("dap")
+	listen := fs.String("listen", "", "address to accept editors on, such as localhost:4712 (default talk over stdin and stdout)")
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Usage: chip8 dap [flags]")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return usageExitCode(err)
+	}
+	if fs.NArg() != 0 {
+		fs.Usage()
+		return exitUsage
+	}
+
+	var err error
+	if *listen != "" {
+		err = dap.ListenAndServe(*listen)
+	} else {
+		err = dap.Serve(os.Stdin, os.Stdout)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitError
+	}
+
+	return exitOK
+}
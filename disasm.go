@@ -0,0 +1,62 @@
+package main
+
+import (
+	"chip8/chip8"
+	"chip8/symbols"
+	"fmt"
+	"os"
+)
+
+func disasmCommand(args []string) int {
+	fs := newFlagSet("disasm")
+	symbolFile := fs.String("symbols", "", "symbol file naming addresses in the listing (default the ROM with a .sym extension if it exists)")
+	source := addROMFlags(fs)
+	rom, err := parseROMArgs(fs, args)
+	if err != nil {
+		return usageExitCode(err)
+	}
+
+	data, err := source.read(rom)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Could not load ROM:", err)
+		return exitROM
+	}
+
+	table, err := symbols.LoadForROM(rom, *symbolFile)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Could not load symbols:", err)
+		return exitUsage
+	}
+
+	for _, line := range chip8.DisassembleROM(data) {
+		if symbol, ok := table.Symbol(line.Address); ok && isLabel(table, symbol) {
+			fmt.Printf("%s:\n", symbol)
+		}
+
+		text := fmt.Sprintf("0x%03X  %04X  %s", line.Address, line.Opcode, line.Text)
+		if target, ok := addressOperand(line.Opcode); ok {
+			if symbol, ok := table.Symbol(target); ok {
+				text += "  ; " + symbol
+			}
+		}
+		fmt.Println(text)
+	}
+
+	return exitOK
+}
+
+// isLabel reports whether a symbol is a label itself rather than an offset from one
+func isLabel(table *symbols.Table, symbol string) bool {
+	_, ok := table.Label(symbol)
+	return ok
+}
+
+// addressOperand returns the address an instruction jumps to, calls or points I at
+func addressOperand(opcode uint16) (uint16, bool) {
+	switch opcode & 0xF000 {
+	case 0x1000, 0x2000, 0xA000, 0xB000:
+		return opcode & 0x0FFF, true
+	}
+
+	return 0, false
+}
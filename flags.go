@@ -0,0 +1,72 @@
+package main
+
+import (
+	"chip8/chip8"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+)
+
+var errMissingROM = errors.New("missing ROM path")
+
+// newFlagSet creates the flag set for a command with usage output that includes the positional ROM argument
+func newFlagSet(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: chip8 %s [flags] <rom>\n", name)
+		fs.PrintDefaults()
+	}
+
+	return fs
+}
+
+// parseROMArgs parses the flags for a command and returns the single ROM path that must follow them
+func parseROMArgs(fs *flag.FlagSet, args []string) (string, error) {
+	if err := fs.Parse(args); err != nil {
+		return "", err
+	}
+
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return "", errMissingROM
+	}
+
+	return fs.Arg(0), nil
+}
+
+// usageExitCode is the exit code for an error returned from parseROMArgs. Asking for help isn't a failure.
+func usageExitCode(err error) int {
+	if err == flag.ErrHelp {
+		return exitOK
+	}
+
+	return exitUsage
+}
+
+// quirksAuto picks the quirk preset from the platform detected when the ROM is loaded
+const quirksAuto = "auto"
+
+func quirksUsage() string {
+	return "quirk preset to use (" + quirksAuto + ", " + strings.Join(chip8.QuirkPresetNames(), ", ") + ")"
+}
+
+// validateQuirks checks a -quirks flag before anything is loaded so typos are reported as usage errors
+func validateQuirks(name string) error {
+	if name == quirksAuto {
+		return nil
+	}
+
+	_, err := chip8.QuirkPreset(name)
+	return err
+}
+
+// resolveQuirks turns a -quirks flag into the quirks to use for a loaded ROM
+func resolveQuirks(name string, info *chip8.ROMInfo) (chip8.Quirks, error) {
+	if name == quirksAuto {
+		name = info.Platform.QuirkPreset()
+	}
+
+	return chip8.QuirkPreset(name)
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"chip8/chip8"
+	"chip8/record"
+	"chip8/render"
+	"chip8/symbols"
+	"fmt"
+	"math"
+	"os"
+)
+
+// runFrame executes one 60Hz frame worth of instructions using tick, which is either the CPU's own Tick or a debugger's. Dirty flags
+// are reset on every tick so they are collected over the whole frame, otherwise pixels changed by earlier ticks would never be drawn.
+func runFrame(computer *chip8.Chip8, tick func() error, cycles int) ([64][32]bool, error) {
+	frameDirty := [64][32]bool{}
+
+	for i := 0; i < cycles; i++ {
+		if err := tick(); err != nil {
+			return frameDirty, err
+		}
+
+		for x, column := range computer.GetDirtyFlags() {
+			for y, isDirty := range column {
+				frameDirty[x][y] = frameDirty[x][y] || isDirty
+			}
+		}
+	}
+
+	return frameDirty, nil
+}
+
+// printCallStack shows where the CPU is and the calls that led there, innermost first
+func printCallStack(computer *chip8.Chip8, table *symbols.Table) {
+	fmt.Fprintln(os.Stderr, "Call stack:")
+	for i, frame := range table.CallStack(computer.Registers().PC, computer.Stack()) {
+		fmt.Fprintf(os.Stderr, "  #%d  %s\n", i, frame)
+	}
+}
+
+// newRecorder creates a recorder matching how the display is drawn, or nil if nothing is being recorded
+func newRecorder(opts runOptions) *record.Recorder {
+	if opts.record == "" {
+		return nil
+	}
+
+	recorder := record.New(recordRenderOptions(opts))
+	recorder.SetBlend(opts.blend, opts.blendFrames)
+	return recorder
+}
+
+// saveRecording writes out a recording if there is one
+func saveRecording(recorder *record.Recorder, path string) error {
+	if recorder == nil {
+		return nil
+	}
+
+	if err := recorder.Save(path); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(os.Stderr, "Recorded %d frames to %s\n", recorder.Frames(), path)
+	return nil
+}
+
+func recordRenderOptions(opts runOptions) render.Options {
+	return render.Options{
+		Scale:     int(math.Ceil(opts.scale)),
+		Palette:   opts.palette,
+		Style:     opts.style,
+		Scanlines: opts.scanlines,
+	}
+}
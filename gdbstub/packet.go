@@ -0,0 +1,103 @@
+package gdbstub
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// The byte a client sends outside of a packet to interrupt a running target
+const interrupt = 0x03
+
+// readPacket reads the next packet or interrupt from the client, acknowledging it unless acks are off. Acks sent by the client are
+// skipped. Returns "\x03" for an interrupt.
+func readPacket(r *bufio.Reader, w io.Writer, ack bool) (string, error) {
+	for {
+		b, err := r.ReadByte()
+		if err != nil {
+			return "", err
+		}
+
+		switch b {
+		case interrupt:
+			return string(rune(interrupt)), nil
+		case '$':
+		default:
+			// Acks, naks and noise between packets
+			continue
+		}
+
+		data, err := r.ReadString('#')
+		if err != nil {
+			return "", err
+		}
+		data = data[:len(data)-1]
+
+		sum := make([]byte, 2)
+		if _, err := io.ReadFull(r, sum); err != nil {
+			return "", err
+		}
+		expected, err := strconv.ParseUint(string(sum), 16, 8)
+		if err != nil || uint8(expected) != checksum(data) {
+			if ack {
+				if _, err := w.Write([]byte{'-'}); err != nil {
+					return "", err
+				}
+			}
+			continue
+		}
+
+		if ack {
+			if _, err := w.Write([]byte{'+'}); err != nil {
+				return "", err
+			}
+		}
+
+		return unescape(data)
+	}
+}
+
+// writePacket sends a packet, escaping the characters the protocol reserves
+func writePacket(w io.Writer, data string) error {
+	escaped := []byte{}
+	for i := 0; i < len(data); i++ {
+		switch c := data[i]; c {
+		case '$', '#', '}', '*':
+			escaped = append(escaped, '}', c^0x20)
+		default:
+			escaped = append(escaped, c)
+		}
+	}
+
+	_, err := fmt.Fprintf(w, "$%s#%02x", escaped, checksum(string(escaped)))
+	return err
+}
+
+func checksum(data string) uint8 {
+	sum := uint8(0)
+	for i := 0; i < len(data); i++ {
+		sum += data[i]
+	}
+
+	return sum
+}
+
+func unescape(data string) (string, error) {
+	result := []byte{}
+	for i := 0; i < len(data); i++ {
+		if data[i] != '}' {
+			result = append(result, data[i])
+			continue
+		}
+
+		i++
+		if i >= len(data) {
+			return "", errors.New("Packet ends with an escape character")
+		}
+		result = append(result, data[i]^0x20)
+	}
+
+	return string(result), nil
+}
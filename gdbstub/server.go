@@ -0,0 +1,552 @@
+// Package gdbstub lets GDB, or any other debugger that speaks the GDB remote serial protocol, control a running Chip8 CPU over TCP.
+//
+// The frontend keeps running its own frame loop but calls Server.Tick instead of Chip8.Tick, so the debugger can stop and start the CPU
+// while the display keeps updating. Packets are handled on another goroutine, so anything else the frontend does with the CPU must go
+// through Server.Do. Registers are described to the debugger by target.xml: V0-VF, I, PC, SP (stack depth), DT and ST.
+//
+// GDB walks the stack itself and knows nothing of CHIP-8 calls, so "monitor bt" prints the CALL stack instead, named from the symbol
+// table if one is set.
+package gdbstub
+
+import (
+	"bufio"
+	"chip8/chip8"
+	"chip8/symbols"
+	_ "embed"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+//go:embed target.xml
+var targetXML string
+
+// Signals used as halt reasons
+const (
+	sigint  = 2
+	sigill  = 4
+	sigtrap = 5
+	sigsegv = 11
+)
+
+// Size of the register block in g/G packets: V0-VF, I, PC, SP, DT, ST
+const registersSize = 16 + 2 + 2 + 1 + 1 + 1
+
+// Server controls a CPU on behalf of a debugger
+type Server struct {
+	computer *chip8.Chip8
+	symbols  *symbols.Table
+
+	mu          sync.Mutex
+	running     bool
+	breakpoints map[uint16]bool
+	// Set when resuming so a breakpoint on the instruction the CPU is stopped at doesn't stop it again straight away
+	skipBreakpoint bool
+	// Why the CPU last stopped
+	signal int
+	// Why the CPU stopped when it was running, for the connection to report
+	stops chan int
+}
+
+// New creates a server for a CPU. The CPU starts stopped so nothing runs until a debugger connects and continues.
+func New(computer *chip8.Chip8) *Server {
+	return &Server{
+		computer:    computer,
+		breakpoints: map[uint16]bool{},
+		signal:      sigtrap,
+		stops:       make(chan int, 1),
+	}
+}
+
+// SetSymbols sets the table used to name addresses in monitor commands
+func (s *Server) SetSymbols(table *symbols.Table) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.symbols = table
+}
+
+// Tick executes one instruction unless the debugger has the CPU stopped, in which case it does nothing. If the instruction is at a
+// breakpoint or fails, the CPU stops and the debugger is told why. Errors from the CPU are still returned.
+func (s *Server) Tick() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.tick()
+}
+
+// Do runs f while the debugger can't touch the CPU, so the frontend can read the screen or poke memory between packets. Inside f, use
+// the tick it is given rather than Server.Tick, which would wait forever for the lock f is holding.
+func (s *Server) Do(f func(computer *chip8.Chip8, tick func() error)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	f(s.computer, s.tick)
+}
+
+// tick is Tick with the lock already held
+func (s *Server) tick() error {
+	if !s.running {
+		return nil
+	}
+
+	if s.breakpoints[s.computer.Registers().PC] && !s.skipBreakpoint {
+		s.stop(sigtrap)
+		return nil
+	}
+	s.skipBreakpoint = false
+
+	err := s.computer.Tick()
+	if err != nil {
+		s.stop(signalFor(err))
+	}
+
+	return err
+}
+
+// Running reports whether the debugger has let the CPU run
+func (s *Server) Running() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.running
+}
+
+// stop halts the CPU and tells the connection. Must be called with the lock held.
+func (s *Server) stop(signal int) {
+	s.running = false
+	s.signal = signal
+
+	select {
+	case s.stops <- signal:
+	default:
+	}
+}
+
+func signalFor(err error) int {
+	switch err {
+	case chip8.ErrMemoryOutOfBounds, chip8.ErrProgramCounterOutOfBounds, chip8.ErrStackOverflow, chip8.ErrStackUnderflow:
+		return sigsegv
+	}
+
+	return sigill
+}
+
+// ListenAndServe listens on a TCP address, e.g. "localhost:2159", and serves debuggers one at a time
+func (s *Server) ListenAndServe(addr string) error {
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+
+	return s.Serve(l)
+}
+
+// Serve accepts debugger connections one at a time until the listener is closed
+func (s *Server) Serve(l net.Listener) error {
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			return err
+		}
+
+		s.ServeConn(conn)
+		conn.Close()
+	}
+}
+
+// session is the state of a single debugger connection
+type session struct {
+	server  *Server
+	conn    io.ReadWriter
+	writeMu sync.Mutex
+	noAck   int32
+	// Set by k, which leaves the CPU stopped rather than running it again like D
+	killed bool
+}
+
+func (ss *session) Write(data []byte) (int, error) {
+	ss.writeMu.Lock()
+	defer ss.writeMu.Unlock()
+
+	return ss.conn.Write(data)
+}
+
+func (ss *session) send(data string) error {
+	ss.writeMu.Lock()
+	defer ss.writeMu.Unlock()
+
+	return writePacket(ss.conn, data)
+}
+
+// ServeConn talks to a single debugger until it detaches or the connection closes. The CPU is stopped when the debugger connects and
+// left running when it goes away, unless it went away by killing the program.
+func (s *Server) ServeConn(conn io.ReadWriter) error {
+	s.mu.Lock()
+	s.running = false
+	s.mu.Unlock()
+	select {
+	case <-s.stops:
+	default:
+	}
+
+	ss := &session{server: s, conn: conn}
+	defer func() {
+		s.mu.Lock()
+		if !ss.killed {
+			s.running = true
+			s.skipBreakpoint = true
+		}
+		s.mu.Unlock()
+	}()
+
+	packets := make(chan string)
+	readErr := make(chan error, 1)
+	done := make(chan struct{})
+	defer close(done)
+
+	go func() {
+		reader := bufio.NewReader(conn)
+		for {
+			packet, err := readPacket(reader, ss, atomic.LoadInt32(&ss.noAck) == 0)
+			if err != nil {
+				readErr <- err
+				return
+			}
+
+			select {
+			case packets <- packet:
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	for {
+		select {
+		case err := <-readErr:
+			if err == io.EOF {
+				return nil
+			}
+			return err
+
+		case signal := <-s.stops:
+			if err := ss.send(stopReply(signal)); err != nil {
+				return err
+			}
+
+		case packet := <-packets:
+			reply, detach := ss.handle(packet)
+			if reply != nil {
+				if err := ss.send(*reply); err != nil {
+					return err
+				}
+			}
+			if detach {
+				return nil
+			}
+		}
+	}
+}
+
+func stopReply(signal int) string {
+	if signal == sigtrap {
+		// Only breakpoints stop a running CPU with SIGTRAP
+		return fmt.Sprintf("T%02xswbreak:;", signal)
+	}
+
+	return fmt.Sprintf("S%02x", signal)
+}
+
+func reply(data string) *string {
+	return &data
+}
+
+// handle runs a packet and returns the reply, or nil if the reply is sent later (when a continued CPU stops). Also returns whether the
+// debugger is detaching.
+func (ss *session) handle(packet string) (*string, bool) {
+	s := ss.server
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if packet == string(rune(interrupt)) {
+		if s.running {
+			s.stop(sigint)
+		}
+		return nil, false
+	}
+
+	if packet == "" {
+		return reply(""), false
+	}
+
+	args := packet[1:]
+	switch packet[0] {
+	case '?':
+		return reply(fmt.Sprintf("S%02x", s.signal)), false
+
+	case 'g':
+		return reply(hex.EncodeToString(encodeRegisters(s.computer.Registers()))), false
+
+	case 'G':
+		data, err := hex.DecodeString(args)
+		if err != nil || len(data) != registersSize {
+			return reply("E01"), false
+		}
+		if err := s.computer.SetRegisters(decodeRegisters(data)); err != nil {
+			return reply("E02"), false
+		}
+		return reply("OK"), false
+
+	case 'p':
+		n, err := strconv.ParseUint(args, 16, 8)
+		data := encodeRegisters(s.computer.Registers())
+		start, end, ok := registerBytes(int(n))
+		if err != nil || !ok {
+			return reply("E01"), false
+		}
+		return reply(hex.EncodeToString(data[start:end])), false
+
+	case 'P':
+		parts := strings.SplitN(args, "=", 2)
+		if len(parts) != 2 {
+			return reply("E01"), false
+		}
+		n, err := strconv.ParseUint(parts[0], 16, 8)
+		value, hexErr := hex.DecodeString(parts[1])
+		start, end, ok := registerBytes(int(n))
+		if err != nil || hexErr != nil || !ok || len(value) != end-start {
+			return reply("E01"), false
+		}
+		data := encodeRegisters(s.computer.Registers())
+		copy(data[start:end], value)
+		if err := s.computer.SetRegisters(decodeRegisters(data)); err != nil {
+			return reply("E02"), false
+		}
+		return reply("OK"), false
+
+	case 'm':
+		address, length, err := parseAddressLength(args)
+		if err != nil {
+			return reply("E01"), false
+		}
+		data, err := s.computer.ReadMemory(address, length)
+		if err != nil {
+			return reply("E02"), false
+		}
+		return reply(hex.EncodeToString(data)), false
+
+	case 'M':
+		parts := strings.SplitN(args, ":", 2)
+		if len(parts) != 2 {
+			return reply("E01"), false
+		}
+		address, length, err := parseAddressLength(parts[0])
+		data, hexErr := hex.DecodeString(parts[1])
+		if err != nil || hexErr != nil || len(data) != length {
+			return reply("E01"), false
+		}
+		if err := s.computer.WriteMemory(address, data); err != nil {
+			return reply("E02"), false
+		}
+		return reply("OK"), false
+
+	case 'c':
+		if !ss.resumeAt(args) {
+			return reply("E01"), false
+		}
+		s.running = true
+		s.skipBreakpoint = true
+		return nil, false
+
+	case 's':
+		if !ss.resumeAt(args) {
+			return reply("E01"), false
+		}
+		if err := s.computer.Tick(); err != nil {
+			s.signal = signalFor(err)
+		} else {
+			s.signal = sigtrap
+		}
+		return reply(fmt.Sprintf("S%02x", s.signal)), false
+
+	case 'Z', 'z':
+		parts := strings.Split(args, ",")
+		if len(parts) != 3 || parts[0] != "0" {
+			// Only software breakpoints are supported
+			return reply(""), false
+		}
+		address, err := strconv.ParseUint(parts[1], 16, 16)
+		if err != nil {
+			return reply("E01"), false
+		}
+		if packet[0] == 'Z' {
+			s.breakpoints[uint16(address)] = true
+		} else {
+			delete(s.breakpoints, uint16(address))
+		}
+		return reply("OK"), false
+
+	case 'D':
+		return reply("OK"), true
+
+	case 'k':
+		s.running = false
+		ss.killed = true
+		return nil, true
+
+	case 'H':
+		return reply("OK"), false
+
+	case 'q', 'Q':
+		return ss.query(packet), false
+	}
+
+	return reply(""), false
+}
+
+// resumeAt handles the optional address on c and s packets. Must be called with the lock held.
+func (ss *session) resumeAt(args string) bool {
+	if args == "" {
+		return true
+	}
+
+	address, err := strconv.ParseUint(args, 16, 16)
+	if err != nil {
+		return false
+	}
+
+	registers := ss.server.computer.Registers()
+	registers.PC = uint16(address)
+	return ss.server.computer.SetRegisters(registers) == nil
+}
+
+func (ss *session) query(packet string) *string {
+	switch {
+	case strings.HasPrefix(packet, "qSupported"):
+		return reply("PacketSize=1000;qXfer:features:read+;swbreak+;QStartNoAckMode+")
+	case packet == "QStartNoAckMode":
+		atomic.StoreInt32(&ss.noAck, 1)
+		return reply("OK")
+	case packet == "qAttached":
+		return reply("1")
+	case packet == "qfThreadInfo":
+		return reply("m1")
+	case packet == "qsThreadInfo":
+		return reply("l")
+	case packet == "qC":
+		return reply("QC1")
+	case strings.HasPrefix(packet, "qRcmd,"):
+		command, err := hex.DecodeString(strings.TrimPrefix(packet, "qRcmd,"))
+		if err != nil {
+			return reply("E01")
+		}
+		return ss.monitor(strings.TrimSpace(string(command)))
+	case strings.HasPrefix(packet, "qXfer:features:read:target.xml:"):
+		offset, length, err := parseAddressLength(strings.TrimPrefix(packet, "qXfer:features:read:target.xml:"))
+		if err != nil {
+			return reply("E01")
+		}
+		return reply(xferChunk(targetXML, int(offset), length))
+	}
+
+	return reply("")
+}
+
+// monitor runs a "monitor" command from the debugger, replying with its output. Must be called with the lock held.
+func (ss *session) monitor(command string) *string {
+	output := ""
+	switch command {
+	case "bt", "backtrace":
+		computer := ss.server.computer
+		for i, frame := range ss.server.symbols.CallStack(computer.Registers().PC, computer.Stack()) {
+			output += fmt.Sprintf("#%d  %s\n", i, frame)
+		}
+	case "help":
+		output = "bt  show the CHIP-8 call stack\n"
+	default:
+		output = "Unknown monitor command " + command + ", try monitor help\n"
+	}
+
+	return reply(hex.EncodeToString([]byte(output)))
+}
+
+// xferChunk returns part of a qXfer document, prefixed with l if it is the last part
+func xferChunk(document string, offset int, length int) string {
+	if offset >= len(document) {
+		return "l"
+	}
+
+	end := offset + length
+	if end >= len(document) {
+		return "l" + document[offset:]
+	}
+
+	return "m" + document[offset:end]
+}
+
+func parseAddressLength(args string) (uint16, int, error) {
+	parts := strings.SplitN(args, ",", 2)
+	if len(parts) != 2 {
+		return 0, 0, errors.New("Expected address,length")
+	}
+
+	address, err := strconv.ParseUint(parts[0], 16, 16)
+	if err != nil {
+		return 0, 0, err
+	}
+	length, err := strconv.ParseUint(parts[1], 16, 16)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return uint16(address), int(length), nil
+}
+
+// registerBytes is where register n is in the g packet register block
+func registerBytes(n int) (int, int, bool) {
+	switch {
+	case n < 16:
+		return n, n + 1, true
+	case n == 16:
+		return 16, 18, true // I
+	case n == 17:
+		return 18, 20, true // PC
+	case n <= 20:
+		return n + 2, n + 3, true // SP, DT, ST
+	}
+
+	return 0, 0, false
+}
+
+func encodeRegisters(registers chip8.Registers) []uint8 {
+	data := make([]uint8, registersSize)
+	copy(data, registers.V[:])
+	binary.LittleEndian.PutUint16(data[16:], registers.I)
+	binary.LittleEndian.PutUint16(data[18:], registers.PC)
+	data[20] = registers.SP
+	data[21] = registers.DT
+	data[22] = registers.ST
+
+	return data
+}
+
+func decodeRegisters(data []uint8) chip8.Registers {
+	registers := chip8.Registers{
+		I:  binary.LittleEndian.Uint16(data[16:]),
+		PC: binary.LittleEndian.Uint16(data[18:]),
+		SP: data[20],
+		DT: data[21],
+		ST: data[22],
+	}
+	copy(registers.V[:], data)
+
+	return registers
+}
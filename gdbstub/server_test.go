@@ -0,0 +1,349 @@
+package gdbstub
+
+import (
+	"bufio"
+	"chip8/chip8"
+	"chip8/symbols"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+type display struct{}
+
+func (d *display) Update(*[64][32]uint8, *[64][32]bool) {}
+func (d *display) Closed() bool                         { return false }
+func (d *display) KeyDown(key uint8) bool               { return false }
+
+// client is a scripted debugger
+type client struct {
+	t      *testing.T
+	conn   net.Conn
+	reader *bufio.Reader
+	server *Server
+}
+
+// startServer runs a server for a program over loopback with a frontend ticking the CPU in the background
+func startServer(t *testing.T, program []uint8) (*client, *chip8.Chip8) {
+	computer := chip8.New(&display{})
+	if _, err := computer.LoadFromMemory(program); err != nil {
+		t.Fatal(err)
+	}
+	server := New(computer)
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go server.Serve(l)
+
+	stop := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+				server.Tick()
+				time.Sleep(time.Microsecond)
+			}
+		}
+	}()
+
+	conn, err := net.Dial("tcp", l.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+
+	t.Cleanup(func() {
+		close(stop)
+		conn.Close()
+		l.Close()
+	})
+
+	return &client{t: t, conn: conn, reader: bufio.NewReader(conn), server: server}, computer
+}
+
+// send sends a packet and checks it is acknowledged
+func (c *client) send(data string) {
+	c.t.Helper()
+
+	if _, err := fmt.Fprintf(c.conn, "$%s#%02x", data, checksum(data)); err != nil {
+		c.t.Fatal(err)
+	}
+	if ack, err := c.reader.ReadByte(); err != nil || ack != '+' {
+		c.t.Fatalf("Expected packet %s to be acknowledged, got %q %v", data, ack, err)
+	}
+}
+
+// receive reads the next packet and acknowledges it
+func (c *client) receive() string {
+	c.t.Helper()
+
+	packet, err := readPacket(c.reader, c.conn, true)
+	if err != nil {
+		c.t.Fatal(err)
+	}
+
+	return packet
+}
+
+// expect sends a packet and checks the reply
+func (c *client) expect(data string, expected string) {
+	c.t.Helper()
+
+	c.send(data)
+	if got := c.receive(); got != expected {
+		c.t.Errorf("Reply to %s was not correct. Expected %q, got %q", data, expected, got)
+	}
+}
+
+// LD V0, 1; ADD V0, 1; JP 0x202
+var counter = []uint8{0x60, 0x01, 0x70, 0x01, 0x12, 0x02}
+
+func TestStartsStopped(t *testing.T) {
+	c, computer := startServer(t, counter)
+
+	c.expect("?", "S05")
+	if computer.Registers().PC != 0x200 {
+		t.Errorf("PC was not set correctly. Expected %d, got %d", 0x200, computer.Registers().PC)
+	}
+}
+
+func TestRegisters(t *testing.T) {
+	c, _ := startServer(t, counter)
+
+	c.expect("s", "S05")
+	c.expect("g", "01000000000000000000000000000000"+"0000"+"0202"+"000000")
+	c.expect("p10", "0000")
+	c.expect("p11", "0202")
+
+	c.expect("P3=2a", "OK")
+	c.expect("P10=0003", "OK")
+	c.expect("p3", "2a")
+	c.expect("p10", "0003")
+	c.expect("p15", "E01")
+
+	c.expect("G"+strings.Repeat("00", registersSize-3)+"ff0000", "E02")
+}
+
+func TestStackPointerAndTimerRegisters(t *testing.T) {
+	c, computer := startServer(t, counter)
+
+	c.expect("s", "S05")
+	c.expect("p12", "00")
+	c.expect("p13", "00")
+	c.expect("p14", "00")
+
+	c.expect("P12=01", "OK")
+	c.expect("P13=07", "OK")
+	c.expect("P14=09", "OK")
+	c.expect("p12", "01")
+	c.expect("p13", "07")
+	c.expect("p14", "09")
+
+	registers := computer.Registers()
+	if registers.SP != 1 || registers.DT != 7 || registers.ST != 9 {
+		t.Errorf("Registers were not set correctly. Expected SP 1, DT 7, ST 9, got %d, %d, %d", registers.SP, registers.DT, registers.ST)
+	}
+}
+
+func TestMemory(t *testing.T) {
+	c, computer := startServer(t, counter)
+
+	c.expect("m200,6", "600170011202")
+	c.expect("M300,2:abcd", "OK")
+	c.expect("m300,2", "abcd")
+	c.expect("mfff,2", "E02")
+	c.expect("M300,2:ab", "E01")
+
+	data, _ := computer.ReadMemory(0x300, 2)
+	if data[0] != 0xAB || data[1] != 0xCD {
+		t.Errorf("Memory was not written correctly. Got % X", data)
+	}
+}
+
+func TestBreakpointAndContinue(t *testing.T) {
+	c, computer := startServer(t, counter)
+
+	c.expect("Z0,204,2", "OK")
+	c.send("c")
+	if got := c.receive(); got != "T05swbreak:;" {
+		t.Fatalf("Expected to stop at the breakpoint, got %q", got)
+	}
+	if computer.Registers().PC != 0x204 {
+		t.Errorf("PC was not set correctly. Expected %d, got %d", 0x204, computer.Registers().PC)
+	}
+
+	// Continuing moves off the breakpoint and round the loop back to it
+	c.send("c")
+	if got := c.receive(); got != "T05swbreak:;" {
+		t.Fatalf("Expected to stop at the breakpoint again, got %q", got)
+	}
+	c.expect("p0", "03")
+
+	c.expect("z0,204,2", "OK")
+	c.expect("Z1,204,2", "")
+}
+
+func TestMonitorBacktrace(t *testing.T) {
+	// CALL 0x204; LD V0, 1; JP 0x204
+	c, _ := startServer(t, []uint8{0x22, 0x04, 0x60, 0x01, 0x12, 0x04})
+	table := symbols.New()
+	table.AddLabel(0x200, "main")
+	table.AddLabel(0x204, "loop")
+	c.server.SetSymbols(table)
+
+	c.expect("s", "S05")
+	c.expect("qRcmd,"+hex.EncodeToString([]byte("bt")), hex.EncodeToString([]byte("#0  loop\n#1  main\n")))
+	c.expect("qRcmd,"+hex.EncodeToString([]byte("frobnicate")), hex.EncodeToString([]byte("Unknown monitor command frobnicate, try monitor help\n")))
+}
+
+func TestInterrupt(t *testing.T) {
+	c, _ := startServer(t, counter)
+
+	c.send("c")
+	time.Sleep(10 * time.Millisecond)
+	c.conn.Write([]byte{interrupt})
+	if got := c.receive(); got != "S02" {
+		t.Errorf("Expected to stop with SIGINT, got %q", got)
+	}
+	c.expect("?", "S02")
+}
+
+func TestErrorsAreReportedAsSignals(t *testing.T) {
+	// RET with nothing on the stack
+	c, _ := startServer(t, []uint8{0x00, 0xEE})
+
+	c.expect("s", "S0b")
+	c.send("c")
+	if got := c.receive(); got != "S0b" {
+		t.Errorf("Expected to stop with SIGSEGV, got %q", got)
+	}
+}
+
+func TestTargetDescription(t *testing.T) {
+	c, _ := startServer(t, counter)
+
+	c.send("qSupported:multiprocess+")
+	if got := c.receive(); !strings.Contains(got, "qXfer:features:read+") {
+		t.Errorf("Target description support was not advertised, got %q", got)
+	}
+
+	document := ""
+	for {
+		c.send(fmt.Sprintf("qXfer:features:read:target.xml:%x,%x", len(document), 100))
+		chunk := c.receive()
+		document += chunk[1:]
+		if chunk[0] == 'l' {
+			break
+		}
+	}
+
+	if document != targetXML {
+		t.Errorf("Target description was not sent correctly. Got %q", document)
+	}
+	if strings.Count(targetXML, "<reg ") != 21 {
+		t.Errorf("Expected 21 registers in the target description")
+	}
+}
+
+func TestNoAckModeAndDetach(t *testing.T) {
+	c, _ := startServer(t, counter)
+
+	c.expect("QStartNoAckMode", "OK")
+	fmt.Fprintf(c.conn, "$%s#%02x", "D", checksum("D"))
+	packet, err := readPacket(c.reader, c.conn, false)
+	if err != nil || packet != "OK" {
+		t.Errorf("Expected detach to be acknowledged without an ack, got %q %v", packet, err)
+	}
+}
+
+func TestKillLeavesCPUStopped(t *testing.T) {
+	c, _ := startServer(t, counter)
+
+	c.send("c")
+	c.send("k")
+	if _, err := c.reader.ReadByte(); err == nil {
+		t.Fatalf("Expected the connection to be closed after kill")
+	}
+
+	pc := func() uint16 {
+		pc := uint16(0)
+		c.server.Do(func(computer *chip8.Chip8, _ func() error) { pc = computer.Registers().PC })
+		return pc
+	}
+	before := pc()
+	time.Sleep(10 * time.Millisecond)
+	if c.server.Running() {
+		t.Errorf("CPU was left running after kill")
+	}
+	if after := pc(); after != before {
+		t.Errorf("CPU kept running after kill. PC went from 0x%03X to 0x%03X", before, after)
+	}
+}
+
+func TestDetachLeavesCPURunning(t *testing.T) {
+	c, _ := startServer(t, counter)
+
+	c.expect("D", "OK")
+	if _, err := c.reader.ReadByte(); err == nil {
+		t.Fatalf("Expected the connection to be closed after detach")
+	}
+	if !c.server.Running() {
+		t.Errorf("CPU was not left running after detach")
+	}
+}
+
+// Run with -race: the frontend's frame loop and the debugger's packets both use the CPU
+func TestFrontendGoesThroughDo(t *testing.T) {
+	c, _ := startServer(t, counter)
+
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	screen := [64][32]uint8{}
+	go func() {
+		defer close(done)
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+			}
+			c.server.Do(func(computer *chip8.Chip8, tick func() error) {
+				tick()
+				computer.ReadMemory(0x300, 1)
+				computer.Registers()
+				computer.GetDirtyFlags()
+				screen = *computer.GetScreen()
+			})
+			time.Sleep(time.Microsecond)
+		}
+	}()
+
+	for i := 0; i < 20; i++ {
+		c.expect("s", "S05")
+		c.expect("M300,1:ff", "OK")
+		c.expect("m300,1", "ff")
+	}
+
+	close(stop)
+	<-done
+	if screen != ([64][32]uint8{}) {
+		t.Errorf("Screen was not copied correctly")
+	}
+}
+
+func TestPacketEscaping(t *testing.T) {
+	buf := strings.Builder{}
+	writePacket(&buf, "a$b#c}d*")
+
+	packet, err := readPacket(bufio.NewReader(strings.NewReader(buf.String())), &strings.Builder{}, true)
+	if err != nil || packet != "a$b#c}d*" {
+		t.Errorf("Packet was not escaped correctly. Got %q %v", packet, err)
+	}
+}
@@ -1,8 +1,17 @@
 module chip8
 
-go 1.15
+go 1.18
 
 require (
 	github.com/faiface/pixel v0.10.0
 	golang.org/x/image v0.0.0-20190523035834-f03afa92d3ff
 )
+
+require (
+	github.com/faiface/glhf v0.0.0-20181018222622-82a6317ac380 // indirect
+	github.com/faiface/mainthread v0.0.0-20171120011319-8b78f0a41ae3 // indirect
+	github.com/go-gl/gl v0.0.0-20190320180904-bf2b1f2f34d7 // indirect
+	github.com/go-gl/glfw/v3.3/glfw v0.0.0-20191125211704-12ad95a8df72 // indirect
+	github.com/go-gl/mathgl v0.0.0-20190416160123-c4601bc793c7 // indirect
+	github.com/pkg/errors v0.8.1 // indirect
+)
@@ -0,0 +1,67 @@
+package headless
+
+// HeadlessDisplay implements the chip8 display interface without any window. It is used for benchmarking and any other tooling that
+// needs to run a ROM without a user in front of it.
+type HeadlessDisplay struct {
+	screen   [64][32]uint8
+	keysDown [16]bool
+	closed   bool
+	frames   int
+	script   Script
+}
+
+func New() *HeadlessDisplay {
+	return &HeadlessDisplay{}
+}
+
+func (hd *HeadlessDisplay) Update(pixels *[64][32]uint8, dirty *[64][32]bool) {
+	hd.screen = *pixels
+	hd.frames++
+	hd.applyScript()
+}
+
+// SetScript plays back key events as frames are drawn. Events for frame 0 happen straight away, and events for frame n once Update has
+// been called n times.
+func (hd *HeadlessDisplay) SetScript(script Script) {
+	hd.script = script
+	hd.applyScript()
+}
+
+func (hd *HeadlessDisplay) applyScript() {
+	for len(hd.script) > 0 && hd.script[0].Frame <= hd.frames {
+		hd.SetKey(hd.script[0].Key, hd.script[0].Down)
+		hd.script = hd.script[1:]
+	}
+}
+
+func (hd *HeadlessDisplay) Closed() bool {
+	return hd.closed
+}
+
+func (hd *HeadlessDisplay) KeyDown(key uint8) bool {
+	if int(key) >= len(hd.keysDown) {
+		return false
+	}
+
+	return hd.keysDown[key]
+}
+
+// SetKey presses or releases a key on the chip8 keypad
+func (hd *HeadlessDisplay) SetKey(key uint8, down bool) {
+	hd.keysDown[key&0xF] = down
+}
+
+// Close marks the display as closed so any run loop waiting on it will exit
+func (hd *HeadlessDisplay) Close() {
+	hd.closed = true
+}
+
+// Screen returns the last screen passed to Update
+func (hd *HeadlessDisplay) Screen() *[64][32]uint8 {
+	return &hd.screen
+}
+
+// Frames returns how many times Update has been called
+func (hd *HeadlessDisplay) Frames() int {
+	return hd.frames
+}
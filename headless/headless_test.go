@@ -0,0 +1,52 @@
+package headless
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseScript(t *testing.T) {
+	script, err := ParseScript(strings.NewReader("# comment\n\n10 up 5\n 2 down A\n"))
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+
+	expected := Script{{Frame: 2, Key: 0xA, Down: true}, {Frame: 10, Key: 0x5, Down: false}}
+	if len(script) != len(expected) || script[0] != expected[0] || script[1] != expected[1] {
+		t.Errorf("Script was not parsed correctly. Expected %v, got %v", expected, script)
+	}
+}
+
+func TestParseScriptErrors(t *testing.T) {
+	for _, text := range []string{"1 down", "x down 1", "1 press 1", "1 down G", "-1 down 1"} {
+		if _, err := ParseScript(strings.NewReader(text)); err == nil {
+			t.Errorf("Expected an error for %q", text)
+		}
+	}
+}
+
+func TestScriptPlaysBackByFrame(t *testing.T) {
+	display := New()
+	display.SetScript(Script{{Frame: 0, Key: 1, Down: true}, {Frame: 2, Key: 1, Down: false}})
+	screen := [64][32]uint8{}
+
+	if !display.KeyDown(1) {
+		t.Error("Frame 0 event was not applied straight away")
+	}
+	display.Update(&screen, &[64][32]bool{})
+	if !display.KeyDown(1) {
+		t.Error("Key was released too early")
+	}
+	display.Update(&screen, &[64][32]bool{})
+	if display.KeyDown(1) {
+		t.Error("Key was not released on frame 2")
+	}
+}
+
+func TestKeyDownOutOfRange(t *testing.T) {
+	display := New()
+
+	if display.KeyDown(0xFF) {
+		t.Error("Invalid key should not be down")
+	}
+}
@@ -0,0 +1,93 @@
+package headless
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// KeyEvent presses or releases a key at the start of a frame
+type KeyEvent struct {
+	Frame int
+	Key   uint8
+	Down  bool
+}
+
+// Script is a list of key events, ordered by frame, used to play a ROM without a user. Scripts are text with one event per line:
+//
+//	# frame  action  key
+//	60       down    5
+//	90       up      5
+//
+// Keys are chip8 keys as hex digits. Blank lines and lines starting with # are ignored.
+type Script []KeyEvent
+
+// ParseScript reads a script
+func ParseScript(r io.Reader) (Script, error) {
+	script := Script{}
+	scanner := bufio.NewScanner(r)
+	line := 0
+
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		event, err := parseEvent(strings.Fields(text))
+		if err != nil {
+			return nil, errors.New("line " + strconv.Itoa(line) + ": " + err.Error())
+		}
+		script = append(script, event)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(script, func(i, j int) bool { return script[i].Frame < script[j].Frame })
+	return script, nil
+}
+
+// LoadScript reads a script from a file
+func LoadScript(path string) (Script, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return ParseScript(f)
+}
+
+func parseEvent(fields []string) (KeyEvent, error) {
+	if len(fields) != 3 {
+		return KeyEvent{}, errors.New("expected <frame> <down|up> <key>")
+	}
+
+	frame, err := strconv.Atoi(fields[0])
+	if err != nil || frame < 0 {
+		return KeyEvent{}, errors.New("invalid frame " + fields[0])
+	}
+
+	var down bool
+	switch fields[1] {
+	case "down":
+		down = true
+	case "up":
+		down = false
+	default:
+		return KeyEvent{}, errors.New("invalid action " + fields[1])
+	}
+
+	key, err := strconv.ParseUint(fields[2], 16, 4)
+	if err != nil {
+		return KeyEvent{}, errors.New("invalid key " + fields[2])
+	}
+
+	return KeyEvent{Frame: frame, Key: uint8(key), Down: down}, nil
+}
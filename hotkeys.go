@@ -0,0 +1,30 @@
+package main
+
+import (
+	"chip8/palette"
+
+	"github.com/faiface/pixel/pixelgl"
+)
+
+// Frontend hotkeys are on the function keys so they don't clash with any of the keymap layouts
+const (
+	hotkeyPause     = pixelgl.KeyF1
+	hotkeyPalette   = pixelgl.KeyF2
+	hotkeyStyle     = pixelgl.KeyF3
+	hotkeyCallStack = pixelgl.KeyF4
+	hotkeyExit      = pixelgl.KeyEscape // Only when started from the launcher; none of the keymap layouts use it
+)
+
+// nextPalette cycles through the named palettes in order. A custom palette given as colours isn't in the list so moves to the first.
+func nextPalette(current palette.Palette) palette.Palette {
+	names := palette.Names()
+	for i, name := range names {
+		if name == current.Name {
+			next, _ := palette.Lookup(names[(i+1)%len(names)])
+			return next
+		}
+	}
+
+	first, _ := palette.Lookup(names[0])
+	return first
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"chip8/chip8"
+	"chip8/romdb"
+	"fmt"
+	"os"
+)
+
+func infoCommand(args []string) int {
+	fs := newFlagSet("info")
+	database := fs.String("romdb", romdb.DefaultOverridePath(), romdbUsage())
+	source := addROMFlags(fs)
+	rom, err := parseROMArgs(fs, args)
+	if err != nil {
+		return usageExitCode(err)
+	}
+
+	data, err := source.read(rom)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Could not load ROM:", err)
+		return exitROM
+	}
+
+	info, err := chip8.NewROMInfo(data)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Invalid ROM:", err)
+		return exitROM
+	}
+
+	entry, err := lookupROM(*database, info)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Could not load ROM database:", err)
+		return exitUsage
+	}
+	printROMEntry(os.Stdout, entry)
+
+	fmt.Printf("File:      %s\n", rom)
+	fmt.Printf("Size:      %d bytes\n", info.Size)
+	fmt.Printf("SHA-1:     %s\n", info.SHA1)
+	fmt.Printf("Platform:  %s\n", info.Platform)
+	quirks := info.Platform.QuirkPreset()
+	if entry.Quirks != "" {
+		quirks = entry.Quirks
+	}
+	fmt.Printf("Quirks:    %s\n", quirks)
+	if entry.CyclesPerFrame != 0 {
+		fmt.Printf("Speed:     %d cycles/frame\n", entry.CyclesPerFrame)
+	}
+	fmt.Printf("Entry:     0x%03X\n", info.EntryPoint)
+
+	return exitOK
+}
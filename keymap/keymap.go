@@ -0,0 +1,199 @@
+package keymap
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Keymap maps each chip8 key to the names of the host keys that press it. Names are the ones used by pixel (e.g. "Q", "Up", "Space",
+// "Semicolon") and are matched case insensitively by frontends.
+type Keymap map[uint8][]string
+
+// Layouts are the built-in keymaps. Key names are positions on a US keyboard whatever layout the system uses, so qwerty puts the
+// keypad in the same physical place on AZERTY, Dvorak and other keyboards too:
+// 1 2 3 C
+// 4 5 6 D
+// 7 8 9 E
+// A 0 B F
+var Layouts = map[string]Keymap{
+	"qwerty": fromRows("1234", "QWER", "ASDF", "ZXCV"),
+}
+
+// The chip8 keys in keypad order, used to build layouts row by row
+var keypad = [4][4]uint8{
+	{0x1, 0x2, 0x3, 0xC},
+	{0x4, 0x5, 0x6, 0xD},
+	{0x7, 0x8, 0x9, 0xE},
+	{0xA, 0x0, 0xB, 0xF},
+}
+
+func fromRows(rows ...string) Keymap {
+	keys := Keymap{}
+	for i, row := range rows {
+		for j, name := range row {
+			keys[keypad[i][j]] = []string{string(name)}
+		}
+	}
+
+	return keys
+}
+
+// Default is the keymap used when nothing else is configured
+func Default() Keymap {
+	return Layouts["qwerty"].Copy()
+}
+
+// Layout gets a copy of a built-in layout by name.
+// Will return an error if there is no layout with that name.
+func Layout(name string) (Keymap, error) {
+	layout, ok := Layouts[strings.ToLower(name)]
+	if !ok {
+		return nil, errors.New("Unknown keyboard layout " + name)
+	}
+
+	return layout.Copy(), nil
+}
+
+// LayoutNames returns the names of the built-in layouts in a stable order
+func LayoutNames() []string {
+	names := []string{}
+	for name := range Layouts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// Copy returns a keymap that can be changed without affecting the original
+func (k Keymap) Copy() Keymap {
+	result := Keymap{}
+	for key, names := range k {
+		result[key] = append([]string{}, names...)
+	}
+
+	return result
+}
+
+// keyList accepts either a single key name or a list of them in JSON
+type keyList []string
+
+func (kl *keyList) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*kl = keyList{single}
+		return nil
+	}
+
+	var list []string
+	if err := json.Unmarshal(data, &list); err != nil {
+		return errors.New("Keys must be a key name or a list of key names")
+	}
+	*kl = keyList(list)
+
+	return nil
+}
+
+// Bindings is a set of changes on top of a layout. Keys are chip8 keys as hex digits.
+type Bindings struct {
+	Layout string             `json:"layout,omitempty"`
+	Keys   map[string]keyList `json:"keys,omitempty"`
+}
+
+// apply changes a keymap in place. Each chip8 key listed replaces all of its existing bindings.
+func (b Bindings) apply(keys Keymap) (Keymap, error) {
+	if b.Layout != "" {
+		layout, err := Layout(b.Layout)
+		if err != nil {
+			return nil, err
+		}
+		keys = layout
+	}
+
+	for k, names := range b.Keys {
+		key, err := strconv.ParseUint(k, 16, 4)
+		if err != nil {
+			return nil, errors.New("Invalid chip8 key " + k)
+		}
+		keys[uint8(key)] = append([]string{}, names...)
+	}
+
+	return keys, nil
+}
+
+// Config is a keymap file. For example:
+//
+//	{
+//		"layout": "azerty",
+//		"keys": {"5": ["Z", "Up"], "8": ["S", "Down"]},
+//		"roms": {
+//			"b232ef880bd6060fb45fa6effed7edf0ae95670e": {"keys": {"1": "Up", "4": "Down"}}
+//		}
+//	}
+//
+// The top level bindings apply to every ROM; entries in "roms" are keyed by the SHA-1 of a ROM and are applied on top of them.
+type Config struct {
+	Bindings
+	ROMs map[string]Bindings `json:"roms,omitempty"`
+}
+
+// Parse reads a keymap config from JSON and checks every binding in it is valid
+func Parse(data []byte) (*Config, error) {
+	config := Config{}
+	if err := json.Unmarshal(data, &config); err != nil {
+		return nil, err
+	}
+
+	if _, err := config.Bindings.apply(Default()); err != nil {
+		return nil, err
+	}
+	for hash, bindings := range config.ROMs {
+		if _, err := bindings.apply(Default()); err != nil {
+			return nil, errors.New("ROM " + hash + ": " + err.Error())
+		}
+	}
+
+	return &config, nil
+}
+
+// Load reads a keymap config from a file
+func Load(path string) (*Config, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return Parse(data)
+}
+
+// Keymap resolves the keymap to use for a ROM, identified by its hex encoded SHA-1
+func (c *Config) Keymap(hash string) (Keymap, error) {
+	keys, err := c.Bindings.apply(Default())
+	if err != nil {
+		return nil, err
+	}
+
+	for h, bindings := range c.ROMs {
+		if strings.EqualFold(h, hash) {
+			return bindings.apply(keys)
+		}
+	}
+
+	return keys, nil
+}
+
+// DefaultPath is where the keymap file lives if one isn't given explicitly
+func DefaultPath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+
+	return filepath.Join(dir, "chip8", "keymap.json")
+}
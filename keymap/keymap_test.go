@@ -0,0 +1,90 @@
+package keymap
+
+import (
+	"testing"
+)
+
+func TestLayoutsCoverEveryKey(t *testing.T) {
+	for name, layout := range Layouts {
+		for key := uint8(0); key < 16; key++ {
+			if len(layout[key]) == 0 {
+				t.Errorf("Layout %s has no binding for key %X", name, key)
+			}
+		}
+	}
+}
+
+func TestDefaultIsQwerty(t *testing.T) {
+	keys := Default()
+
+	if keys[0x4][0] != "Q" || keys[0x0][0] != "X" {
+		t.Errorf("Default layout was not QWERTY, got %v", keys)
+	}
+}
+
+func TestConfigMultipleKeysAndSingleKey(t *testing.T) {
+	config, err := Parse([]byte(`{"keys": {"5": ["W", "Up"], "8": "Down"}}`))
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+
+	keys, _ := config.Keymap("")
+	if len(keys[0x5]) != 2 || keys[0x5][1] != "Up" {
+		t.Errorf("Key 5 was not bound correctly, got %v", keys[0x5])
+	}
+	if len(keys[0x8]) != 1 || keys[0x8][0] != "Down" {
+		t.Errorf("Key 8 was not bound correctly, got %v", keys[0x8])
+	}
+	if keys[0x4][0] != "Q" {
+		t.Errorf("Unlisted keys should keep the layout, got %v", keys[0x4])
+	}
+}
+
+func TestConfigLayout(t *testing.T) {
+	config, _ := Parse([]byte(`{"layout": "QWERTY"}`))
+
+	keys, _ := config.Keymap("")
+	if keys[0x4][0] != "Q" {
+		t.Errorf("Layout was not applied, got %v", keys[0x4])
+	}
+}
+
+func TestConfigROMOverride(t *testing.T) {
+	config, _ := Parse([]byte(`{"layout": "qwerty", "keys": {"4": "A"}, "roms": {"ABCD": {"keys": {"1": "Up"}}}}`))
+
+	keys, _ := config.Keymap("abcd")
+	if keys[0x1][0] != "Up" {
+		t.Errorf("ROM override was not applied, got %v", keys[0x1])
+	}
+	if keys[0x4][0] != "A" {
+		t.Errorf("ROM override should build on the global layout, got %v", keys[0x4])
+	}
+
+	other, _ := config.Keymap("1234")
+	if other[0x1][0] != "1" {
+		t.Errorf("ROM override was applied to another ROM, got %v", other[0x1])
+	}
+}
+
+func TestConfigInvalid(t *testing.T) {
+	for _, data := range []string{
+		`{"keys": {"G": "Up"}}`,
+		`{"layout": "colemak"}`,
+		`{"layout": "azerty"}`,
+		`{"keys": {"1": 5}}`,
+		`{"roms": {"abcd": {"keys": {"10": "Up"}}}}`,
+	} {
+		if _, err := Parse([]byte(data)); err == nil {
+			t.Errorf("Expected an error for %s", data)
+		}
+	}
+}
+
+func TestCopyIsIndependent(t *testing.T) {
+	keys := Default()
+	keys[0x1][0] = "Space"
+
+	if Layouts["qwerty"][0x1][0] != "1" {
+		t.Error("Changing a copy changed the built-in layout")
+	}
+}
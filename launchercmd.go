@@ -0,0 +1,265 @@
+package main
+
+import (
+	"chip8/chip8"
+	"chip8/keymap"
+	"chip8/library"
+	"chip8/palette"
+	"chip8/pixeldisplay"
+	"chip8/render"
+	"chip8/romdb"
+	"embed"
+	"fmt"
+	"io/fs"
+	"os"
+	"time"
+
+	"github.com/faiface/pixel/pixelgl"
+)
+
+// The ROMs that come with chip8, listed by the launcher when it isn't given a directory
+//
+//go:embed roms
+var bundledROMs embed.FS
+
+const launcherFooter = "Up/Down: choose  Enter: play  Tab: quirks  Left/Right: speed"
+
+type launchOptions struct {
+	scale     float64
+	keymap    string
+	statePath string
+}
+
+func launchCommand(args []string) int {
+	flags := newFlagSet("launch")
+	scale := flags.Float64("scale", 8, "size of each chip8 pixel on screen")
+	database := flags.String("romdb", romdb.DefaultOverridePath(), romdbUsage())
+	keys := flags.String("keymap", "", "JSON keymap file (default "+keymap.DefaultPath()+" if it exists)")
+	frames := flags.Int("thumbnail-frames", 300, "60Hz frames each ROM runs for without a display to make its thumbnail")
+	statePath := flags.String("state", library.DefaultStatePath(), "file remembering recently played games and the settings for each ROM")
+	flags.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Usage: chip8 launch [flags] [directory of ROMs]")
+		fmt.Fprintln(os.Stderr, "Lists the ROMs that come with chip8 if no directory is given.")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return usageExitCode(err)
+	}
+	if flags.NArg() > 1 {
+		flags.Usage()
+		return exitUsage
+	}
+	if *statePath == "" {
+		fmt.Fprintln(os.Stderr, "-state must be given as there is no config directory")
+		return exitUsage
+	}
+
+	roms, err := launcherROMs(flags.Arg(0))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Could not open ROMs:", err)
+		return exitROM
+	}
+
+	db, err := romdb.Builtin()
+	if err == nil && *database != "" {
+		err = db.LoadOverrides(*database)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Could not load ROM database:", err)
+		return exitUsage
+	}
+
+	items, skipped, err := library.Scan(roms, db, *frames)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Could not load ROMs:", err)
+		return exitROM
+	}
+	for _, err := range skipped {
+		fmt.Fprintln(os.Stderr, "Skipped", err)
+	}
+
+	state, err := library.LoadState(*statePath)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Could not load launcher state:", err)
+		return exitUsage
+	}
+	state.Sort(items)
+
+	opts := launchOptions{scale: *scale, keymap: *keys, statePath: *statePath}
+	code := exitOK
+	pixelgl.Run(func() {
+		code = launch(items, state, opts)
+	})
+
+	return code
+}
+
+// launcherROMs is the directory to list, or the bundled ROMs if there isn't one
+func launcherROMs(dir string) (fs.FS, error) {
+	if dir == "" {
+		return fs.Sub(bundledROMs, "roms")
+	}
+
+	if _, err := os.Stat(dir); err != nil {
+		return nil, err
+	}
+
+	return os.DirFS(dir), nil
+}
+
+// launch shows the list of ROMs and plays the chosen one, coming back to the list when it's left, until the window is closed.
+// Must be called from within pixelgl.Run.
+func launch(items []library.Item, state *library.State, opts launchOptions) int {
+	display := pixeldisplay.New(opts.scale)
+	ticker := time.NewTicker(time.Second / 60)
+	defer ticker.Stop()
+
+	selected := 0
+	entries := launcherEntries(items, state)
+	for !display.Closed() {
+		changed := false
+		switch {
+		case display.JustPressed(pixelgl.KeyUp) && selected > 0:
+			selected--
+		case display.JustPressed(pixelgl.KeyDown) && selected < len(items)-1:
+			selected++
+		case len(items) == 0:
+		case display.JustPressed(pixelgl.KeyTab):
+			settings := romSettings(items[selected], state)
+			settings.Quirks = nextQuirks(settings.Quirks)
+			state.ROMs[items[selected].Info.SHA1] = settings
+			changed = true
+		case display.JustPressed(pixelgl.KeyLeft):
+			settings := romSettings(items[selected], state)
+			if settings.CyclesPerFrame > 1 {
+				settings.CyclesPerFrame--
+			}
+			state.ROMs[items[selected].Info.SHA1] = settings
+			changed = true
+		case display.JustPressed(pixelgl.KeyRight):
+			settings := romSettings(items[selected], state)
+			settings.CyclesPerFrame++
+			state.ROMs[items[selected].Info.SHA1] = settings
+			changed = true
+		case display.JustPressed(pixelgl.KeyEnter):
+			playFromLauncher(display, items[selected], state, opts)
+			state.Sort(items)
+			selected = 0
+			changed = true
+		}
+
+		if changed {
+			if err := state.Save(opts.statePath); err != nil {
+				fmt.Fprintln(os.Stderr, "Could not save launcher state:", err)
+			}
+			entries = launcherEntries(items, state)
+		}
+
+		display.DrawLauncher(entries, selected, launcherFooter)
+		<-ticker.C
+	}
+
+	return exitOK
+}
+
+// playFromLauncher runs a ROM in the launcher's window with its saved settings, then remembers it was played and any palette or
+// style changed with the hotkeys
+func playFromLauncher(display *pixeldisplay.PixelDisplay, item library.Item, state *library.State, opts launchOptions) {
+	settings := romSettings(item, state)
+
+	game := runOptions{
+		data:           item.Data,
+		scale:          opts.scale,
+		cyclesPerFrame: settings.CyclesPerFrame,
+		launched:       true,
+	}
+
+	var err error
+	if game.quirks, err = chip8.QuirkPreset(settings.Quirks); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return
+	}
+	if game.keys, err = loadKeymap(opts.keymap, item.Info); err != nil {
+		fmt.Fprintln(os.Stderr, "Could not load keymap:", err)
+		return
+	}
+	game.palette, game.style = settingsDisplay(settings)
+
+	printROMEntry(os.Stdout, item.Entry)
+	display.SetTitle(item.Title())
+	result := play(display, game)
+	display.SetTitle("Chip8.go")
+
+	if result.palette.Name != "" {
+		settings.Palette = result.palette.Name
+	}
+	settings.Style = result.style.String()
+	state.ROMs[item.Info.SHA1] = settings
+	state.Played(item.Info.SHA1)
+}
+
+// romSettings are the settings saved for a ROM, with anything not chosen yet filled in from the ROM database or the defaults
+func romSettings(item library.Item, state *library.State) library.Settings {
+	settings := state.ROMs[item.Info.SHA1]
+	if _, err := chip8.QuirkPreset(settings.Quirks); err != nil {
+		settings.Quirks = item.Quirks()
+	}
+	if settings.CyclesPerFrame < 1 {
+		settings.CyclesPerFrame = item.CyclesPerFrame()
+	}
+	if settings.Palette == "" {
+		settings.Palette = item.Entry.Palette
+	}
+
+	return settings
+}
+
+// settingsDisplay is the palette and style to play with. Ones that can't be used any more, such as a palette that was only in a
+// -palettes file, go back to the defaults.
+func settingsDisplay(s library.Settings) (palette.Palette, render.Style) {
+	p, err := palette.Parse(s.Palette)
+	if err != nil {
+		p = palette.Presets["classic"]
+	}
+	style, err := render.ParseStyle(s.Style)
+	if err != nil {
+		style = render.StyleSolid
+	}
+
+	return p, style
+}
+
+// nextQuirks cycles through the quirk presets in order
+func nextQuirks(current string) string {
+	names := chip8.QuirkPresetNames()
+	for i, name := range names {
+		if name == current {
+			return names[(i+1)%len(names)]
+		}
+	}
+
+	return names[0]
+}
+
+// launcherEntries describes the items for the launcher list, with thumbnails drawn in each ROM's palette. The recently played are
+// marked with a *.
+func launcherEntries(items []library.Item, state *library.State) []pixeldisplay.LauncherItem {
+	entries := []pixeldisplay.LauncherItem{}
+	for _, item := range items {
+		settings := romSettings(item, state)
+		p, _ := settingsDisplay(settings)
+
+		title := item.Title()
+		if state.IsRecent(item.Info.SHA1) {
+			title = "* " + title
+		}
+
+		entries = append(entries, pixeldisplay.LauncherItem{
+			Title:     title,
+			Details:   fmt.Sprintf("%d bytes, %s, %s quirks, %d cycles/frame", item.Info.Size, item.Info.Platform, settings.Quirks, settings.CyclesPerFrame),
+			Thumbnail: render.Image(&item.Thumbnail, render.Options{Scale: 1, Palette: p}),
+		})
+	}
+
+	return entries
+}
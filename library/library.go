@@ -0,0 +1,110 @@
+// Package library gathers a collection of ROMs for a launcher: what each one is, according to the ROM database and by looking at it,
+// and a thumbnail of how it looks after running for a while. It also remembers which games were played recently and the settings
+// chosen for each.
+package library
+
+import (
+	"chip8/chip8"
+	"chip8/headless"
+	"chip8/loader"
+	"chip8/romdb"
+	"errors"
+	"io/fs"
+	"path"
+	"strings"
+)
+
+// Item is a ROM in the library
+type Item struct {
+	Name  string // Where the ROM came from, such as games/pong.ch8
+	Data  []uint8
+	Info  *chip8.ROMInfo
+	Entry romdb.Entry // Empty if the ROM isn't in the database
+
+	// The screen after running for a while with the settings from the database, blank if the ROM stopped with an error first
+	Thumbnail [64][32]uint8
+}
+
+// Title is the title from the ROM database, or the file name without its extension
+func (i Item) Title() string {
+	if i.Entry.Title != "" {
+		return i.Entry.Title
+	}
+
+	base := path.Base(i.Name)
+	return strings.TrimSuffix(base, path.Ext(base))
+}
+
+// Quirks is the quirk preset the database recommends, or the one for the platform the ROM looks like it was written for
+func (i Item) Quirks() string {
+	if i.Entry.Quirks != "" {
+		return i.Entry.Quirks
+	}
+
+	return i.Info.Platform.QuirkPreset()
+}
+
+// CyclesPerFrame is the speed the database recommends, or the default
+func (i Item) CyclesPerFrame() int {
+	if i.Entry.CyclesPerFrame != 0 {
+		return i.Entry.CyclesPerFrame
+	}
+
+	return DefaultCyclesPerFrame
+}
+
+// DefaultCyclesPerFrame is the speed ROMs run at when the database doesn't say
+const DefaultCyclesPerFrame = 5
+
+// Scan finds the ROMs in a filesystem, including inside archives, and makes a thumbnail of each by running it for some frames without
+// a display. Files that can't be loaded as ROMs are left out and returned as skipped. The error is only for a filesystem that can't be
+// read. The database may be nil.
+func Scan(fsys fs.FS, db *romdb.Database, thumbnailFrames int) ([]Item, []error, error) {
+	roms, skipped, err := loader.Find(fsys)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	items := []Item{}
+	for _, rom := range roms {
+		info, err := chip8.NewROMInfo(rom.Data)
+		if err != nil {
+			skipped = append(skipped, errors.New(rom.Name+": "+err.Error()))
+			continue
+		}
+
+		item := Item{Name: rom.Name, Data: rom.Data, Info: info}
+		if db != nil {
+			item.Entry, _ = db.Lookup(info.SHA1)
+		}
+
+		quirks, err := chip8.QuirkPreset(item.Quirks())
+		if err != nil {
+			quirks = chip8.Quirks{}
+		}
+		item.Thumbnail = Thumbnail(rom.Data, quirks, item.CyclesPerFrame(), thumbnailFrames)
+
+		items = append(items, item)
+	}
+
+	return items, skipped, nil
+}
+
+// Thumbnail runs a ROM for some frames with no keys pressed and returns the screen. If the ROM stops with an error the screen is blank,
+// as whatever was drawn by then is unlikely to show what the game looks like.
+func Thumbnail(data []uint8, quirks chip8.Quirks, cyclesPerFrame int, frames int) [64][32]uint8 {
+	display := headless.New()
+	computer := chip8.New(display)
+	computer.SetQuirks(quirks)
+	if _, err := computer.LoadFromMemory(data); err != nil {
+		return [64][32]uint8{}
+	}
+
+	for i := 0; i < frames*cyclesPerFrame && !computer.IsHalted(); i++ {
+		if err := computer.Tick(); err != nil {
+			return [64][32]uint8{}
+		}
+	}
+
+	return *computer.GetScreen()
+}
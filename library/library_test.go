@@ -0,0 +1,125 @@
+package library
+
+import (
+	"chip8/chip8"
+	"chip8/romdb"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+)
+
+// Draws the font sprite for 0 in the top left corner and stops there
+var drawZero = []uint8{
+	0x60, 0x00, // 200: LD V0, 0
+	0xF0, 0x29, // 202: LD F, V0
+	0xD0, 0x05, // 204: DRW V0, V0, 5
+	0x12, 0x06, // 206: JP 0x206
+}
+
+var crashes = []uint8{
+	0xD0, 0x05, // 200: DRW V0, V0, 5
+	0x00, 0x00, // 202: invalid
+}
+
+func TestThumbnail(t *testing.T) {
+	screen := Thumbnail(drawZero, chip8.Quirks{}, 5, 10)
+	if screen[0][0] != 1 || screen[4][0] != 0 || screen[1][1] != 0 {
+		t.Errorf("Thumbnail was not drawn correctly")
+	}
+
+	if Thumbnail(crashes, chip8.Quirks{}, 5, 10) != ([64][32]uint8{}) {
+		t.Errorf("Thumbnail of a ROM that crashed was not blank")
+	}
+}
+
+func TestScan(t *testing.T) {
+	fsys := fstest.MapFS{
+		"zero.ch8":  {Data: drawZero},
+		"empty.ch8": {Data: []uint8{}},
+		"readme.md": {Data: []uint8("# ROMs")},
+	}
+
+	db := romdb.New()
+	info, _ := chip8.NewROMInfo(drawZero)
+	if err := db.Merge([]byte(`{"` + info.SHA1 + `": {"title": "Zero", "cyclesPerFrame": 7}}`)); err != nil {
+		t.Fatal(err)
+	}
+
+	items, skipped, err := Scan(fsys, db, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(items) != 1 {
+		t.Fatalf("Expected %d item, got %d", 1, len(items))
+	}
+	if len(skipped) != 1 {
+		t.Errorf("Expected the empty ROM to be skipped, got %v", skipped)
+	}
+	if items[0].Title() != "Zero" || items[0].CyclesPerFrame() != 7 || items[0].Thumbnail[0][0] != 1 {
+		t.Errorf("Item was not set correctly. Got %q at %d cycles per frame", items[0].Title(), items[0].CyclesPerFrame())
+	}
+}
+
+func TestScanSkipsBadArchives(t *testing.T) {
+	fsys := fstest.MapFS{
+		"zero.ch8":    {Data: drawZero},
+		"corrupt.zip": {Data: []uint8("PK\x03\x04 not really a zip")},
+	}
+
+	items, skipped, err := Scan(fsys, nil, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(items) != 1 || items[0].Name != "zero.ch8" {
+		t.Errorf("Items were not found correctly. Got %d", len(items))
+	}
+	if len(skipped) != 1 {
+		t.Errorf("Expected the corrupt archive to be skipped, got %v", skipped)
+	}
+}
+
+func TestRecentAndSort(t *testing.T) {
+	state := &State{ROMs: map[string]Settings{}}
+	for i := 0; i < MaxRecent+2; i++ {
+		state.Played(string(rune('a' + i)))
+	}
+	state.Played("c")
+
+	if len(state.Recent) != MaxRecent || state.Recent[0] != "c" || state.Recent[1] != "l" {
+		t.Errorf("Recently played list was not set correctly. Got %v", state.Recent)
+	}
+
+	items := []Item{
+		{Name: "zebra.ch8", Info: &chip8.ROMInfo{SHA1: "x"}},
+		{Name: "apple.ch8", Info: &chip8.ROMInfo{SHA1: "y"}},
+		{Name: "mango.ch8", Info: &chip8.ROMInfo{SHA1: "c"}},
+	}
+	state.Sort(items)
+	if items[0].Name != "mango.ch8" || items[1].Name != "apple.ch8" || items[2].Name != "zebra.ch8" {
+		t.Errorf("Items were not sorted correctly. Got %s, %s, %s", items[0].Name, items[1].Name, items[2].Name)
+	}
+}
+
+func TestSaveAndLoadState(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "chip8", "launcher.json")
+
+	state, err := LoadState(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	state.Played("abc")
+	state.ROMs["abc"] = Settings{Quirks: "vip", CyclesPerFrame: 12}
+	if err := state.Save(path); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := LoadState(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(loaded.Recent) != 1 || loaded.ROMs["abc"].Quirks != "vip" || loaded.ROMs["abc"].CyclesPerFrame != 12 {
+		t.Errorf("State was not loaded correctly. Got %+v", loaded)
+	}
+}
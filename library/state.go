@@ -0,0 +1,112 @@
+package library
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// MaxRecent is how many recently played games are remembered
+const MaxRecent = 10
+
+// Settings are the choices made for a ROM in the launcher. Empty fields use what the ROM database recommends.
+type Settings struct {
+	Quirks         string `json:"quirks,omitempty"`
+	CyclesPerFrame int    `json:"cyclesPerFrame,omitempty"`
+	Palette        string `json:"palette,omitempty"`
+	Style          string `json:"style,omitempty"`
+}
+
+// State is what the launcher remembers between runs, with ROMs identified by their SHA-1
+type State struct {
+	Recent []string            `json:"recent"` // Most recent first
+	ROMs   map[string]Settings `json:"roms"`
+}
+
+// DefaultStatePath is where the launcher keeps its state if a file isn't given explicitly
+func DefaultStatePath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+
+	return filepath.Join(dir, "chip8", "launcher.json")
+}
+
+// LoadState reads the launcher state. A missing file is a launcher that hasn't been used yet.
+func LoadState(path string) (*State, error) {
+	state := State{ROMs: map[string]Settings{}}
+
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return &state, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, err
+	}
+	if state.ROMs == nil {
+		state.ROMs = map[string]Settings{}
+	}
+
+	return &state, nil
+}
+
+// Save writes the state to a file, creating its directory if needed
+func (s *State) Save(path string) error {
+	data, err := json.MarshalIndent(s, "", "\t")
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(path, append(data, '\n'), 0644)
+}
+
+// Played moves a ROM to the top of the recently played list
+func (s *State) Played(sha1 string) {
+	recent := []string{sha1}
+	for _, hash := range s.Recent {
+		if !strings.EqualFold(hash, sha1) && len(recent) < MaxRecent {
+			recent = append(recent, hash)
+		}
+	}
+
+	s.Recent = recent
+}
+
+// recentRank is a ROM's position in the recently played list, or MaxRecent if it isn't there
+func (s *State) recentRank(sha1 string) int {
+	for i, hash := range s.Recent {
+		if strings.EqualFold(hash, sha1) {
+			return i
+		}
+	}
+
+	return MaxRecent
+}
+
+// Sort orders the items with the recently played first, most recent at the top, then the rest by title
+func (s *State) Sort(items []Item) {
+	sort.SliceStable(items, func(i, j int) bool {
+		ri, rj := s.recentRank(items[i].Info.SHA1), s.recentRank(items[j].Info.SHA1)
+		if ri != rj {
+			return ri < rj
+		}
+
+		return strings.ToLower(items[i].Title()) < strings.ToLower(items[j].Title())
+	})
+}
+
+// IsRecent reports whether a ROM is in the recently played list
+func (s *State) IsRecent(sha1 string) bool {
+	return s.recentRank(sha1) < MaxRecent
+}
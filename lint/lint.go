@@ -0,0 +1,203 @@
+// Package lint warns about code in a ROM that behaves differently between interpreters, so homebrew authors can find it before their
+// ROM ships. It runs over the instructions the control-flow graph from package analysis can reach, so data in the ROM isn't mistaken
+// for code. Each warning names the quirk the code depends on.
+package lint
+
+import (
+	"chip8/analysis"
+	"chip8/chip8"
+	"chip8/symbols"
+	"fmt"
+	"io"
+	"sort"
+)
+
+// Rules that can be broken
+const (
+	RuleShift     = "shift"      // 8XY6/8XYE with X and Y different
+	RuleLoadStore = "load-store" // FX55/FX65 followed by a use of I
+	RuleJump      = "jump"       // BNNN
+	RuleEdge      = "edge"       // A sprite drawn across the edge of the screen
+	RuleVF        = "vf"         // VF as an operand of arithmetic
+	RuleSys       = "sys"        // 0NNN
+)
+
+// Warning is a problem found at an instruction
+type Warning struct {
+	Address uint16
+	Rule    string
+	Message string
+}
+
+// Location shows where the warning is: the source line if the symbol table has one, otherwise the address. The table may be nil.
+func (w Warning) Location(table *symbols.Table) string {
+	if location, ok := table.Line(w.Address); ok {
+		return location.String()
+	}
+
+	return table.Format(w.Address)
+}
+
+// Lint checks every instruction that can be reached in a ROM, returning the warnings in address order
+func Lint(rom []uint8) []Warning {
+	g := analysis.Analyze(rom)
+	warnings := []Warning{}
+
+	for _, address := range g.Addresses() {
+		block := g.Blocks[address]
+		constants := map[uint16]uint8{}
+
+		for i, decoded := range block.Instructions {
+			warn := func(rule string, format string, args ...interface{}) {
+				warnings = append(warnings, Warning{Address: decoded.Address, Rule: rule, Message: fmt.Sprintf(format, args...)})
+			}
+			args := decoded.Instruction.Arguments
+
+			switch decoded.Instruction.Command {
+			case chip8.CmdShiftLeft, chip8.CmdShiftRight:
+				if args[0] != args[1] {
+					warn(RuleShift, "V%X is shifted in place on some interpreters and set from V%X shifted on others", args[0], args[1])
+				}
+			case chip8.CmdReadMemoryRange, chip8.CmdReadRegisterRange:
+				if use, ok := useOfI(g, block, i+1); ok {
+					warn(RuleLoadStore, "I is used at 0x%03X, but is only moved past the registers loaded or stored on some interpreters", use)
+				}
+			case chip8.CmdJumpV0Addr:
+				warn(RuleJump, "jumps relative to V0 on some interpreters and to V%X on others", args[0]>>8)
+			case chip8.CmdDisplaySprite:
+				x, xKnown := constants[args[0]]
+				y, yKnown := constants[args[1]]
+				if xKnown && yKnown && (int(x)%64 > 64-8 || int(y)%32 > 32-int(args[2])) {
+					warn(RuleEdge, "sprite at %d, %d crosses the edge of the screen, which wraps on some interpreters and clips on others", x, y)
+				}
+			}
+			if usesVF(decoded.Instruction) {
+				warn(RuleVF, "VF is an operand of arithmetic that also sets VF, and interpreters set it in different orders")
+			}
+
+			trackConstants(constants, decoded.Instruction)
+		}
+	}
+
+	// 0NNN isn't in the decode table, so the graph stops there as if it were invalid. CLS and RET share its prefix but aren't calls.
+	for _, address := range g.Invalid {
+		i := int(address) - chip8.ProgramStart
+		if i+1 >= len(rom) || rom[i]&0xF0 != 0 {
+			continue
+		}
+		if target := uint16(rom[i]&0x0F)<<8 | uint16(rom[i+1]); target != 0x0E0 && target != 0x0EE {
+			warnings = append(warnings, Warning{Address: address, Rule: RuleSys,
+				Message: fmt.Sprintf("calls machine code at 0x%03X, which only the original interpreter can run", target)})
+		}
+	}
+
+	sort.SliceStable(warnings, func(i, j int) bool {
+		return warnings[i].Address < warnings[j].Address
+	})
+
+	return warnings
+}
+
+// usesVF reports whether an instruction that sets VF as a flag also takes VF as an operand
+func usesVF(instruction *chip8.Instruction) bool {
+	switch instruction.Command {
+	case chip8.CmdAdd, chip8.CmdSub, chip8.CmdSubN, chip8.CmdShiftLeft, chip8.CmdShiftRight, chip8.CmdAnd, chip8.CmdOr, chip8.CmdXOr:
+		return instruction.Arguments[0] == 0xF || instruction.Arguments[1] == 0xF
+	}
+
+	return false
+}
+
+// trackConstants follows which registers hold a value known from LD Vx, byte earlier in the block
+func trackConstants(constants map[uint16]uint8, instruction *chip8.Instruction) {
+	args := instruction.Arguments
+
+	switch instruction.Command {
+	case chip8.CmdSetRegister:
+		constants[args[0]] = uint8(args[1])
+		return
+	case chip8.CmdAddToRegister:
+		if value, ok := constants[args[0]]; ok {
+			constants[args[0]] = value + uint8(args[1])
+		}
+		return
+	case chip8.CmdCopyRegister:
+		if value, ok := constants[args[1]]; ok {
+			constants[args[0]] = value
+		} else {
+			delete(constants, args[0])
+		}
+		return
+	case chip8.CmdAdd, chip8.CmdSub, chip8.CmdSubN, chip8.CmdShiftLeft, chip8.CmdShiftRight, chip8.CmdAnd, chip8.CmdOr, chip8.CmdXOr,
+		chip8.CmdRandom, chip8.CmdGetDelayTimer, chip8.CmdWaitForKey:
+		delete(constants, args[0])
+	case chip8.CmdReadMemoryRange:
+		for register := uint16(0); register <= args[0]; register++ {
+			delete(constants, register)
+		}
+	}
+
+	// Anything else that sets a flag changes VF
+	delete(constants, 0xF)
+}
+
+// useOfI finds an instruction that uses I before it is set again, starting from an instruction in a block. The search follows jumps,
+// skips and falling through, but stops at calls as the subroutine may set I itself.
+func useOfI(g *analysis.Graph, block *analysis.Block, start int) (uint16, bool) {
+	type position struct {
+		block *analysis.Block
+		start int
+	}
+	seen := map[uint16]bool{}
+	work := []position{{block, start}}
+
+	for len(work) > 0 {
+		p := work[0]
+		work = work[1:]
+
+		use, used, set := scanForI(p.block.Instructions[p.start:])
+		if used {
+			return use, true
+		} else if set {
+			continue
+		}
+
+		for _, edge := range p.block.Edges {
+			if next, ok := g.Blocks[edge.To]; ok && !seen[edge.To] && edge.Kind != analysis.EdgeReturn {
+				seen[edge.To] = true
+				work = append(work, position{next, 0})
+			}
+		}
+	}
+
+	return 0, false
+}
+
+// scanForI finds the first instruction that uses I, or reports that I is set before any use
+func scanForI(instructions []analysis.Decoded) (use uint16, used bool, set bool) {
+	for _, decoded := range instructions {
+		switch decoded.Instruction.Command {
+		case chip8.CmdDisplaySprite, chip8.CmdReadMemoryRange, chip8.CmdReadRegisterRange, chip8.CmdStoreBCD, chip8.CmdAddToI:
+			return decoded.Address, true, false
+		case chip8.CmdSetI, chip8.CmdSetIToFont, chip8.CmdCallSubRoutine:
+			return 0, false, true
+		}
+	}
+
+	return 0, false, false
+}
+
+// WriteWarnings prints each warning on a line, in the style of a compiler. The symbol table may be nil.
+func WriteWarnings(w io.Writer, warnings []Warning, table *symbols.Table) error {
+	for _, warning := range warnings {
+		text := fmt.Sprintf("%s: %s [%s]", warning.Location(table), warning.Message, warning.Rule)
+		if _, ok := table.Line(warning.Address); ok {
+			text = fmt.Sprintf("%s: 0x%03X: %s [%s]", warning.Location(table), warning.Address, warning.Message, warning.Rule)
+		}
+		if _, err := fmt.Fprintln(w, text); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
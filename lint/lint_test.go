@@ -0,0 +1,95 @@
+package lint
+
+import (
+	"bytes"
+	"chip8/symbols"
+	"strings"
+	"testing"
+)
+
+var program = []uint8{
+	0x81, 0x26, // 200: SHR V1, V2
+	0x83, 0x36, // 202: SHR V3, V3
+	0xA3, 0x00, // 204: LD I, 0x300
+	0xF1, 0x55, // 206: LD [I], V1
+	0x30, 0x00, // 208: SE V0, 0x00
+	0xD0, 0x15, // 20A: DRW V0, V1, 5
+	0x60, 0x3C, // 20C: LD V0, 60
+	0x61, 0x02, // 20E: LD V1, 2
+	0xD0, 0x15, // 210: DRW V0, V1, 5
+	0x8F, 0x14, // 212: ADD VF, V1
+	0x30, 0x00, // 214: SE V0, 0x00
+	0x03, 0x00, // 216: SYS 0x300
+	0xB2, 0x00, // 218: JP V0, 0x200
+}
+
+func rules(warnings []Warning) map[uint16]string {
+	found := map[uint16]string{}
+	for _, warning := range warnings {
+		found[warning.Address] += warning.Rule
+	}
+
+	return found
+}
+
+func TestLint(t *testing.T) {
+	found := rules(Lint(program))
+
+	expected := map[uint16]string{
+		0x200: RuleShift,
+		0x206: RuleLoadStore,
+		0x210: RuleEdge,
+		0x212: RuleVF,
+		0x216: RuleSys,
+		0x218: RuleJump,
+	}
+	for address, rule := range expected {
+		if found[address] != rule {
+			t.Errorf("Warning at 0x%03X was not found correctly. Expected %q, got %q", address, rule, found[address])
+		}
+	}
+	if len(found) != len(expected) {
+		t.Errorf("Expected warnings at %d addresses, got %v", len(expected), found)
+	}
+}
+
+func TestStartsWithCLS(t *testing.T) {
+	found := rules(Lint([]uint8{
+		0x00, 0xE0, // 200: CLS
+		0x81, 0x26, // 202: SHR V1, V2
+		0x12, 0x02, // 204: JP 0x202
+	}))
+
+	if len(found) != 1 || found[0x202] != RuleShift {
+		t.Errorf("Warnings were not found correctly. Expected %q at 0x202, got %v", RuleShift, found)
+	}
+}
+
+func TestLoadStoreWithISet(t *testing.T) {
+	warnings := Lint([]uint8{
+		0xF1, 0x65, // 200: LD V1, [I]
+		0xA3, 0x00, // 202: LD I, 0x300
+		0xD0, 0x15, // 204: DRW V0, V1, 5
+		0x12, 0x00, // 206: JP 0x200
+	})
+
+	if len(warnings) != 0 {
+		t.Errorf("Expected no warnings, got %+v", warnings)
+	}
+}
+
+func TestWriteWarnings(t *testing.T) {
+	table, err := symbols.Parse(strings.NewReader("line 0x218 12 game.8o\n"), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var b bytes.Buffer
+	if err := WriteWarnings(&b, Lint(program), table); err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(b.String(), "game.8o:12: 0x218: ") || !strings.Contains(b.String(), "0x200: ") {
+		t.Errorf("Warnings were not written correctly. Got:\n%s", b.String())
+	}
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"chip8/lint"
+	"chip8/symbols"
+	"fmt"
+	"os"
+)
+
+func lintCommand(args []string) int {
+	fs := newFlagSet("lint")
+	symbolFile := fs.String("symbols", "", "symbol file giving source lines for warnings (default the ROM with a .sym extension if it exists)")
+	source := addROMFlags(fs)
+	rom, err := parseROMArgs(fs, args)
+	if err != nil {
+		return usageExitCode(err)
+	}
+
+	data, err := source.read(rom)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Could not load ROM:", err)
+		return exitROM
+	}
+
+	table, err := symbols.LoadForROM(rom, *symbolFile)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Could not load symbols:", err)
+		return exitUsage
+	}
+
+	warnings := lint.Lint(data)
+	if err := lint.WriteWarnings(os.Stdout, warnings, table); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitError
+	}
+	if len(warnings) > 0 {
+		return exitError
+	}
+
+	return exitOK
+}
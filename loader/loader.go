@@ -0,0 +1,282 @@
+// Package loader finds ROMs wherever they are kept: files, standard input, any fs.FS (so a collection can be embedded in a binary with
+// embed), and .zip or .gz archives of them. Archives are recognised by their contents rather than their names, so they can be piped
+// in too. An archive can hold several ROMs, so loading gives a list to choose from.
+package loader
+
+import (
+	"archive/zip"
+	"bytes"
+	"chip8/chip8"
+	"compress/gzip"
+	"errors"
+	"io"
+	"io/fs"
+	"io/ioutil"
+	"os"
+	"path"
+	"sort"
+	"strings"
+)
+
+// Stdin is the path that loads a ROM from standard input
+const Stdin = "-"
+
+// maxArchiveSize limits how much is read from an archive or its entries, so a corrupt or malicious one can't use up all memory
+const maxArchiveSize = 64 << 20
+
+// Extensions ROMs are usually saved with. When an archive has files with these, its other files are taken to be documentation.
+var Extensions = []string{".ch8", ".c8", ".sc8", ".xo8", ".rom", ".bin"}
+
+var (
+	// ErrNoROMs is returned for an archive without anything that could be a ROM
+	ErrNoROMs = errors.New("No ROMs found")
+	// ErrTooLarge is returned when an archive is too large to read
+	ErrTooLarge = errors.New("Archive is too large")
+)
+
+// ROM is a ROM that has been read
+type ROM struct {
+	Name string // Where it came from, such as games.zip/pong.ch8
+	Data []uint8
+}
+
+// Load reads the ROMs at a path, or from standard input if the path is -
+func Load(p string) ([]ROM, error) {
+	if p == Stdin {
+		return Read(os.Stdin, "stdin")
+	}
+
+	file, err := os.Open(p)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return Read(file, p)
+}
+
+// LoadFS reads the ROMs at a path in a filesystem
+func LoadFS(fsys fs.FS, p string) ([]ROM, error) {
+	file, err := fsys.Open(p)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return Read(file, p)
+}
+
+// Read reads a ROM, or the ROMs in an archive, named so they can be told apart
+func Read(r io.Reader, name string) ([]ROM, error) {
+	data, err := readLimited(r)
+	if err != nil {
+		return nil, err
+	}
+
+	return unpack(data, name, 0)
+}
+
+// readLimited reads everything up to maxArchiveSize
+func readLimited(r io.Reader) ([]uint8, error) {
+	data, err := ioutil.ReadAll(io.LimitReader(r, maxArchiveSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxArchiveSize {
+		return nil, ErrTooLarge
+	}
+
+	return data, nil
+}
+
+// unpack opens archives, including archives inside archives up to a limit
+func unpack(data []uint8, name string, depth int) ([]ROM, error) {
+	if depth > 2 {
+		return nil, errors.New("Archives are nested too deeply")
+	}
+
+	switch {
+	case bytes.HasPrefix(data, []uint8("PK\x03\x04")):
+		return unzip(data, name, depth)
+	case bytes.HasPrefix(data, []uint8{0x1F, 0x8B}):
+		return gunzip(data, name, depth)
+	}
+
+	return []ROM{{Name: name, Data: data}}, nil
+}
+
+func unzip(data []uint8, name string, depth int) ([]ROM, error) {
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, err
+	}
+
+	roms := []ROM{}
+	for _, file := range archive.File {
+		if file.FileInfo().IsDir() || file.UncompressedSize64 == 0 {
+			continue
+		}
+
+		contents, err := readZipFile(file)
+		if err != nil {
+			return nil, errors.New(file.Name + ": " + err.Error())
+		}
+		found, err := unpack(contents, name+"/"+file.Name, depth+1)
+		if err != nil {
+			return nil, err
+		}
+		roms = append(roms, found...)
+	}
+
+	return likelyROMs(roms)
+}
+
+func readZipFile(file *zip.File) ([]uint8, error) {
+	r, err := file.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	return readLimited(r)
+}
+
+func gunzip(data []uint8, name string, depth int) ([]ROM, error) {
+	r, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	contents, err := readLimited(r)
+	if err != nil {
+		return nil, err
+	}
+
+	// The name inside is optional, so fall back to the name of the archive without .gz
+	inner := r.Name
+	if inner == "" {
+		inner = strings.TrimSuffix(path.Base(name), ".gz")
+	}
+
+	return unpack(contents, name+"/"+inner, depth+1)
+}
+
+// likelyROMs drops files from an archive that can't be ROMs. Files too large to fit in memory go, and if any files have a ROM
+// extension then the rest go too.
+func likelyROMs(roms []ROM) ([]ROM, error) {
+	fit := []ROM{}
+	withExtension := []ROM{}
+	for _, rom := range roms {
+		if len(rom.Data) > chip8.MaxROMSize {
+			continue
+		}
+		fit = append(fit, rom)
+		if HasExtension(rom.Name) {
+			withExtension = append(withExtension, rom)
+		}
+	}
+
+	if len(withExtension) > 0 {
+		return withExtension, nil
+	}
+	if len(fit) == 0 {
+		return nil, ErrNoROMs
+	}
+
+	return fit, nil
+}
+
+// HasExtension reports whether a file is named like a ROM
+func HasExtension(name string) bool {
+	ext := strings.ToLower(path.Ext(name))
+	for _, e := range Extensions {
+		if ext == e {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Find lists the ROMs in a filesystem: every file named like a ROM, and the ROMs inside any archives. They are sorted by name.
+// Files that can't be loaded, such as corrupt archives, are left out and their errors returned as skipped, so one bad file doesn't
+// hide the rest. The error is only for a filesystem that can't be read.
+func Find(fsys fs.FS) ([]ROM, []error, error) {
+	roms := []ROM{}
+	skipped := []error{}
+	err := fs.WalkDir(fsys, ".", func(p string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() {
+			return err
+		}
+
+		ext := strings.ToLower(path.Ext(p))
+		if !HasExtension(p) && ext != ".zip" && ext != ".gz" {
+			return nil
+		}
+
+		found, err := LoadFS(fsys, p)
+		if err != nil {
+			skipped = append(skipped, errors.New(p+": "+err.Error()))
+			return nil
+		}
+		roms = append(roms, found...)
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	sort.Slice(roms, func(i, j int) bool {
+		return roms[i].Name < roms[j].Name
+	})
+
+	return roms, skipped, nil
+}
+
+// Chooser asks which of several ROMs to use, returning its index
+type Chooser func(roms []ROM) (int, error)
+
+// Choose picks one ROM. A single ROM is used as it is. Otherwise the ROM whose name, or the end of it, matches name is used, and if
+// name is empty the chooser is asked. The chooser may be nil, in which case it is an error to have to ask.
+func Choose(roms []ROM, name string, choose Chooser) (ROM, error) {
+	if len(roms) == 0 {
+		return ROM{}, ErrNoROMs
+	}
+
+	if name != "" {
+		for _, rom := range roms {
+			if rom.Name == name || strings.HasSuffix(rom.Name, "/"+name) {
+				return rom, nil
+			}
+		}
+		return ROM{}, errors.New("No ROM named " + name)
+	}
+
+	if len(roms) == 1 {
+		return roms[0], nil
+	}
+	if choose == nil {
+		return ROM{}, errors.New("There are several ROMs to choose from: " + strings.Join(Names(roms), ", "))
+	}
+
+	i, err := choose(roms)
+	if err != nil {
+		return ROM{}, err
+	}
+	if i < 0 || i >= len(roms) {
+		return ROM{}, errors.New("No such ROM")
+	}
+
+	return roms[i], nil
+}
+
+// Names lists the names of ROMs
+func Names(roms []ROM) []string {
+	names := []string{}
+	for _, rom := range roms {
+		names = append(names, rom.Name)
+	}
+
+	return names
+}
@@ -0,0 +1,145 @@
+package loader
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"testing"
+	"testing/fstest"
+)
+
+var (
+	pong   = []uint8{0x6A, 0x02, 0x6B, 0x0C}
+	tetris = []uint8{0xA2, 0xB4, 0x23, 0xE6}
+)
+
+func zipOf(t *testing.T, files map[string][]uint8) []uint8 {
+	var b bytes.Buffer
+	w := zip.NewWriter(&b)
+	for name, data := range files {
+		f, err := w.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		f.Write(data)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	return b.Bytes()
+}
+
+func gzipOf(t *testing.T, name string, data []uint8) []uint8 {
+	var b bytes.Buffer
+	w := gzip.NewWriter(&b)
+	w.Name = name
+	w.Write(data)
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	return b.Bytes()
+}
+
+func TestReadPlain(t *testing.T) {
+	roms, err := Read(bytes.NewReader(pong), "pong.ch8")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(roms) != 1 || roms[0].Name != "pong.ch8" || !bytes.Equal(roms[0].Data, pong) {
+		t.Errorf("ROM was not read correctly. Got %+v", roms)
+	}
+}
+
+func TestReadZip(t *testing.T) {
+	archive := zipOf(t, map[string][]uint8{"games/pong.ch8": pong, "games/tetris.ch8": tetris, "README.txt": []uint8("Have fun")})
+
+	roms, err := Read(bytes.NewReader(archive), "games.zip")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(roms) != 2 {
+		t.Fatalf("Expected %d ROMs, got %v", 2, Names(roms))
+	}
+	rom, err := Choose(roms, "tetris.ch8", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rom.Name != "games.zip/games/tetris.ch8" || !bytes.Equal(rom.Data, tetris) {
+		t.Errorf("ROM was not chosen correctly. Got %+v", rom)
+	}
+}
+
+func TestReadGzip(t *testing.T) {
+	roms, err := Read(bytes.NewReader(gzipOf(t, "", pong)), "pong.ch8.gz")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(roms) != 1 || roms[0].Name != "pong.ch8.gz/pong.ch8" || !bytes.Equal(roms[0].Data, pong) {
+		t.Errorf("ROM was not read correctly. Got %+v", roms)
+	}
+}
+
+func TestChoose(t *testing.T) {
+	roms := []ROM{{Name: "a.ch8", Data: pong}, {Name: "b.ch8", Data: tetris}}
+
+	if _, err := Choose(roms, "", nil); err == nil {
+		t.Errorf("Expected an error choosing between several ROMs without asking")
+	}
+
+	asked := 0
+	rom, err := Choose(roms, "", func(roms []ROM) (int, error) {
+		asked++
+		return 1, nil
+	})
+	if err != nil || asked != 1 || rom.Name != "b.ch8" {
+		t.Errorf("ROM was not chosen correctly. Asked %d times, got %+v, %v", asked, rom, err)
+	}
+
+	if _, err := Choose(roms, "c.ch8", nil); err == nil {
+		t.Errorf("Expected an error choosing a ROM that isn't there")
+	}
+}
+
+func TestFind(t *testing.T) {
+	fsys := fstest.MapFS{
+		"pong.ch8":       {Data: pong},
+		"more/games.zip": {Data: zipOf(t, map[string][]uint8{"tetris.ch8": tetris})},
+		"notes.txt":      {Data: []uint8("not a ROM")},
+	}
+
+	roms, skipped, err := Find(fsys)
+	if err != nil || len(skipped) != 0 {
+		t.Fatal(err, skipped)
+	}
+
+	names := Names(roms)
+	if len(names) != 2 || names[0] != "more/games.zip/tetris.ch8" || names[1] != "pong.ch8" {
+		t.Errorf("ROMs were not found correctly. Got %v", names)
+	}
+}
+
+func TestFindSkipsBadArchives(t *testing.T) {
+	fsys := fstest.MapFS{
+		"pong.ch8":    {Data: pong},
+		"corrupt.zip": {Data: []uint8("PK\x03\x04 not really a zip")},
+		"huge.zip":    {Data: zipOf(t, map[string][]uint8{"huge.ch8": make([]uint8, 4096)})},
+	}
+
+	roms, skipped, err := Find(fsys)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	names := Names(roms)
+	if len(names) != 1 || names[0] != "pong.ch8" {
+		t.Errorf("ROMs were not found correctly. Got %v", names)
+	}
+	if len(skipped) != 2 {
+		t.Errorf("Expected %d files to be skipped, got %v", 2, skipped)
+	}
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"bufio"
+	"chip8/loader"
+	"chip8/patch"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// romFlags are the flags for how a ROM is loaded
+type romFlags struct {
+	entry   *string
+	patches *patchList
+}
+
+func addROMFlags(fs *flag.FlagSet) romFlags {
+	return romFlags{
+		entry:   fs.String("entry", "", "name of the ROM to load from an archive that holds several (default ask)"),
+		patches: addPatchFlag(fs),
+	}
+}
+
+// read loads a ROM from a file, an archive or standard input (-), then applies the patches. If an archive holds several ROMs and
+// -entry wasn't given, the user is asked which to use.
+func (rf romFlags) read(path string) ([]uint8, error) {
+	roms, err := loader.Load(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var choose loader.Chooser
+	if path != loader.Stdin && isTerminal(os.Stdin) {
+		choose = askForROM
+	}
+	rom, err := loader.Choose(roms, *rf.entry, choose)
+	if err != nil {
+		return nil, err
+	}
+
+	return patch.ApplyFiles(rom.Data, *rf.patches...)
+}
+
+func isTerminal(file *os.File) bool {
+	info, err := file.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// askForROM lists the ROMs and reads the number of one from the terminal
+func askForROM(roms []loader.ROM) (int, error) {
+	fmt.Fprintln(os.Stderr, "The archive holds several ROMs:")
+	for i, rom := range roms {
+		fmt.Fprintf(os.Stderr, "  %2d  %s\n", i+1, rom.Name)
+	}
+	fmt.Fprint(os.Stderr, "Which one? ")
+
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil {
+		return 0, errors.New("No ROM chosen")
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(line))
+	if err != nil {
+		return 0, errors.New("No ROM chosen")
+	}
+
+	return n - 1, nil
+}
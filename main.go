@@ -1,36 +1,69 @@
 package main
 
 import (
-	"chip8/chip8"
-	"chip8/pixeldisplay"
-	"time"
+	"fmt"
+	"os"
+)
 
-	"github.com/faiface/pixel/pixelgl"
+// Exit codes used by all commands
+const (
+	exitOK    = 0
+	exitError = 1
+	exitUsage = 2
+	exitROM   = 3
 )
 
-func setup() {
-	display := pixeldisplay.New(8)
-	computer := chip8.New(display)
-	computer.LoadROM("roms/pong.rom")
+type command struct {
+	name    string
+	summary string
+	run     func(args []string) int
+}
 
-	ticker := time.NewTicker(time.Second / 300)
+var commands = []command{
+	{name: "run", summary: "run a ROM in a window", run: runCommand},
+	{name: "launch", summary: "choose a ROM to run from a list", run: launchCommand},
+	{name: "info", summary: "show information about a ROM", run: infoCommand},
+	{name: "disasm", summary: "print a disassembly of a ROM", run: disasmCommand},
+	{name: "conformance", summary: "run a directory of test ROMs under every quirk preset", run: conformanceCommand},
+	{name: "cfg", summary: "analyse the control flow of a ROM without running it", run: cfgCommand},
+	{name: "lint", summary: "warn about code that depends on interpreter quirks", run: lintCommand},
+	{name: "coverage", summary: "report which instructions of a ROM ran, from recorded coverage", run: coverageCommand},
+	{name: "bench", summary: "measure how fast a ROM runs without a display", run: benchCommand},
+	{name: "patch", summary: "create an IPS or BPS patch from two versions of a ROM", run: patchCommand},
+	{name: "cheat", summary: "search memory and make cheats in a console", run: cheatCommand},
+	{name: "dap", summary: "run a Debug Adapter Protocol server for editors", run: dapCommand},
+}
 
-	for !display.Closed() && !computer.IsHalted() {
-		computer.Tick()
-		display.Update(computer.GetScreen(), computer.GetDirtyFlags())
+func usage() {
+	fmt.Fprintln(os.Stderr, "Usage: chip8 <command> [flags] <rom>")
+	fmt.Fprintln(os.Stderr)
+	fmt.Fprintln(os.Stderr, "Commands:")
+	for _, c := range commands {
+		fmt.Fprintf(os.Stderr, "  %-12s %s\n", c.name, c.summary)
+	}
+	fmt.Fprintln(os.Stderr)
+	fmt.Fprintln(os.Stderr, "Run 'chip8 <command> -h' for the flags of a command.")
+}
 
-		<-ticker.C
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(exitUsage)
 	}
 
-	computer.Pause()
-	ticker.Stop()
+	name := os.Args[1]
+	for _, c := range commands {
+		if c.name == name {
+			os.Exit(c.run(os.Args[2:]))
+		}
+	}
 
-	// Keep the display running after halting; makes it easier to debug etc
-	for !display.Closed() {
-		display.Update(computer.GetScreen(), computer.GetDirtyFlags())
+	if name == "-h" || name == "-help" || name == "help" {
+		usage()
+		os.Exit(exitOK)
 	}
-}
 
-func main() {
-	pixelgl.Run(setup)
+	fmt.Fprintf(os.Stderr, "Unknown command %q\n\n", name)
+	usage()
+	os.Exit(exitUsage)
 }
@@ -0,0 +1,92 @@
+package memwatch
+
+import (
+	"chip8/chip8"
+	"image"
+	"image/color"
+	"image/png"
+	"math"
+	"os"
+)
+
+// HeatmapWidth is the number of addresses in each row of a heatmap, so memory is drawn as a 64x64 square
+const HeatmapWidth = 64
+
+// Heatmap draws memory with a square of scale pixels per address, starting from address 0 in the top left. Green is for instruction
+// fetches, blue for reads of sprites and loads, and red for writes, so self-modifying code shows up yellow. Brightness is on a log
+// scale against the busiest address for each colour, so addresses that are rarely touched are still visible.
+func (w *Watch) Heatmap(scale int) *image.RGBA {
+	if scale < 1 {
+		scale = 1
+	}
+
+	fetches := [chip8.MemorySize]uint64{}
+	reads := [chip8.MemorySize]uint64{}
+	writes := [chip8.MemorySize]uint64{}
+	for address := 0; address < chip8.MemorySize; address++ {
+		fetches[address] = w.counts[chip8.AccessFetch][address]
+		reads[address] = w.counts[chip8.AccessSprite][address] + w.counts[chip8.AccessLoad][address]
+		writes[address] = w.counts[chip8.AccessBCD][address] + w.counts[chip8.AccessStore][address]
+	}
+
+	mostWrites, mostFetches, mostReads := most(writes[:]), most(fetches[:]), most(reads[:])
+
+	rows := chip8.MemorySize / HeatmapWidth
+	img := image.NewRGBA(image.Rect(0, 0, HeatmapWidth*scale, rows*scale))
+	for address := 0; address < chip8.MemorySize; address++ {
+		c := color.RGBA{
+			R: brightness(writes[address], mostWrites),
+			G: brightness(fetches[address], mostFetches),
+			B: brightness(reads[address], mostReads),
+			A: 0xFF,
+		}
+
+		x, y := address%HeatmapWidth*scale, address/HeatmapWidth*scale
+		for i := 0; i < scale; i++ {
+			for j := 0; j < scale; j++ {
+				img.SetRGBA(x+i, y+j, c)
+			}
+		}
+	}
+
+	return img
+}
+
+// SaveHeatmap writes the heatmap to a PNG file
+func (w *Watch) SaveHeatmap(path string, scale int) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	if err := png.Encode(file, w.Heatmap(scale)); err != nil {
+		file.Close()
+		return err
+	}
+
+	return file.Close()
+}
+
+// brightness maps a count to a colour level. Anything accessed at all is at least a quarter brightness so it stands out from black.
+func brightness(count uint64, highest uint64) uint8 {
+	if count == 0 {
+		return 0
+	}
+	if highest <= 1 {
+		return 0xFF
+	}
+
+	level := math.Log(float64(count)) / math.Log(float64(highest))
+	return uint8(0x40 + level*0xBF)
+}
+
+func most(counts []uint64) uint64 {
+	highest := uint64(0)
+	for _, c := range counts {
+		if c > highest {
+			highest = c
+		}
+	}
+
+	return highest
+}
@@ -0,0 +1,153 @@
+// Package memwatch counts how each address in memory is accessed, to help work out the layout of a ROM: which parts are code, which
+// are sprites and which are used for variables. Attach a Watch to a CPU with Chip8.SetMemoryWatcher, then draw it as a heatmap or list
+// it as regions.
+package memwatch
+
+import (
+	"chip8/chip8"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+)
+
+// Watch is a chip8.MemoryWatcher that counts accesses of each kind to each address
+type Watch struct {
+	counts [chip8.AccessKinds][chip8.MemorySize]uint64
+}
+
+// New creates an empty watch
+func New() *Watch {
+	return &Watch{}
+}
+
+// MemoryAccess counts an access to each address in the range
+func (w *Watch) MemoryAccess(kind chip8.AccessKind, address uint16, length int) {
+	for i := int(address); i < int(address)+length && i < chip8.MemorySize; i++ {
+		w.counts[kind][i]++
+	}
+}
+
+// Count returns how many accesses of a kind an address has had
+func (w *Watch) Count(kind chip8.AccessKind, address uint16) uint64 {
+	if int(address) >= chip8.MemorySize {
+		return 0
+	}
+
+	return w.counts[kind][address]
+}
+
+// kinds returns the set of kinds of access an address has had, as a bit per kind
+func (w *Watch) kinds(address int) Kinds {
+	kinds := Kinds(0)
+	for kind := range w.counts {
+		if w.counts[kind][address] > 0 {
+			kinds |= 1 << kind
+		}
+	}
+
+	return kinds
+}
+
+// Kinds is a set of access kinds, with a bit for each
+type Kinds uint8
+
+// Has reports whether the set includes a kind
+func (k Kinds) Has(kind chip8.AccessKind) bool {
+	return k&(1<<kind) != 0
+}
+
+// SelfModifying reports whether memory was both run and written, which means the ROM changes its own code
+func (k Kinds) SelfModifying() bool {
+	return k.Has(chip8.AccessFetch) && (k.Has(chip8.AccessBCD) || k.Has(chip8.AccessStore))
+}
+
+func (k Kinds) String() string {
+	names := []string{}
+	for kind := chip8.AccessKind(0); kind < chip8.AccessKinds; kind++ {
+		if k.Has(kind) {
+			names = append(names, kind.String())
+		}
+	}
+
+	return strings.Join(names, "+")
+}
+
+// Region is a run of addresses that were all accessed in the same ways
+type Region struct {
+	Start  uint16
+	End    uint16 // Inclusive
+	Kinds  Kinds
+	Counts [chip8.AccessKinds]uint64 // Accesses of each kind over the whole region
+}
+
+// Regions splits the memory that was accessed into runs of addresses with the same kinds of access. They are sorted by kind of
+// access, in the order fetch, sprite, bcd, store, load, then by address, so code comes first and variables last.
+func (w *Watch) Regions() []Region {
+	regions := []Region{}
+
+	for address := 0; address < chip8.MemorySize; address++ {
+		kinds := w.kinds(address)
+		if kinds == 0 {
+			continue
+		}
+
+		last := len(regions) - 1
+		if last < 0 || regions[last].Kinds != kinds || int(regions[last].End) != address-1 {
+			regions = append(regions, Region{Start: uint16(address), Kinds: kinds})
+			last++
+		}
+
+		regions[last].End = uint16(address)
+		for kind := range w.counts {
+			regions[last].Counts[kind] += w.counts[kind][address]
+		}
+	}
+
+	sort.SliceStable(regions, func(i, j int) bool {
+		return kindOrder(regions[i].Kinds) < kindOrder(regions[j].Kinds)
+	})
+
+	return regions
+}
+
+// kindOrder sorts sets by their first kind, then by the rest. Reversing the bits puts fetch at the top.
+func kindOrder(kinds Kinds) int {
+	order := 0
+	for kind := 0; kind < chip8.AccessKinds; kind++ {
+		order <<= 1
+		if kinds.Has(chip8.AccessKind(kind)) {
+			order |= 1
+		}
+	}
+
+	return -order
+}
+
+// WriteTable prints the regions with the accesses of each kind. Self-modifying code is marked with a !.
+func (w *Watch) WriteTable(out io.Writer) error {
+	tw := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+
+	header := []string{"START", "END", "SIZE", "ACCESS"}
+	for kind := chip8.AccessKind(0); kind < chip8.AccessKinds; kind++ {
+		header = append(header, strings.ToUpper(kind.String()))
+	}
+	fmt.Fprintln(tw, strings.Join(header, "\t"))
+
+	for _, region := range w.Regions() {
+		access := region.Kinds.String()
+		if region.Kinds.SelfModifying() {
+			access += " !"
+		}
+
+		row := []string{fmt.Sprintf("0x%03X", region.Start), fmt.Sprintf("0x%03X", region.End), fmt.Sprint(region.End - region.Start + 1),
+			access}
+		for _, count := range region.Counts {
+			row = append(row, fmt.Sprint(count))
+		}
+		fmt.Fprintln(tw, strings.Join(row, "\t"))
+	}
+
+	return tw.Flush()
+}
@@ -0,0 +1,101 @@
+package memwatch
+
+import (
+	"bytes"
+	"chip8/chip8"
+	"strings"
+	"testing"
+)
+
+type display struct{}
+
+func (d *display) Update(*[64][32]uint8, *[64][32]bool) {}
+func (d *display) Closed() bool                         { return false }
+func (d *display) KeyDown(key uint8) bool               { return false }
+
+// Writes JP 0x208 over the instruction at 0x208 and then runs it
+var selfModifying = []uint8{
+	0xA2, 0x08, // 200: LD I, 0x208
+	0x60, 0x12, // 202: LD V0, 0x12
+	0x61, 0x08, // 204: LD V1, 0x08
+	0xF1, 0x55, // 206: LD [I], V1
+	0x00, 0x00, // 208: replaced with JP 0x208
+}
+
+func watchProgram(t *testing.T) *Watch {
+	computer := chip8.New(&display{})
+	if _, err := computer.LoadFromMemory(selfModifying); err != nil {
+		t.Fatal(err)
+	}
+
+	watch := New()
+	computer.SetMemoryWatcher(watch)
+	for i := 0; i < 6; i++ {
+		if err := computer.Tick(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return watch
+}
+
+func TestCounts(t *testing.T) {
+	watch := watchProgram(t)
+
+	if watch.Count(chip8.AccessFetch, 0x208) != 2 || watch.Count(chip8.AccessStore, 0x209) != 1 {
+		t.Errorf("Accesses were not counted correctly. Got %d fetches and %d stores", watch.Count(chip8.AccessFetch, 0x208),
+			watch.Count(chip8.AccessStore, 0x209))
+	}
+	if watch.Count(chip8.AccessStore, 0x20A) != 0 {
+		t.Errorf("Store was counted past its end")
+	}
+}
+
+func TestRegions(t *testing.T) {
+	regions := watchProgram(t).Regions()
+
+	if len(regions) != 2 {
+		t.Fatalf("Expected %d regions, got %+v", 2, regions)
+	}
+
+	if regions[0].Start != 0x208 || regions[0].End != 0x209 || !regions[0].Kinds.SelfModifying() {
+		t.Errorf("Self-modifying region was not found correctly. Got %+v", regions[0])
+	}
+	if regions[0].Counts[chip8.AccessFetch] != 4 || regions[0].Counts[chip8.AccessStore] != 2 {
+		t.Errorf("Region counts were not totalled correctly. Got %v", regions[0].Counts)
+	}
+	if regions[1].Start != 0x200 || regions[1].End != 0x207 || regions[1].Kinds.SelfModifying() {
+		t.Errorf("Code region was not found correctly. Got %+v", regions[1])
+	}
+}
+
+func TestWriteTable(t *testing.T) {
+	buf := bytes.Buffer{}
+	if err := watchProgram(t).WriteTable(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 3 || !strings.Contains(lines[1], "fetch+store !") || !strings.HasPrefix(lines[2], "0x200  0x207  8") {
+		t.Errorf("Table was not written correctly:\n%s", buf.String())
+	}
+}
+
+func TestHeatmap(t *testing.T) {
+	img := watchProgram(t).Heatmap(2)
+
+	if img.Bounds().Dx() != 128 || img.Bounds().Dy() != 128 {
+		t.Fatalf("Heatmap size was not correct. Expected 128x128, got %v", img.Bounds())
+	}
+
+	// 0x208 is row 8, column 8
+	if c := img.RGBAAt(17, 17); c.R == 0 || c.G == 0 || c.B != 0 {
+		t.Errorf("Self-modifying code was not drawn correctly. Got %v", c)
+	}
+	if c := img.RGBAAt(0, 16); c.R != 0 || c.G == 0 {
+		t.Errorf("Code was not drawn correctly. Got %v", c)
+	}
+	if c := img.RGBAAt(0, 0); c.R != 0 || c.G != 0 || c.B != 0 {
+		t.Errorf("Untouched memory was not drawn black. Got %v", c)
+	}
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"chip8/memwatch"
+	"flag"
+	"os"
+)
+
+// memoryFlags are the flags for watching memory accesses
+type memoryFlags struct {
+	heatmap *string
+	report  *string
+}
+
+func addMemoryFlags(fs *flag.FlagSet) memoryFlags {
+	return memoryFlags{
+		heatmap: fs.String("heatmap", "", "write a PNG heatmap of memory accesses to this file"),
+		report:  fs.String("memory-report", "", "write a table of memory regions by how they were accessed to this file (- for stdout)"),
+	}
+}
+
+// memoryOutput is a running memory watch and where its results go
+type memoryOutput struct {
+	watch *memwatch.Watch
+	flags memoryFlags
+}
+
+// start creates the watch, or returns nil if it wasn't asked for
+func (mf memoryFlags) start() *memoryOutput {
+	if *mf.heatmap == "" && *mf.report == "" {
+		return nil
+	}
+
+	return &memoryOutput{watch: memwatch.New(), flags: mf}
+}
+
+// save writes out the heatmap and table. Safe to call on a nil watch.
+func (mo *memoryOutput) save() error {
+	if mo == nil {
+		return nil
+	}
+
+	if *mo.flags.heatmap != "" {
+		if err := mo.watch.SaveHeatmap(*mo.flags.heatmap, 8); err != nil {
+			return err
+		}
+	}
+
+	switch *mo.flags.report {
+	case "":
+	case "-":
+		return mo.watch.WriteTable(os.Stdout)
+	default:
+		file, err := os.Create(*mo.flags.report)
+		if err != nil {
+			return err
+		}
+		if err := mo.watch.WriteTable(file); err != nil {
+			file.Close()
+			return err
+		}
+		return file.Close()
+	}
+
+	return nil
+}
@@ -0,0 +1,200 @@
+package palette
+
+import (
+	"encoding/json"
+	"errors"
+	"image/color"
+	"io/ioutil"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Palette holds the colours used to draw the screen, indexed by pixel value. Index 0 is the background and index 1 the foreground. XO-CHIP
+// games that draw to two bit planes use four colours and four planes use sixteen.
+type Palette struct {
+	Name   string
+	Colors []color.RGBA
+}
+
+// Background is the colour of pixels that are off
+func (p Palette) Background() color.RGBA {
+	return p.Colors[0]
+}
+
+// Foreground is the colour of pixels that are on
+func (p Palette) Foreground() color.RGBA {
+	return p.Colors[1]
+}
+
+// Color gets the colour for a pixel value. Values the palette has no colour for are drawn in the foreground colour so a two colour
+// palette still shows everything.
+func (p Palette) Color(value uint8) color.RGBA {
+	if int(value) < len(p.Colors) {
+		return p.Colors[value]
+	}
+
+	return p.Foreground()
+}
+
+// GridColor is a colour between the background and foreground used for grid lines
+func (p Palette) GridColor() color.RGBA {
+	return Mix(p.Background(), p.Foreground(), 0.2)
+}
+
+// Mix blends from a to b, where amount 0 is all a and 1 is all b
+func Mix(a color.RGBA, b color.RGBA, amount float64) color.RGBA {
+	mix := func(x uint8, y uint8) uint8 {
+		return uint8(float64(x) + (float64(y)-float64(x))*amount + 0.5)
+	}
+
+	return color.RGBA{R: mix(a.R, b.R), G: mix(a.G, b.G), B: mix(a.B, b.B), A: mix(a.A, b.A)}
+}
+
+// Presets are the built-in palettes. The Octo themes match the ones built in to the Octo IDE.
+var Presets = map[string]Palette{}
+
+func init() {
+	add := func(name string, colors ...string) {
+		palette, err := fromHex(name, colors)
+		if err != nil {
+			panic(err)
+		}
+		Presets[name] = palette
+	}
+
+	add("classic", "#000000", "#FFFFFF")
+	add("amber", "#1A0F00", "#FFB000")
+	add("green", "#001100", "#33FF33")
+	add("octo", "#996600", "#FFCC00", "#FF6600", "#662200")
+	add("octo-lcd", "#F9FFB3", "#3D8026", "#ABCC47", "#00131A")
+	add("octo-hotdog", "#000000", "#FF0000", "#FFFF00", "#FFFFFF")
+	add("octo-gray", "#AAAAAA", "#000000", "#FFFFFF", "#666666")
+	add("octo-cga0", "#000000", "#00FF00", "#FF0000", "#FFFF00")
+	add("octo-cga1", "#000000", "#FF00FF", "#00FFFF", "#FFFFFF")
+	add("ega", "#000000", "#FFFFFF", "#AA0000", "#FFFF55",
+		"#0000AA", "#00AA00", "#00AAAA", "#AA00AA",
+		"#AA5500", "#AAAAAA", "#555555", "#5555FF",
+		"#55FF55", "#55FFFF", "#FF5555", "#FF55FF")
+}
+
+// user holds the palettes added with Load. They're kept apart from the presets so a file can't replace a built-in palette.
+var user = struct {
+	sync.RWMutex
+	palettes map[string]Palette
+}{palettes: map[string]Palette{}}
+
+// Lookup finds a palette by name, either a preset or one added with Load
+func Lookup(name string) (Palette, bool) {
+	if palette, ok := Presets[name]; ok {
+		return palette, true
+	}
+
+	user.RLock()
+	defer user.RUnlock()
+	palette, ok := user.palettes[name]
+	return palette, ok
+}
+
+// Names returns the names of all presets and palettes added with Load in a stable order
+func Names() []string {
+	names := []string{}
+	for name := range Presets {
+		names = append(names, name)
+	}
+
+	user.RLock()
+	for name := range user.palettes {
+		names = append(names, name)
+	}
+	user.RUnlock()
+	sort.Strings(names)
+
+	return names
+}
+
+// Parse gets a palette either by preset name or from a list of hex colours. For compatibility with the original two colour option the
+// first colour is the foreground and the second the background, e.g. "#FFB000,#000000"; any further colours are used for pixel values
+// 2 and above.
+func Parse(value string) (Palette, error) {
+	if palette, ok := Lookup(value); ok {
+		return palette, nil
+	}
+
+	parts := strings.Split(value, ",")
+	if len(parts) < 2 || len(parts) > 16 {
+		return Palette{}, errors.New("Unknown palette " + value)
+	}
+
+	parts[0], parts[1] = parts[1], parts[0]
+	return fromHex(value, parts)
+}
+
+// Load reads user defined palettes from a JSON file so they can be used by name like the presets. The file maps names to lists of
+// colours ordered by pixel value (background first), e.g. {"mine": ["#000000", "#FF0000"]}.
+// Will return an error if a palette is invalid or has the name of a preset; nothing is added in that case.
+func Load(path string) error {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	raw := map[string][]string{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	loaded := map[string]Palette{}
+	for name, colors := range raw {
+		if _, ok := Presets[name]; ok {
+			return errors.New("Palette " + name + " is built in; give yours another name")
+		}
+		if len(colors) < 2 || len(colors) > 16 {
+			return errors.New("Palette " + name + " must have between 2 and 16 colours")
+		}
+
+		palette, err := fromHex(name, colors)
+		if err != nil {
+			return err
+		}
+		loaded[name] = palette
+	}
+
+	user.Lock()
+	defer user.Unlock()
+	for name, palette := range loaded {
+		user.palettes[name] = palette
+	}
+
+	return nil
+}
+
+func fromHex(name string, values []string) (Palette, error) {
+	palette := Palette{Name: name}
+
+	for _, value := range values {
+		c, err := ParseHexColor(value)
+		if err != nil {
+			return Palette{}, err
+		}
+		palette.Colors = append(palette.Colors, c)
+	}
+
+	return palette, nil
+}
+
+// ParseHexColor parses a colour in the form "#RRGGBB"; the # is optional
+func ParseHexColor(value string) (color.RGBA, error) {
+	value = strings.TrimPrefix(strings.TrimSpace(value), "#")
+	if len(value) != 6 {
+		return color.RGBA{}, errors.New("Invalid colour #" + value)
+	}
+
+	rgb, err := strconv.ParseUint(value, 16, 32)
+	if err != nil {
+		return color.RGBA{}, errors.New("Invalid colour #" + value)
+	}
+
+	return color.RGBA{R: uint8(rgb >> 16), G: uint8(rgb >> 8), B: uint8(rgb), A: 0xFF}, nil
+}
@@ -0,0 +1,98 @@
+package palette
+
+import (
+	"image/color"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+func TestPresetsHaveValidSizes(t *testing.T) {
+	for name, palette := range Presets {
+		if n := len(palette.Colors); n != 2 && n != 4 && n != 16 {
+			t.Errorf("Palette %s has %d colours", name, n)
+		}
+	}
+}
+
+func TestParseForegroundBackground(t *testing.T) {
+	palette, err := Parse("#FFB000,#000000")
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+
+	if palette.Foreground() != (color.RGBA{R: 0xFF, G: 0xB0, A: 0xFF}) {
+		t.Errorf("Foreground was not parsed correctly, got %v", palette.Foreground())
+	}
+	if palette.Background() != (color.RGBA{A: 0xFF}) {
+		t.Errorf("Background was not parsed correctly, got %v", palette.Background())
+	}
+}
+
+func TestParseExtraColours(t *testing.T) {
+	palette, err := Parse("FFFFFF,000000,FF0000,00FF00")
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+
+	if palette.Color(3) != (color.RGBA{G: 0xFF, A: 0xFF}) {
+		t.Errorf("Colour 3 was not parsed correctly, got %v", palette.Color(3))
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	for _, value := range []string{"nope", "#FFFFFF", "#FFFFFF,#GGGGGG", "#FFF,#000"} {
+		if _, err := Parse(value); err == nil {
+			t.Errorf("Expected an error for %q", value)
+		}
+	}
+}
+
+func TestColorFallsBackToForeground(t *testing.T) {
+	palette := Presets["classic"]
+
+	if palette.Color(3) != palette.Foreground() {
+		t.Errorf("Colour 3 should fall back to the foreground, got %v", palette.Color(3))
+	}
+}
+
+func TestMix(t *testing.T) {
+	mixed := Mix(color.RGBA{A: 0xFF}, color.RGBA{R: 200, G: 100, B: 0, A: 0xFF}, 0.5)
+
+	if mixed != (color.RGBA{R: 100, G: 50, B: 0, A: 0xFF}) {
+		t.Errorf("Colours were not mixed correctly, got %v", mixed)
+	}
+}
+
+func TestLoadUserPalettes(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "palettes.json")
+	ioutil.WriteFile(path, []byte(`{"mine": ["#101010", "#F0F0F0"]}`), 0644)
+
+	if err := Load(path); err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	defer delete(user.palettes, "mine")
+
+	palette, err := Parse("mine")
+	if err != nil {
+		t.Fatalf("User palette was not added: %v", err)
+	}
+	if palette.Background() != (color.RGBA{R: 0x10, G: 0x10, B: 0x10, A: 0xFF}) {
+		t.Errorf("Background was not loaded correctly, got %v", palette.Background())
+	}
+}
+
+func TestLoadRejectsBuiltInNames(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "palettes.json")
+	ioutil.WriteFile(path, []byte(`{"classic": ["#101010", "#F0F0F0"], "other": ["#000000", "#FFFFFF"]}`), 0644)
+
+	if err := Load(path); err == nil {
+		t.Error("Expected an error for a palette named like a preset")
+	}
+	if Presets["classic"].Background() != (color.RGBA{A: 0xFF}) {
+		t.Errorf("Preset was replaced, got %v", Presets["classic"].Background())
+	}
+	if _, ok := Lookup("other"); ok {
+		t.Error("Palettes were added from a file with an error")
+	}
+}
@@ -0,0 +1,226 @@
+package patch
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+)
+
+const bpsHeader = "BPS1"
+
+// The footer is the CRC-32 of the source, the target and the rest of the patch
+const bpsFooterSize = 12
+
+// Actions, in the low two bits of each command
+const (
+	bpsSourceRead = iota // Copy from the source at the same offset as the output
+	bpsTargetRead        // Copy from the patch
+	bpsSourceCopy        // Copy from anywhere in the source
+	bpsTargetCopy        // Copy from earlier in the output
+)
+
+var errBPSTruncated = errors.New("BPS patch is truncated")
+
+// bpsReader reads the numbers and bytes of a patch, stopping before the footer
+type bpsReader struct {
+	data []uint8
+	pos  int
+}
+
+// number reads a variable length number, which stores 7 bits per byte with the last byte flagged by the top bit
+func (r *bpsReader) number() (uint64, error) {
+	value, shift := uint64(0), uint64(1)
+	for {
+		if r.pos >= len(r.data) || shift > 1<<56 {
+			return 0, errBPSTruncated
+		}
+		b := r.data[r.pos]
+		r.pos++
+
+		value += uint64(b&0x7F) * shift
+		if b&0x80 != 0 {
+			return value, nil
+		}
+		shift <<= 7
+		value += shift
+	}
+}
+
+func (r *bpsReader) bytes(n uint64) ([]uint8, error) {
+	if n > uint64(len(r.data)-r.pos) {
+		return nil, errBPSTruncated
+	}
+
+	b := r.data[r.pos : r.pos+int(n)]
+	r.pos += int(n)
+	return b, nil
+}
+
+// signed reads a relative offset, which has its sign in the lowest bit
+func (r *bpsReader) signed() (int, error) {
+	n, err := r.number()
+	if n&1 != 0 {
+		return -int(n >> 1), err
+	}
+
+	return int(n >> 1), err
+}
+
+// ApplyBPS applies a BPS patch after checking it is intact and was made for this ROM, then checks the result is the ROM the patch
+// was made to produce.
+func ApplyBPS(rom []uint8, patch []uint8) ([]uint8, error) {
+	if !bytes.HasPrefix(patch, []uint8(bpsHeader)) {
+		return nil, errors.New("Missing BPS header")
+	}
+	if len(patch) < len(bpsHeader)+bpsFooterSize {
+		return nil, errBPSTruncated
+	}
+
+	footer := patch[len(patch)-bpsFooterSize:]
+	sourceCRC := binary.LittleEndian.Uint32(footer[0:])
+	targetCRC := binary.LittleEndian.Uint32(footer[4:])
+	patchCRC := binary.LittleEndian.Uint32(footer[8:])
+	if crc32.ChecksumIEEE(patch[:len(patch)-4]) != patchCRC {
+		return nil, errors.New("BPS patch is corrupt")
+	}
+	if crc32.ChecksumIEEE(rom) != sourceCRC {
+		return nil, errors.New("BPS patch was made for a different ROM")
+	}
+
+	r := bpsReader{data: patch[:len(patch)-bpsFooterSize], pos: len(bpsHeader)}
+	sourceSize, err := r.number()
+	if err != nil {
+		return nil, err
+	}
+	targetSize, err := r.number()
+	if err != nil {
+		return nil, err
+	}
+	metadataSize, err := r.number()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := r.bytes(metadataSize); err != nil {
+		return nil, err
+	}
+	if sourceSize != uint64(len(rom)) {
+		return nil, errors.New("BPS patch was made for a different ROM")
+	}
+	if targetSize > maxSize {
+		return nil, errors.New("BPS patch makes a ROM that is too large")
+	}
+
+	output := make([]uint8, 0, targetSize)
+	sourceOffset, targetOffset := 0, 0
+
+	for r.pos < len(r.data) {
+		command, err := r.number()
+		if err != nil {
+			return nil, err
+		}
+		if command>>2 >= targetSize-uint64(len(output)) {
+			return nil, errors.New("BPS patch writes past the end of the ROM")
+		}
+		length := int(command>>2) + 1
+
+		switch command & 3 {
+		case bpsSourceRead:
+			if len(output)+length > len(rom) {
+				return nil, errors.New("BPS patch reads past the end of the ROM")
+			}
+			output = append(output, rom[len(output):len(output)+length]...)
+		case bpsTargetRead:
+			data, err := r.bytes(uint64(length))
+			if err != nil {
+				return nil, err
+			}
+			output = append(output, data...)
+		case bpsSourceCopy:
+			offset, err := r.signed()
+			if err != nil {
+				return nil, err
+			}
+			sourceOffset += offset
+			if sourceOffset < 0 || sourceOffset+length > len(rom) {
+				return nil, errors.New("BPS patch reads past the end of the ROM")
+			}
+			output = append(output, rom[sourceOffset:sourceOffset+length]...)
+			sourceOffset += length
+		case bpsTargetCopy:
+			offset, err := r.signed()
+			if err != nil {
+				return nil, err
+			}
+			targetOffset += offset
+			if targetOffset < 0 || targetOffset >= len(output) {
+				return nil, errors.New("BPS patch copies from outside the ROM")
+			}
+			// Copied a byte at a time as the run can overlap the bytes it is writing
+			for i := 0; i < length; i++ {
+				output = append(output, output[targetOffset])
+				targetOffset++
+			}
+		}
+	}
+
+	if uint64(len(output)) != targetSize {
+		return nil, errors.New("BPS patch is truncated")
+	}
+	if crc32.ChecksumIEEE(output) != targetCRC {
+		return nil, errors.New("BPS patch produced the wrong ROM")
+	}
+
+	return output, nil
+}
+
+// appendNumber writes a variable length number as read by bpsReader.number
+func appendNumber(b []uint8, n uint64) []uint8 {
+	for {
+		x := uint8(n & 0x7F)
+		n >>= 7
+		if n == 0 {
+			return append(b, 0x80|x)
+		}
+		b = append(b, x)
+		n--
+	}
+}
+
+// CreateBPS makes a BPS patch that reads the bytes the ROMs share from the source and the rest from the patch
+func CreateBPS(source []uint8, target []uint8) []uint8 {
+	patch := []uint8(bpsHeader)
+	patch = appendNumber(patch, uint64(len(source)))
+	patch = appendNumber(patch, uint64(len(target)))
+	patch = appendNumber(patch, 0)
+
+	same := func(i int) bool {
+		return i < len(source) && source[i] == target[i]
+	}
+
+	for i := 0; i < len(target); {
+		end := i
+		for end < len(target) && same(end) == same(i) {
+			end++
+		}
+
+		if same(i) {
+			patch = appendNumber(patch, uint64(end-i-1)<<2|bpsSourceRead)
+		} else {
+			patch = appendNumber(patch, uint64(end-i-1)<<2|bpsTargetRead)
+			patch = append(patch, target[i:end]...)
+		}
+		i = end
+	}
+
+	patch = appendCRC(patch, source)
+	patch = appendCRC(patch, target)
+	return appendCRC(patch, patch)
+}
+
+func appendCRC(b []uint8, data []uint8) []uint8 {
+	crc := make([]uint8, 4)
+	binary.LittleEndian.PutUint32(crc, crc32.ChecksumIEEE(data))
+
+	return append(b, crc...)
+}
@@ -0,0 +1,112 @@
+package patch
+
+import (
+	"bytes"
+	"errors"
+)
+
+const (
+	ipsHeader = "PATCH"
+	ipsFooter = "EOF"
+	// Records can't start at this offset, as it reads as the footer
+	ipsFooterOffset = 0x454F46
+	ipsMaxRecord    = 0xFFFF
+)
+
+var errIPSTruncated = errors.New("IPS patch is truncated")
+
+// ApplyIPS applies an IPS patch. Records past the end of the ROM make it longer, and the optional truncation after the footer makes it
+// shorter.
+func ApplyIPS(rom []uint8, patch []uint8) ([]uint8, error) {
+	if !bytes.HasPrefix(patch, []uint8(ipsHeader)) {
+		return nil, errors.New("Missing IPS header")
+	}
+
+	output := append([]uint8{}, rom...)
+	p := patch[len(ipsHeader):]
+
+	for {
+		if len(p) < 3 {
+			return nil, errIPSTruncated
+		}
+		if string(p[:3]) == ipsFooter {
+			p = p[3:]
+			break
+		}
+		if len(p) < 5 {
+			return nil, errIPSTruncated
+		}
+
+		offset := int(p[0])<<16 | int(p[1])<<8 | int(p[2])
+		size := int(p[3])<<8 | int(p[4])
+		p = p[5:]
+
+		var data []uint8
+		if size > 0 {
+			if len(p) < size {
+				return nil, errIPSTruncated
+			}
+			data, p = p[:size], p[size:]
+		} else {
+			// A run of the same byte
+			if len(p) < 3 {
+				return nil, errIPSTruncated
+			}
+			data = bytes.Repeat([]uint8{p[2]}, int(p[0])<<8|int(p[1]))
+			p = p[3:]
+		}
+
+		if end := offset + len(data); end > len(output) {
+			output = append(output, make([]uint8, end-len(output))...)
+		}
+		copy(output[offset:], data)
+	}
+
+	switch len(p) {
+	case 0:
+	case 3:
+		if length := int(p[0])<<16 | int(p[1])<<8 | int(p[2]); length < len(output) {
+			output = output[:length]
+		}
+	default:
+		return nil, errors.New("Unexpected data after the IPS footer")
+	}
+
+	return output, nil
+}
+
+// CreateIPS makes an IPS patch with a record for each run of bytes that differs
+func CreateIPS(source []uint8, target []uint8) ([]uint8, error) {
+	if len(target) > maxSize {
+		return nil, errors.New("ROM is too large for an IPS patch")
+	}
+
+	patch := []uint8(ipsHeader)
+	for i := 0; i < len(target); {
+		if i < len(source) && source[i] == target[i] {
+			i++
+			continue
+		}
+
+		start := i
+		if start == ipsFooterOffset {
+			// Rewrite the byte before so the record doesn't look like the footer
+			start--
+		}
+		end := i
+		for end < len(target) && end-start < ipsMaxRecord && (end >= len(source) || source[end] != target[end]) {
+			end++
+		}
+
+		patch = append(patch, uint8(start>>16), uint8(start>>8), uint8(start), uint8((end-start)>>8), uint8(end-start))
+		patch = append(patch, target[start:end]...)
+		i = end
+	}
+	patch = append(patch, ipsFooter...)
+
+	if len(target) < len(source) {
+		patch = append(patch, uint8(len(target)>>16), uint8(len(target)>>8), uint8(len(target)))
+	}
+
+	return patch, nil
+}
@@ -0,0 +1,101 @@
+// Package patch applies and creates the IPS and BPS patches that fan translations and bug fixes are shared as. IPS is the older,
+// simpler format: runs of bytes to write at offsets. BPS also carries CRC-32 checksums of the original ROM, the patched ROM and the
+// patch itself, so a patch made for a different version of a ROM is caught instead of producing garbage.
+package patch
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"strings"
+)
+
+// Format is a kind of patch file
+type Format int
+
+const (
+	FormatIPS Format = iota
+	FormatBPS
+)
+
+var formatNames = []string{"ips", "bps"}
+
+func (f Format) String() string {
+	if f >= 0 && int(f) < len(formatNames) {
+		return formatNames[f]
+	}
+
+	return "unknown"
+}
+
+// ParseFormat looks up a format by name
+func ParseFormat(name string) (Format, error) {
+	for i, n := range formatNames {
+		if strings.EqualFold(n, name) {
+			return Format(i), nil
+		}
+	}
+
+	return 0, errors.New("Unknown patch format " + name)
+}
+
+// maxSize is the largest ROM a patch can make. Far larger than any CHIP-8 ROM, but it stops a corrupt patch using up all memory.
+const maxSize = 0xFFFFFF
+
+// ErrUnknownFormat is returned for data that isn't an IPS or BPS patch
+var ErrUnknownFormat = errors.New("Not an IPS or BPS patch")
+
+// Detect works out the format of a patch from its header
+func Detect(patch []uint8) (Format, error) {
+	switch {
+	case bytes.HasPrefix(patch, []uint8(ipsHeader)):
+		return FormatIPS, nil
+	case bytes.HasPrefix(patch, []uint8(bpsHeader)):
+		return FormatBPS, nil
+	}
+
+	return 0, ErrUnknownFormat
+}
+
+// Apply patches a ROM, detecting the format of the patch. The ROM passed in is left as it is.
+// Will return an error if the patch is corrupt or, for BPS, was made for a different ROM.
+func Apply(rom []uint8, patch []uint8) ([]uint8, error) {
+	format, err := Detect(patch)
+	if err != nil {
+		return nil, err
+	}
+
+	if format == FormatBPS {
+		return ApplyBPS(rom, patch)
+	}
+
+	return ApplyIPS(rom, patch)
+}
+
+// ApplyFiles patches a ROM with each patch file in turn
+func ApplyFiles(rom []uint8, paths ...string) ([]uint8, error) {
+	for _, path := range paths {
+		patch, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+
+		if rom, err = Apply(rom, patch); err != nil {
+			return nil, errors.New(path + ": " + err.Error())
+		}
+	}
+
+	return rom, nil
+}
+
+// Create makes a patch that turns one ROM into another
+func Create(format Format, source []uint8, target []uint8) ([]uint8, error) {
+	switch format {
+	case FormatIPS:
+		return CreateIPS(source, target)
+	case FormatBPS:
+		return CreateBPS(source, target), nil
+	}
+
+	return nil, errors.New("Unknown patch format")
+}
@@ -0,0 +1,99 @@
+package patch
+
+import (
+	"bytes"
+	"testing"
+)
+
+var (
+	original = []uint8{0x60, 0x01, 0x61, 0x02, 0xA2, 0x0A, 0xD0, 0x15, 0x12, 0x08}
+	fixed    = []uint8{0x60, 0x05, 0x61, 0x02, 0xA2, 0x0A, 0xD0, 0x16, 0x12, 0x08, 0xF0, 0x90}
+)
+
+func TestRoundTrip(t *testing.T) {
+	for _, format := range []Format{FormatIPS, FormatBPS} {
+		for _, pair := range [][2][]uint8{{original, fixed}, {fixed, original}, {original, original}} {
+			patch, err := Create(format, pair[0], pair[1])
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			patched, err := Apply(pair[0], patch)
+			if err != nil {
+				t.Fatalf("%s: %v", format, err)
+			}
+			if !bytes.Equal(patched, pair[1]) {
+				t.Errorf("%s patch was not applied correctly. Expected % X, got % X", format, pair[1], patched)
+			}
+		}
+	}
+}
+
+func TestIPSRunAndTruncate(t *testing.T) {
+	patch := []uint8("PATCH")
+	patch = append(patch, 0x00, 0x00, 0x02, 0x00, 0x00, 0x00, 0x03, 0xEE) // Three 0xEE from offset 2
+	patch = append(patch, "EOF"...)
+	patch = append(patch, 0x00, 0x00, 0x04) // Truncate to 4 bytes
+
+	patched, err := ApplyIPS(original, patch)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []uint8{0x60, 0x01, 0xEE, 0xEE}
+	if !bytes.Equal(patched, expected) {
+		t.Errorf("IPS patch was not applied correctly. Expected % X, got % X", expected, patched)
+	}
+	if original[2] != 0x61 {
+		t.Errorf("Applying a patch changed the original ROM")
+	}
+}
+
+func TestBPSCopies(t *testing.T) {
+	source := []uint8("ABCD")
+	target := []uint8("CDABABABX")
+
+	patch := []uint8(bpsHeader)
+	patch = appendNumber(patch, uint64(len(source)))
+	patch = appendNumber(patch, uint64(len(target)))
+	patch = appendNumber(patch, 0)
+	patch = appendNumber(patch, 1<<2|bpsSourceCopy) // CD
+	patch = appendNumber(patch, 2<<1)
+	patch = appendNumber(patch, 1<<2|bpsSourceCopy) // AB
+	patch = appendNumber(patch, 4<<1|1)
+	patch = appendNumber(patch, 3<<2|bpsTargetCopy) // ABAB, overlapping what it writes
+	patch = appendNumber(patch, 2<<1)
+	patch = appendNumber(patch, 0<<2|bpsTargetRead) // X
+	patch = append(patch, 'X')
+	patch = appendCRC(patch, source)
+	patch = appendCRC(patch, target)
+	patch = appendCRC(patch, patch)
+
+	patched, err := ApplyBPS(source, patch)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(patched, target) {
+		t.Errorf("BPS patch was not applied correctly. Expected %q, got %q", target, patched)
+	}
+}
+
+func TestBPSChecksums(t *testing.T) {
+	patch := CreateBPS(original, fixed)
+
+	if _, err := ApplyBPS(fixed, patch); err == nil {
+		t.Errorf("Expected an error applying a patch to the wrong ROM")
+	}
+
+	corrupt := append([]uint8{}, patch...)
+	corrupt[len(bpsHeader)+4] ^= 0xFF
+	if _, err := ApplyBPS(original, corrupt); err == nil {
+		t.Errorf("Expected an error applying a corrupt patch")
+	}
+}
+
+func TestDetect(t *testing.T) {
+	if _, err := Apply(original, []uint8("not a patch")); err != ErrUnknownFormat {
+		t.Errorf("Expected ErrUnknownFormat, got %v", err)
+	}
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"chip8/patch"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+)
+
+// patchList collects the files given to a repeated -patch flag
+type patchList []string
+
+func (pl *patchList) String() string {
+	return strings.Join(*pl, ",")
+}
+
+func (pl *patchList) Set(path string) error {
+	*pl = append(*pl, path)
+	return nil
+}
+
+func addPatchFlag(fs *flag.FlagSet) *patchList {
+	patches := &patchList{}
+	fs.Var(patches, "patch", "apply an IPS or BPS patch to the ROM as it is loaded; can be given more than once")
+
+	return patches
+}
+
+func patchCommand(args []string) int {
+	fs := newFlagSet("patch")
+	format := fs.String("format", "bps", "patch format (ips, bps); BPS patches check they are applied to the right ROM")
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Usage: chip8 patch [flags] <original rom> <modified rom> <patch file>")
+		fmt.Fprintln(os.Stderr, "Creates a patch that turns the original ROM into the modified one, for 'chip8 run -patch'.")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return usageExitCode(err)
+	}
+	if fs.NArg() != 3 {
+		fs.Usage()
+		return exitUsage
+	}
+
+	patchFormat, err := patch.ParseFormat(*format)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitUsage
+	}
+
+	original, err := ioutil.ReadFile(fs.Arg(0))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Could not load ROM:", err)
+		return exitROM
+	}
+	modified, err := ioutil.ReadFile(fs.Arg(1))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Could not load ROM:", err)
+		return exitROM
+	}
+
+	data, err := patch.Create(patchFormat, original, modified)
+	if err == nil {
+		err = ioutil.WriteFile(fs.Arg(2), data, 0644)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Could not write patch:", err)
+		return exitError
+	}
+
+	return exitOK
+}
@@ -0,0 +1,43 @@
+package pixeldisplay
+
+import (
+	"chip8/keymap"
+	"errors"
+	"strings"
+
+	"github.com/faiface/pixel/pixelgl"
+)
+
+// ButtonByName finds a pixelgl button from its name (as returned by Button.String), e.g. "Q", "Space" or "Up". Case insensitive.
+func ButtonByName(name string) (pixelgl.Button, error) {
+	for b := pixelgl.Button(0); b <= pixelgl.KeyLast; b++ {
+		if strings.EqualFold(b.String(), name) {
+			return b, nil
+		}
+	}
+
+	return pixelgl.KeyUnknown, errors.New("Unknown key " + name)
+}
+
+// buttons converts a keymap from key names into pixelgl buttons
+func buttons(keys keymap.Keymap) (map[uint8][]pixelgl.Button, error) {
+	result := map[uint8][]pixelgl.Button{}
+
+	for key, names := range keys {
+		for _, name := range names {
+			button, err := ButtonByName(name)
+			if err != nil {
+				return nil, err
+			}
+			result[key] = append(result[key], button)
+		}
+	}
+
+	return result, nil
+}
+
+// ValidateKeymap checks every key name in a keymap is one pixel knows about. Useful for reporting errors before a window is opened.
+func ValidateKeymap(keys keymap.Keymap) error {
+	_, err := buttons(keys)
+	return err
+}
@@ -0,0 +1,128 @@
+package pixeldisplay
+
+import (
+	"image"
+	"image/color"
+
+	"github.com/faiface/pixel"
+	"github.com/faiface/pixel/imdraw"
+	"github.com/faiface/pixel/text"
+	"golang.org/x/image/font/basicfont"
+)
+
+// LauncherItem is a ROM as it's shown in the launcher list
+type LauncherItem struct {
+	Title     string
+	Details   string      // Smaller print under the title, such as the size and platform
+	Thumbnail *image.RGBA // Drawn at its own size, so 64x32 fits a row
+}
+
+// Launcher layout in window pixels
+const (
+	launcherRowHeight = 40
+	launcherMargin    = 8
+	launcherFooter    = 20
+)
+
+var (
+	launcherBackground = color.RGBA{0x10, 0x10, 0x18, 0xFF}
+	launcherHighlight  = color.RGBA{0x30, 0x38, 0x58, 0xFF}
+	launcherTitle      = color.RGBA{0xFF, 0xFF, 0xFF, 0xFF}
+	launcherDetails    = color.RGBA{0xA0, 0xA0, 0xB0, 0xFF}
+)
+
+// launcher holds what's needed to draw the launcher; made the first time it's shown
+type launcher struct {
+	text    *text.Text
+	shapes  *imdraw.IMDraw
+	sprites map[*image.RGBA]*pixel.Sprite
+}
+
+func newLauncher() *launcher {
+	return &launcher{
+		text:    text.New(pixel.ZV, text.NewAtlas(basicfont.Face7x13, text.ASCII)),
+		shapes:  imdraw.New(nil),
+		sprites: map[*image.RGBA]*pixel.Sprite{},
+	}
+}
+
+// write adds a line of text with its baseline starting at pos
+func (l *launcher) write(pos pixel.Vec, c color.Color, s string) {
+	l.text.Dot = pos
+	l.text.Color = c
+	l.text.WriteString(s)
+}
+
+// sprite turns a thumbnail into something that can be drawn, keeping it for the next frame
+func (l *launcher) sprite(img *image.RGBA, used map[*image.RGBA]*pixel.Sprite) *pixel.Sprite {
+	sprite, ok := l.sprites[img]
+	if !ok {
+		pic := pixel.PictureDataFromImage(img)
+		sprite = pixel.NewSprite(pic, pic.Bounds())
+	}
+
+	used[img] = sprite
+	return sprite
+}
+
+// DrawLauncher shows a list of ROMs with the selected one highlighted and scrolled into view, and a line of help at the bottom.
+// Call it once a frame instead of Update while the launcher is showing.
+func (pd *PixelDisplay) DrawLauncher(items []LauncherItem, selected int, footer string) {
+	if pd.launcher == nil {
+		pd.launcher = newLauncher()
+	}
+	l := pd.launcher
+	bounds := pd.win.Bounds()
+
+	visible := int((bounds.H() - launcherFooter) / launcherRowHeight)
+	if visible < 1 {
+		visible = 1
+	}
+	first := 0
+	if selected >= visible {
+		first = selected - visible + 1
+	}
+
+	pd.win.Clear(launcherBackground)
+	l.shapes.Clear()
+	l.text.Clear()
+
+	if len(items) == 0 {
+		l.write(pixel.V(launcherMargin, bounds.H()-2*launcherMargin), launcherTitle, "No ROMs found")
+	}
+
+	// Only keep the sprites still in use, so thumbnails that were replaced don't pile up
+	used := map[*image.RGBA]*pixel.Sprite{}
+	thumbnails := []func(){}
+	for i := first; i < len(items) && i < first+visible; i++ {
+		top := bounds.H() - float64((i-first)*launcherRowHeight)
+		if i == selected {
+			l.shapes.Color = launcherHighlight
+			l.shapes.Push(pixel.V(0, top-launcherRowHeight), pixel.V(bounds.W(), top))
+			l.shapes.Rectangle(0)
+		}
+
+		textX := float64(launcherMargin)
+		if items[i].Thumbnail != nil {
+			size := items[i].Thumbnail.Bounds()
+			center := pixel.V(launcherMargin+float64(size.Dx())/2, top-launcherRowHeight/2)
+			sprite := l.sprite(items[i].Thumbnail, used)
+			thumbnails = append(thumbnails, func() { sprite.Draw(pd.win, pixel.IM.Moved(center)) })
+			textX += float64(size.Dx() + launcherMargin)
+		}
+
+		l.write(pixel.V(textX, top-16), launcherTitle, items[i].Title)
+		l.write(pixel.V(textX, top-32), launcherDetails, items[i].Details)
+	}
+	l.sprites = used
+
+	l.write(pixel.V(launcherMargin, 6), launcherDetails, footer)
+
+	// Highlight first so the thumbnails and text go on top of it
+	l.shapes.Draw(pd.win)
+	for _, draw := range thumbnails {
+		draw()
+	}
+	l.text.Draw(pd.win, pixel.IM)
+	pd.win.Update()
+}
@@ -1,40 +1,25 @@
 package pixeldisplay
 
 import (
+	"chip8/blend"
+	"chip8/keymap"
+	"chip8/palette"
+	"chip8/render"
+	"image"
+	"math"
+
 	"github.com/faiface/pixel"
-	"github.com/faiface/pixel/imdraw"
 	"github.com/faiface/pixel/pixelgl"
-	"golang.org/x/image/colornames"
 )
 
 type PixelDisplay struct {
-	scale float64
-	win   *pixelgl.Window
-	imd   *imdraw.IMDraw
-}
-
-// Keypad:
-// 1 2 3 4
-// Q W E R
-// A S D F
-// Z X C V
-var Keys = map[uint8]pixelgl.Button{
-	0x1: pixelgl.Key1,
-	0x2: pixelgl.Key2,
-	0x3: pixelgl.Key3,
-	0xC: pixelgl.Key4,
-	0x4: pixelgl.KeyQ,
-	0x5: pixelgl.KeyW,
-	0x6: pixelgl.KeyE,
-	0xD: pixelgl.KeyR,
-	0x7: pixelgl.KeyA,
-	0x8: pixelgl.KeyS,
-	0x9: pixelgl.KeyD,
-	0xE: pixelgl.KeyF,
-	0xA: pixelgl.KeyZ,
-	0x0: pixelgl.KeyX,
-	0xB: pixelgl.KeyC,
-	0xF: pixelgl.KeyV,
+	scale    float64
+	win      *pixelgl.Window
+	canvas   *pixelgl.Canvas
+	renderer *render.Renderer
+	pixels   []uint8 // Rendered image flipped to the bottom up row order OpenGL expects
+	keys     map[uint8][]pixelgl.Button
+	launcher *launcher
 }
 
 func New(scale float64) *PixelDisplay {
@@ -48,44 +33,105 @@ func New(scale float64) *PixelDisplay {
 		panic(err)
 	}
 
-	imd := imdraw.New(nil)
+	// Render at the nearest whole scale and let the canvas stretch it to fit the window
+	renderScale := int(math.Ceil(scale))
+	if renderScale < 1 {
+		renderScale = 1
+	}
+	renderer := render.New(render.Options{Scale: renderScale, Palette: palette.Presets["classic"]})
+	canvas := pixelgl.NewCanvas(pixel.R(0, 0, float64(64*renderScale), float64(32*renderScale)))
+
+	// The built-in layouts only use valid names
+	keys, _ := buttons(keymap.Default())
 
 	result := PixelDisplay{
-		scale: scale,
-		win:   win,
-		imd:   imd,
+		scale:    scale,
+		win:      win,
+		canvas:   canvas,
+		renderer: renderer,
+		keys:     keys,
 	}
 	return &result
 }
 
+// SetPalette changes the colours used for drawing. Can be changed at any time; the whole screen is redrawn on the next update.
+func (pd *PixelDisplay) SetPalette(p palette.Palette) {
+	pd.renderer.SetPalette(p)
+}
+
+// SetBlend changes how frames are combined to reduce flicker. frames is how long pixels take to fade in phosphor mode.
+func (pd *PixelDisplay) SetBlend(mode blend.Mode, frames int) {
+	pd.renderer.SetBlend(mode, frames)
+}
+
+// SetStyle changes how pixels are drawn. Can be changed at any time; the whole screen is redrawn on the next update.
+func (pd *PixelDisplay) SetStyle(style render.Style) {
+	pd.renderer.SetStyle(style)
+}
+
+// SetScanlines turns the CRT scanline effect on or off
+func (pd *PixelDisplay) SetScanlines(scanlines bool) {
+	pd.renderer.SetScanlines(scanlines)
+}
+
+// SetTitle changes the window title
+func (pd *PixelDisplay) SetTitle(title string) {
+	pd.win.SetTitle(title)
+}
+
 func (pd *PixelDisplay) Closed() bool {
 	return pd.win.Closed()
 }
 
 func (pd *PixelDisplay) Update(pixels *[64][32]uint8, dirty *[64][32]bool) {
-	// Clear our image drawer each time. Note: Don't clear the screen. This means we're only drawing the changes each time rather than
-	// redrawing everything.
-	pd.imd.Clear()
-
-	for x, px := range *dirty {
-		for y, isDirty := range px {
-			// If this pixel has changed redraw it
-			if isDirty {
-				if pixels[x][y] > 0 {
-					pd.imd.Color = colornames.White
-				} else {
-					pd.imd.Color = colornames.Black
-				}
-				pd.imd.Push(pixel.V(float64(x)*pd.scale, float64(31-y)*pd.scale), pixel.V(float64(x+1)*pd.scale, float64(31-y+1)*pd.scale))
-				pd.imd.Rectangle(0)
-			}
-		}
-	}
+	// The renderer only redraws the pixels that changed; the whole image is uploaded each frame as it's tiny
+	img := pd.renderer.Render(pixels, dirty)
+	pd.canvas.SetPixels(pd.flip(img))
 
-	pd.imd.Draw(pd.win)
+	bounds := pd.canvas.Bounds()
+	pd.canvas.Draw(pd.win, pixel.IM.Scaled(pixel.ZV, pd.win.Bounds().W()/bounds.W()).Moved(pd.win.Bounds().Center()))
 	pd.win.Update()
 }
 
+// flip reverses the row order of an image
+func (pd *PixelDisplay) flip(img *image.RGBA) []uint8 {
+	if len(pd.pixels) != len(img.Pix) {
+		pd.pixels = make([]uint8, len(img.Pix))
+	}
+
+	height := img.Bounds().Dy()
+	for y := 0; y < height; y++ {
+		row := img.Pix[y*img.Stride : (y+1)*img.Stride]
+		copy(pd.pixels[(height-1-y)*img.Stride:], row)
+	}
+
+	return pd.pixels
+}
+
+// KeyDown is true if any of the keyboard keys bound to a chip8 key are held
 func (pd *PixelDisplay) KeyDown(key uint8) bool {
-	return pd.win.Pressed(Keys[key])
+	for _, button := range pd.keys[key] {
+		if pd.win.Pressed(button) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// SetKeymap changes which keyboard keys press each chip8 key.
+// Will return an error if the keymap uses a key name pixel doesn't know about; the current keymap is kept in that case.
+func (pd *PixelDisplay) SetKeymap(keys keymap.Keymap) error {
+	result, err := buttons(keys)
+	if err != nil {
+		return err
+	}
+
+	pd.keys = result
+	return nil
+}
+
+// JustPressed reports whether a key on the host keyboard was pressed since the last update; used for frontend hotkeys like pausing
+func (pd *PixelDisplay) JustPressed(button pixelgl.Button) bool {
+	return pd.win.JustPressed(button)
 }
@@ -0,0 +1,233 @@
+package profile
+
+import (
+	"compress/gzip"
+	"io"
+	"sort"
+)
+
+// Field numbers from pprof's profile.proto
+const (
+	profileSampleType  = 1
+	profileSample      = 2
+	profileMapping     = 3
+	profileLocation    = 4
+	profileFunction    = 5
+	profileStringTable = 6
+	profilePeriodType  = 11
+	profilePeriod      = 12
+
+	valueTypeType = 1
+	valueTypeUnit = 2
+
+	sampleLocationID = 1
+	sampleValue      = 2
+
+	mappingID          = 1
+	mappingMemoryStart = 2
+	mappingMemoryLimit = 3
+	mappingFilename    = 5
+	mappingHasFunction = 7
+
+	locationID        = 1
+	locationMappingID = 2
+	locationAddress   = 3
+	locationLine      = 4
+
+	lineFunctionID = 1
+	lineLine       = 2
+
+	functionID         = 1
+	functionName       = 2
+	functionSystemName = 3
+	functionFilename   = 4
+	functionStartLine  = 5
+)
+
+// WritePprof writes a gzipped pprof profile, which go tool pprof can show. Each sample is a call stack with the number of
+// instructions executed in it. Functions are the subroutines, named from the symbol table, and each location keeps its address and
+// source line so pprof can list by either.
+func (p *Profiler) WritePprof(w io.Writer, rom string) error {
+	names := stringTable{indexes: map[string]int64{}}
+	names.add("")
+
+	profile := protoBuffer{}
+	valueType := protoBuffer{}
+	valueType.int64Field(valueTypeType, names.add("instructions"))
+	valueType.int64Field(valueTypeUnit, names.add("count"))
+	profile.bytesField(profileSampleType, valueType.data)
+	profile.bytesField(profilePeriodType, valueType.data)
+	profile.int64Field(profilePeriod, 1)
+
+	mapping := protoBuffer{}
+	mapping.uint64Field(mappingID, 1)
+	mapping.uint64Field(mappingMemoryStart, 0)
+	mapping.uint64Field(mappingMemoryLimit, uint64(len(p.counts)))
+	mapping.int64Field(mappingFilename, names.add(rom))
+	mapping.boolField(mappingHasFunction, true)
+	profile.bytesField(profileMapping, mapping.data)
+
+	// Sort the samples so the output is the same for the same run
+	keys := []stackKey{}
+	for key := range p.samples {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return lessStack(keys[i], keys[j])
+	})
+
+	locations := map[location]uint64{}
+	functions := map[uint16]uint64{}
+	for _, key := range keys {
+		ids := []uint64{}
+		for _, l := range key.locations[:key.depth] {
+			id, ok := locations[l]
+			if !ok {
+				id = uint64(len(locations) + 1)
+				locations[l] = id
+				profile.bytesField(profileLocation, p.encodeLocation(id, l, functions, &names, &profile))
+			}
+			ids = append(ids, id)
+		}
+
+		sample := protoBuffer{}
+		sample.packedField(sampleLocationID, ids)
+		sample.packedField(sampleValue, []uint64{p.samples[key]})
+		profile.bytesField(profileSample, sample.data)
+	}
+
+	for _, s := range names.values {
+		profile.bytesField(profileStringTable, []byte(s))
+	}
+
+	gz := gzip.NewWriter(w)
+	if _, err := gz.Write(profile.data); err != nil {
+		return err
+	}
+
+	return gz.Close()
+}
+
+// encodeLocation encodes a location, writing the function for its subroutine to the profile first if it hasn't been already
+func (p *Profiler) encodeLocation(id uint64, l location, functions map[uint16]uint64, names *stringTable,
+	profile *protoBuffer) []byte {
+	function, ok := functions[l.entry]
+	if !ok {
+		function = uint64(len(functions) + 1)
+		functions[l.entry] = function
+
+		name := names.add(p.symbols.Format(l.entry))
+		f := protoBuffer{}
+		f.uint64Field(functionID, function)
+		f.int64Field(functionName, name)
+		f.int64Field(functionSystemName, name)
+		if source, ok := p.symbols.Line(l.entry); ok {
+			f.int64Field(functionFilename, names.add(source.File))
+			f.int64Field(functionStartLine, int64(source.Line))
+		}
+		profile.bytesField(profileFunction, f.data)
+	}
+
+	line := protoBuffer{}
+	line.uint64Field(lineFunctionID, function)
+	if source, ok := p.symbols.Line(l.address); ok {
+		line.int64Field(lineLine, int64(source.Line))
+	}
+
+	encoded := protoBuffer{}
+	encoded.uint64Field(locationID, id)
+	encoded.uint64Field(locationMappingID, 1)
+	encoded.uint64Field(locationAddress, uint64(l.address))
+	encoded.bytesField(locationLine, line.data)
+
+	return encoded.data
+}
+
+func lessStack(a stackKey, b stackKey) bool {
+	for i := 0; i < a.depth && i < b.depth; i++ {
+		if a.locations[i] != b.locations[i] {
+			if a.locations[i].address != b.locations[i].address {
+				return a.locations[i].address < b.locations[i].address
+			}
+			return a.locations[i].entry < b.locations[i].entry
+		}
+	}
+
+	return a.depth < b.depth
+}
+
+// stringTable collects the strings a profile refers to by index
+type stringTable struct {
+	values  []string
+	indexes map[string]int64
+}
+
+func (st *stringTable) add(s string) int64 {
+	if index, ok := st.indexes[s]; ok {
+		return index
+	}
+
+	index := int64(len(st.values))
+	st.values = append(st.values, s)
+	st.indexes[s] = index
+
+	return index
+}
+
+// protoBuffer encodes the few protocol buffer wire types a profile needs, so pprof output doesn't need a protobuf library
+type protoBuffer struct {
+	data []byte
+}
+
+const (
+	wireVarint = 0
+	wireBytes  = 2
+)
+
+func (pb *protoBuffer) varint(x uint64) {
+	for x >= 0x80 {
+		pb.data = append(pb.data, byte(x)|0x80)
+		x >>= 7
+	}
+	pb.data = append(pb.data, byte(x))
+}
+
+func (pb *protoBuffer) key(field int, wireType int) {
+	pb.varint(uint64(field)<<3 | uint64(wireType))
+}
+
+// uint64Field writes a varint field. Zero is the default so it is left out.
+func (pb *protoBuffer) uint64Field(field int, x uint64) {
+	if x == 0 {
+		return
+	}
+
+	pb.key(field, wireVarint)
+	pb.varint(x)
+}
+
+func (pb *protoBuffer) int64Field(field int, x int64) {
+	pb.uint64Field(field, uint64(x))
+}
+
+func (pb *protoBuffer) boolField(field int, x bool) {
+	if x {
+		pb.uint64Field(field, 1)
+	}
+}
+
+// bytesField writes a length delimited field. Unlike the others it is always written, as repeated strings can be empty.
+func (pb *protoBuffer) bytesField(field int, data []byte) {
+	pb.key(field, wireBytes)
+	pb.varint(uint64(len(data)))
+	pb.data = append(pb.data, data...)
+}
+
+func (pb *protoBuffer) packedField(field int, values []uint64) {
+	packed := protoBuffer{}
+	for _, v := range values {
+		packed.varint(v)
+	}
+
+	pb.bytesField(field, packed.data)
+}
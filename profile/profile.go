@@ -0,0 +1,204 @@
+// Package profile counts where a ROM spends its cycles. Attach a Profiler to a CPU with Chip8.SetTracer and it counts how often each
+// address runs, which commands make up the instruction mix, and how many instructions each subroutine costs, both on its own
+// (exclusive) and including everything it calls (inclusive). Subroutines are found by following the CALL stack, so they don't need
+// symbols, but a symbol table makes the reports and pprof output much easier to read.
+package profile
+
+import (
+	"chip8/chip8"
+	"chip8/symbols"
+	"sort"
+)
+
+// maxDepth is the deepest the stack goes, plus the main routine
+const maxDepth = 17
+
+// frame is a subroutine that is running
+type frame struct {
+	entry    uint16 // First address of the subroutine
+	callSite uint16 // Address of the CALL that started it
+}
+
+// stackKey identifies a call stack, innermost first, for pprof samples. Arrays are comparable so it can be used as a map key.
+type stackKey struct {
+	depth     int
+	locations [maxDepth]location
+}
+
+// location is an address within a subroutine
+type location struct {
+	address uint16
+	entry   uint16
+}
+
+// Subroutine is the cost of a subroutine. The main routine is included as the subroutine the ROM started in.
+type Subroutine struct {
+	Entry     uint16
+	Calls     uint64
+	Exclusive uint64 // Instructions executed in the subroutine itself
+	Inclusive uint64 // Instructions executed in the subroutine and everything it called
+}
+
+// HotSpot is how often the instruction at an address ran
+type HotSpot struct {
+	Address uint16
+	Opcode  uint16
+	Count   uint64
+}
+
+// CommandCount is how often a command ran
+type CommandCount struct {
+	Command chip8.Command
+	Count   uint64
+}
+
+// Profiler is a chip8.Tracer that counts executions
+type Profiler struct {
+	symbols     *symbols.Table
+	total       uint64
+	counts      [chip8.MemorySize]uint64
+	opcodes     [chip8.MemorySize]uint16
+	commands    map[chip8.Command]uint64
+	subroutines map[uint16]*Subroutine
+	samples     map[stackKey]uint64
+	stack       []frame
+	lastPC      uint16
+}
+
+// New creates a profiler. The symbol table names addresses in reports and may be nil.
+func New(table *symbols.Table) *Profiler {
+	return &Profiler{
+		symbols:     table,
+		commands:    map[chip8.Command]uint64{},
+		subroutines: map[uint16]*Subroutine{},
+		samples:     map[stackKey]uint64{},
+	}
+}
+
+// Trace counts an instruction. The stack is kept in step with the CPU's stack depth rather than by decoding CALL and RET, so calls that
+// fail or a debugger changing SP can't leave it out of step.
+func (p *Profiler) Trace(record *chip8.TraceRecord) {
+	depth := int(record.SP) + 1
+	for len(p.stack) > depth {
+		p.stack = p.stack[:len(p.stack)-1]
+	}
+	for len(p.stack) < depth {
+		p.push(record.PC, len(p.stack) > 0 || p.total > 0)
+	}
+
+	p.total++
+	p.counts[record.PC]++
+	p.opcodes[record.PC] = record.Opcode
+	if record.Instruction != nil {
+		p.commands[record.Instruction.Command]++
+	}
+
+	top := len(p.stack) - 1
+	p.subroutines[p.stack[top].entry].Exclusive++
+	for i, f := range p.stack {
+		if !p.onStackBelow(f.entry, i) {
+			p.subroutines[f.entry].Inclusive++
+		}
+	}
+
+	key := stackKey{depth: len(p.stack)}
+	key.locations[0] = location{address: record.PC, entry: p.stack[top].entry}
+	for i := top; i > 0; i-- {
+		key.locations[top-i+1] = location{address: p.stack[i].callSite, entry: p.stack[i-1].entry}
+	}
+	p.samples[key]++
+
+	p.lastPC = record.PC
+}
+
+// push starts a subroutine at entry, counting it as a call if it was reached by one
+func (p *Profiler) push(entry uint16, called bool) {
+	p.stack = append(p.stack, frame{entry: entry, callSite: p.lastPC})
+
+	subroutine, ok := p.subroutines[entry]
+	if !ok {
+		subroutine = &Subroutine{Entry: entry}
+		p.subroutines[entry] = subroutine
+	}
+	if called {
+		subroutine.Calls++
+	}
+}
+
+// onStackBelow reports whether a subroutine is already running further down the stack, so recursion isn't counted twice
+func (p *Profiler) onStackBelow(entry uint16, i int) bool {
+	for _, f := range p.stack[:i] {
+		if f.entry == entry {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Total returns the number of instructions counted
+func (p *Profiler) Total() uint64 {
+	return p.total
+}
+
+// Count returns how often the instruction at an address ran
+func (p *Profiler) Count(address uint16) uint64 {
+	if int(address) >= len(p.counts) {
+		return 0
+	}
+
+	return p.counts[address]
+}
+
+// HotSpots returns the n addresses that ran most often, busiest first. n < 1 returns all of them.
+func (p *Profiler) HotSpots(n int) []HotSpot {
+	spots := []HotSpot{}
+	for address, count := range p.counts {
+		if count > 0 {
+			spots = append(spots, HotSpot{Address: uint16(address), Opcode: p.opcodes[address], Count: count})
+		}
+	}
+
+	sort.SliceStable(spots, func(i, j int) bool {
+		return spots[i].Count > spots[j].Count
+	})
+	if n > 0 && len(spots) > n {
+		spots = spots[:n]
+	}
+
+	return spots
+}
+
+// Subroutines returns the cost of every subroutine that ran, most expensive (inclusive) first
+func (p *Profiler) Subroutines() []Subroutine {
+	subroutines := []Subroutine{}
+	for _, s := range p.subroutines {
+		subroutines = append(subroutines, *s)
+	}
+
+	sort.Slice(subroutines, func(i, j int) bool {
+		if subroutines[i].Inclusive != subroutines[j].Inclusive {
+			return subroutines[i].Inclusive > subroutines[j].Inclusive
+		}
+		return subroutines[i].Entry < subroutines[j].Entry
+	})
+
+	return subroutines
+}
+
+// Mix returns how often each command ran, most common first
+func (p *Profiler) Mix() []CommandCount {
+	mix := []CommandCount{}
+	for command, count := range p.commands {
+		mix = append(mix, CommandCount{Command: command, Count: count})
+	}
+
+	sort.Slice(mix, func(i, j int) bool {
+		if mix[i].Count != mix[j].Count {
+			return mix[i].Count > mix[j].Count
+		}
+		return mix[i].Command < mix[j].Command
+	})
+
+	return mix
+}
@@ -0,0 +1,167 @@
+package profile
+
+import (
+	"bytes"
+	"chip8/chip8"
+	"chip8/symbols"
+	"compress/gzip"
+	"io/ioutil"
+	"strings"
+	"testing"
+)
+
+type display struct{}
+
+func (d *display) Update(*[64][32]uint8, *[64][32]bool) {}
+func (d *display) Closed() bool                         { return false }
+func (d *display) KeyDown(key uint8) bool               { return false }
+
+// main calls outer twice then loops, outer calls inner
+var program = []uint8{
+	0x22, 0x06, // 200: CALL outer
+	0x22, 0x06, // 202: CALL outer
+	0x12, 0x04, // 204: JP 204
+	0x22, 0x0C, // 206: outer: CALL inner
+	0x70, 0x01, // 208: ADD V0, 1
+	0x00, 0xEE, // 20A: RET
+	0x61, 0x02, // 20C: inner: LD V1, 2
+	0x00, 0xEE, // 20E: RET
+}
+
+func testSymbols() *symbols.Table {
+	table := symbols.New()
+	table.AddLabel(0x200, "main")
+	table.AddLabel(0x206, "outer")
+	table.AddLabel(0x20C, "inner")
+
+	return table
+}
+
+// profileProgram runs the program for 14 instructions, ending with main looping twice
+func profileProgram(t *testing.T) *Profiler {
+	computer := chip8.New(&display{})
+	if _, err := computer.LoadFromMemory(program); err != nil {
+		t.Fatal(err)
+	}
+
+	profiler := New(testSymbols())
+	computer.SetTracer(profiler)
+	for i := 0; i < 14; i++ {
+		if err := computer.Tick(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return profiler
+}
+
+func TestCounts(t *testing.T) {
+	profiler := profileProgram(t)
+
+	if profiler.Total() != 14 {
+		t.Errorf("Total was not counted correctly. Expected %d, got %d", 14, profiler.Total())
+	}
+	if profiler.Count(0x200) != 1 || profiler.Count(0x20C) != 2 {
+		t.Errorf("Counts were not set correctly. Expected 1 and 2, got %d and %d", profiler.Count(0x200), profiler.Count(0x20C))
+	}
+
+	spots := profiler.HotSpots(2)
+	if len(spots) != 2 || spots[0].Address != 0x204 || spots[0].Count != 2 || spots[0].Opcode != 0x1204 {
+		t.Errorf("Hot spots were not found correctly. Got %+v", spots)
+	}
+}
+
+func TestSubroutines(t *testing.T) {
+	subroutines := profileProgram(t).Subroutines()
+
+	expected := []Subroutine{
+		{Entry: 0x200, Calls: 0, Exclusive: 4, Inclusive: 14},
+		{Entry: 0x206, Calls: 2, Exclusive: 6, Inclusive: 10},
+		{Entry: 0x20C, Calls: 2, Exclusive: 4, Inclusive: 4},
+	}
+	if len(subroutines) != len(expected) {
+		t.Fatalf("Expected %d subroutines, got %d", len(expected), len(subroutines))
+	}
+	for i := range expected {
+		if subroutines[i] != expected[i] {
+			t.Errorf("Subroutine was not counted correctly. Expected %+v, got %+v", expected[i], subroutines[i])
+		}
+	}
+}
+
+func TestRecursionCountedOnce(t *testing.T) {
+	// 200: CALL 202; 202: CALL 202 (recurses until the stack overflows)
+	computer := chip8.New(&display{})
+	computer.LoadFromMemory([]uint8{0x22, 0x02, 0x22, 0x02})
+	profiler := New(nil)
+	computer.SetTracer(profiler)
+	for i := 0; i < 5; i++ {
+		computer.Tick()
+	}
+
+	subroutine := profiler.Subroutines()[0]
+	if subroutine.Entry != 0x200 || subroutine.Inclusive != 5 {
+		t.Errorf("Main routine was not counted correctly. Got %+v", subroutine)
+	}
+	subroutine = profiler.Subroutines()[1]
+	if subroutine.Entry != 0x202 || subroutine.Calls != 4 || subroutine.Inclusive != 4 || subroutine.Exclusive != 4 {
+		t.Errorf("Recursive subroutine was not counted correctly. Got %+v", subroutine)
+	}
+}
+
+func TestMix(t *testing.T) {
+	mix := profileProgram(t).Mix()
+
+	if len(mix) != 5 {
+		t.Fatalf("Expected %d commands, got %d", 5, len(mix))
+	}
+	if mix[0].Command != chip8.CmdCallSubRoutine || mix[0].Count != 4 || mix[1].Command != chip8.CmdReturn || mix[1].Count != 4 {
+		t.Errorf("Mix was not counted correctly. Got %+v", mix)
+	}
+}
+
+func TestWriteReport(t *testing.T) {
+	buf := bytes.Buffer{}
+	if err := profileProgram(t).WriteReport(&buf, 3); err != nil {
+		t.Fatal(err)
+	}
+
+	report := buf.String()
+	for _, expected := range []string{"14 instructions", "main+0x4", "JP 0x204", "outer", "CALL addr"} {
+		if !strings.Contains(report, expected) {
+			t.Errorf("Report is missing %q:\n%s", expected, report)
+		}
+	}
+}
+
+func TestWritePprof(t *testing.T) {
+	buf := bytes.Buffer{}
+	if err := profileProgram(t).WritePprof(&buf, "test.ch8"); err != nil {
+		t.Fatal(err)
+	}
+
+	gz, err := gzip.NewReader(&buf)
+	if err != nil {
+		t.Fatalf("Profile is not gzipped: %v", err)
+	}
+	data, err := ioutil.ReadAll(gz)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, expected := range []string{"instructions", "test.ch8", "main", "outer", "inner"} {
+		if !bytes.Contains(data, []byte(expected)) {
+			t.Errorf("Profile is missing the string %q", expected)
+		}
+	}
+}
+
+func TestProtoVarint(t *testing.T) {
+	pb := protoBuffer{}
+	pb.uint64Field(1, 300)
+	pb.uint64Field(2, 0)
+
+	if !bytes.Equal(pb.data, []byte{0x08, 0xAC, 0x02}) {
+		t.Errorf("Varint was not encoded correctly. Expected 08 AC 02, got % X", pb.data)
+	}
+}
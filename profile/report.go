@@ -0,0 +1,53 @@
+package profile
+
+import (
+	"chip8/chip8"
+	"fmt"
+	"io"
+	"text/tabwriter"
+)
+
+// WriteReport prints the top n hot spots, the cost of each subroutine and the instruction mix. n < 1 prints every address that ran.
+func (p *Profiler) WriteReport(w io.Writer, n int) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+
+	fmt.Fprintf(tw, "%d instructions\n\n", p.total)
+
+	fmt.Fprintln(tw, "COUNT\t%\tADDRESS\tSYMBOL\tINSTRUCTION")
+	for _, spot := range p.HotSpots(n) {
+		fmt.Fprintf(tw, "%d\t%s\t0x%03X\t%s\t%s\n", spot.Count, p.percent(spot.Count), spot.Address, p.symbol(spot.Address),
+			chip8.Disassemble(spot.Opcode))
+	}
+
+	fmt.Fprintln(tw)
+	fmt.Fprintln(tw, "INCLUSIVE\t%\tEXCLUSIVE\t%\tCALLS\tSUBROUTINE")
+	for _, s := range p.Subroutines() {
+		fmt.Fprintf(tw, "%d\t%s\t%d\t%s\t%d\t%s\n", s.Inclusive, p.percent(s.Inclusive), s.Exclusive, p.percent(s.Exclusive), s.Calls,
+			p.symbols.Format(s.Entry))
+	}
+
+	fmt.Fprintln(tw)
+	fmt.Fprintln(tw, "COUNT\t%\tCOMMAND")
+	for _, c := range p.Mix() {
+		fmt.Fprintf(tw, "%d\t%s\t%s\n", c.Count, p.percent(c.Count), c.Command.Syntax())
+	}
+
+	return tw.Flush()
+}
+
+func (p *Profiler) percent(count uint64) string {
+	if p.total == 0 {
+		return "-"
+	}
+
+	return fmt.Sprintf("%.1f", float64(count)*100/float64(p.total))
+}
+
+// symbol names an address, or returns - if there is no symbol for it
+func (p *Profiler) symbol(address uint16) string {
+	if symbol, ok := p.symbols.Symbol(address); ok {
+		return symbol
+	}
+
+	return "-"
+}
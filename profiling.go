@@ -0,0 +1,80 @@
+package main
+
+import (
+	"chip8/profile"
+	"chip8/symbols"
+	"flag"
+	"fmt"
+	"os"
+)
+
+// profileFlags are the flags for profiling a run
+type profileFlags struct {
+	pprof  *string
+	report *string
+	top    *int
+}
+
+func addProfileFlags(fs *flag.FlagSet) profileFlags {
+	return profileFlags{
+		pprof:  fs.String("profile", "", "write a pprof profile of where instructions were executed to this file, for go tool pprof"),
+		report: fs.String("profile-report", "", "write a report of hot spots, subroutine costs and the instruction mix to this file (- for stdout)"),
+		top:    fs.Int("profile-top", 20, "number of hot spots in the profile report (0 for all)"),
+	}
+}
+
+// profileOutput is a running profiler and where its results go
+type profileOutput struct {
+	profiler *profile.Profiler
+	flags    profileFlags
+	rom      string
+}
+
+// start creates the profiler, or returns nil if profiling wasn't asked for
+func (pf profileFlags) start(rom string, table *symbols.Table) *profileOutput {
+	if *pf.pprof == "" && *pf.report == "" {
+		return nil
+	}
+
+	return &profileOutput{profiler: profile.New(table), flags: pf, rom: rom}
+}
+
+// save writes out the profile and report. Safe to call on a nil profile.
+func (po *profileOutput) save() error {
+	if po == nil {
+		return nil
+	}
+
+	if *po.flags.pprof != "" {
+		file, err := os.Create(*po.flags.pprof)
+		if err != nil {
+			return err
+		}
+		if err := po.profiler.WritePprof(file, po.rom); err != nil {
+			file.Close()
+			return err
+		}
+		if err := file.Close(); err != nil {
+			return err
+		}
+		fmt.Fprintf(os.Stderr, "Profiled %d instructions to %s\n", po.profiler.Total(), *po.flags.pprof)
+	}
+
+	switch *po.flags.report {
+	case "":
+	case "-":
+		return po.profiler.WriteReport(os.Stdout, *po.flags.top)
+	default:
+		file, err := os.Create(*po.flags.report)
+		if err != nil {
+			return err
+		}
+		if err := po.profiler.WriteReport(file, *po.flags.top); err != nil {
+			file.Close()
+			return err
+		}
+		return file.Close()
+	}
+
+	return nil
+}
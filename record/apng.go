@@ -0,0 +1,142 @@
+package record
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"image/png"
+	"io"
+)
+
+var pngSignature = []byte("\x89PNG\r\n\x1a\n")
+
+type pngChunk struct {
+	kind string
+	data []byte
+}
+
+// readChunks splits an encoded PNG into its chunks
+func readChunks(data []byte) ([]pngChunk, error) {
+	if !bytes.HasPrefix(data, pngSignature) {
+		return nil, errors.New("Not a PNG")
+	}
+	data = data[len(pngSignature):]
+
+	chunks := []pngChunk{}
+	for len(data) >= 12 {
+		length := int(binary.BigEndian.Uint32(data))
+		if len(data) < 12+length {
+			return nil, errors.New("Truncated PNG chunk")
+		}
+		chunks = append(chunks, pngChunk{kind: string(data[4:8]), data: data[8 : 8+length]})
+		data = data[12+length:]
+	}
+
+	return chunks, nil
+}
+
+func writeChunk(w io.Writer, kind string, data []byte) error {
+	header := make([]byte, 8)
+	binary.BigEndian.PutUint32(header, uint32(len(data)))
+	copy(header[4:], kind)
+
+	crc := crc32.NewIEEE()
+	crc.Write(header[4:])
+	crc.Write(data)
+	footer := make([]byte, 4)
+	binary.BigEndian.PutUint32(footer, crc.Sum32())
+
+	for _, b := range [][]byte{header, data, footer} {
+		if _, err := w.Write(b); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// EncodeAPNG writes the recording as an animated PNG. Each frame is encoded with the standard PNG encoder and its image data moved into
+// the APNG frame chunks. APNG delays are fractions so 60Hz timing is exact.
+func (r *Recorder) EncodeAPNG(w io.Writer) error {
+	frames := r.frames()
+	p, err := colorPalette(frames)
+	if err != nil {
+		return err
+	}
+
+	if _, err := w.Write(pngSignature); err != nil {
+		return err
+	}
+
+	sequence := uint32(0)
+	for i, f := range frames {
+		buf := bytes.Buffer{}
+		if err := png.Encode(&buf, paletted(f.img, p)); err != nil {
+			return err
+		}
+		chunks, err := readChunks(buf.Bytes())
+		if err != nil {
+			return err
+		}
+
+		if i == 0 {
+			// Everything before the image data (header and palette) comes from the first frame, followed by the animation control chunk
+			for _, c := range chunks {
+				if c.kind == "IDAT" {
+					break
+				}
+				if err := writeChunk(w, c.kind, c.data); err != nil {
+					return err
+				}
+				if c.kind == "IHDR" {
+					actl := make([]byte, 8)
+					binary.BigEndian.PutUint32(actl, uint32(len(frames)))
+					// 0 plays means loop forever
+					if err := writeChunk(w, "acTL", actl); err != nil {
+						return err
+					}
+				}
+			}
+		}
+
+		bounds := f.img.Bounds()
+		fctl := make([]byte, 26)
+		binary.BigEndian.PutUint32(fctl[0:], sequence)
+		binary.BigEndian.PutUint32(fctl[4:], uint32(bounds.Dx()))
+		binary.BigEndian.PutUint32(fctl[8:], uint32(bounds.Dy()))
+		// x and y offsets are 0
+		delay := f.frames
+		if delay > 0xFFFF {
+			delay = 0xFFFF
+		}
+		binary.BigEndian.PutUint16(fctl[20:], uint16(delay))
+		binary.BigEndian.PutUint16(fctl[22:], FrameRate)
+		// Dispose and blend ops of 0 leave the frame in place and overwrite it
+		if err := writeChunk(w, "fcTL", fctl); err != nil {
+			return err
+		}
+		sequence++
+
+		for _, c := range chunks {
+			if c.kind != "IDAT" {
+				continue
+			}
+
+			if i == 0 {
+				err = writeChunk(w, "IDAT", c.data)
+			} else {
+				fdat := make([]byte, 4+len(c.data))
+				binary.BigEndian.PutUint32(fdat, sequence)
+				copy(fdat[4:], c.data)
+				err = writeChunk(w, "fdAT", fdat)
+				sequence++
+			}
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return writeChunk(w, "IEND", nil)
+}
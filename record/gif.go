@@ -0,0 +1,34 @@
+package record
+
+import (
+	"image"
+	"image/gif"
+	"io"
+)
+
+// EncodeGIF writes the recording as an animated GIF. GIF delays are in hundredths of a second, which 60Hz frames don't divide into, so
+// delays are rounded against the running total to keep the overall timing correct.
+func (r *Recorder) EncodeGIF(w io.Writer) error {
+	frames := r.frames()
+	p, err := colorPalette(frames)
+	if err != nil {
+		return err
+	}
+
+	anim := gif.GIF{}
+	elapsed := 0
+	for _, f := range frames {
+		start := (elapsed*100 + FrameRate/2) / FrameRate
+		elapsed += f.frames
+		end := (elapsed*100 + FrameRate/2) / FrameRate
+
+		anim.Image = append(anim.Image, paletted(f.img, p))
+		anim.Delay = append(anim.Delay, end-start)
+		anim.Disposal = append(anim.Disposal, gif.DisposalNone)
+	}
+
+	bounds := frames[0].img.Bounds()
+	anim.Config = image.Config{ColorModel: p, Width: bounds.Dx(), Height: bounds.Dy()}
+
+	return gif.EncodeAll(w, &anim)
+}
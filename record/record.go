@@ -0,0 +1,189 @@
+package record
+
+import (
+	"bytes"
+	"chip8/blend"
+	"chip8/render"
+	"errors"
+	"image"
+	"image/color"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// The emulator draws a frame 60 times a second
+const FrameRate = 60
+
+// Format is the file format a recording is saved in
+type Format int
+
+const (
+	FormatGIF Format = iota
+	FormatAPNG
+)
+
+// FormatFromPath picks the format from a file extension: .gif for GIF, .png or .apng for APNG
+func FormatFromPath(path string) (Format, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".gif":
+		return FormatGIF, nil
+	case ".png", ".apng":
+		return FormatAPNG, nil
+	}
+
+	return FormatGIF, errors.New("Unknown recording format for " + path + "; use .gif or .png")
+}
+
+// screenRun is a screen that stayed the same for a number of frames
+type screenRun struct {
+	screen [64][32]uint8
+	frames int
+}
+
+// Recorder collects the screen every frame so gameplay can be saved as an animation. Identical consecutive screens are stored once.
+type Recorder struct {
+	opts       render.Options
+	blendMode  blend.Mode
+	blendFade  int
+	runs       []screenRun
+	frameCount int
+}
+
+// New creates a recorder that draws frames with the given render options
+func New(opts render.Options) *Recorder {
+	return &Recorder{opts: opts, blendMode: blend.ModeNone, blendFade: 1}
+}
+
+// SetBlend makes the recording use the same flicker blending as the display
+func (r *Recorder) SetBlend(mode blend.Mode, frames int) {
+	r.blendMode = mode
+	r.blendFade = frames
+}
+
+// AddFrame records the screen for one 60Hz frame. Call it every time the display is updated.
+func (r *Recorder) AddFrame(screen *[64][32]uint8) {
+	r.frameCount++
+
+	if len(r.runs) > 0 && r.runs[len(r.runs)-1].screen == *screen {
+		r.runs[len(r.runs)-1].frames++
+		return
+	}
+
+	r.runs = append(r.runs, screenRun{screen: *screen, frames: 1})
+}
+
+// Frames returns how many frames have been recorded
+func (r *Recorder) Frames() int {
+	return r.frameCount
+}
+
+// frame is a rendered image to be shown for a number of 60Hz frames
+type frame struct {
+	img    *image.RGBA
+	frames int
+}
+
+// frames renders the recording, merging consecutive frames that look the same. Screens that stay the same can still look different
+// while pixels fade out, so each run is rendered frame by frame.
+func (r *Recorder) frames() []frame {
+	renderer := render.New(r.opts)
+	renderer.SetBlend(r.blendMode, r.blendFade)
+	all := [64][32]bool{}
+	for x := range all {
+		for y := range all[x] {
+			all[x][y] = true
+		}
+	}
+
+	result := []frame{}
+	for _, run := range r.runs {
+		for i := 0; i < run.frames; i++ {
+			img := renderer.Render(&run.screen, &all)
+
+			if len(result) > 0 && bytes.Equal(result[len(result)-1].img.Pix, img.Pix) {
+				result[len(result)-1].frames++
+				continue
+			}
+
+			result = append(result, frame{img: copyImage(img), frames: 1})
+		}
+	}
+
+	return result
+}
+
+func copyImage(img *image.RGBA) *image.RGBA {
+	result := image.NewRGBA(img.Bounds())
+	copy(result.Pix, img.Pix)
+	return result
+}
+
+// colorPalette collects the colours used across all frames so every frame can share one palette
+func colorPalette(frames []frame) (color.Palette, error) {
+	seen := map[color.RGBA]bool{}
+	result := color.Palette{}
+
+	for _, f := range frames {
+		for i := 0; i < len(f.img.Pix); i += 4 {
+			c := color.RGBA{R: f.img.Pix[i], G: f.img.Pix[i+1], B: f.img.Pix[i+2], A: f.img.Pix[i+3]}
+			if !seen[c] {
+				if len(result) == 256 {
+					return nil, errors.New("Recording uses more than 256 colours")
+				}
+				seen[c] = true
+				result = append(result, c)
+			}
+		}
+	}
+
+	return result, nil
+}
+
+// paletted converts a frame to use a palette that is known to contain all of its colours
+func paletted(img *image.RGBA, p color.Palette) *image.Paletted {
+	index := map[color.RGBA]uint8{}
+	for i, c := range p {
+		index[c.(color.RGBA)] = uint8(i)
+	}
+
+	result := image.NewPaletted(img.Bounds(), p)
+	for i, j := 0, 0; i < len(img.Pix); i, j = i+4, j+1 {
+		result.Pix[j] = index[color.RGBA{R: img.Pix[i], G: img.Pix[i+1], B: img.Pix[i+2], A: img.Pix[i+3]}]
+	}
+
+	return result
+}
+
+// Encode writes the recording in the given format
+func (r *Recorder) Encode(w io.Writer, format Format) error {
+	if r.frameCount == 0 {
+		return errors.New("Nothing has been recorded")
+	}
+
+	if format == FormatAPNG {
+		return r.EncodeAPNG(w)
+	}
+	return r.EncodeGIF(w)
+}
+
+// Save writes the recording to a file, picking the format from the extension
+func (r *Recorder) Save(path string) error {
+	format, err := FormatFromPath(path)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	if err := r.Encode(f, format); err != nil {
+		f.Close()
+		return err
+	}
+
+	return f.Close()
+}
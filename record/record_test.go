@@ -0,0 +1,123 @@
+package record
+
+import (
+	"bytes"
+	"chip8/blend"
+	"chip8/palette"
+	"chip8/render"
+	"encoding/binary"
+	"image/gif"
+	"image/png"
+	"path/filepath"
+	"testing"
+)
+
+// recordBlink records a pixel that is on for 3 frames then off for 2
+func recordBlink() *Recorder {
+	r := New(render.Options{Scale: 2, Palette: palette.Presets["amber"]})
+	screen := [64][32]uint8{}
+
+	screen[1][1] = 1
+	for i := 0; i < 3; i++ {
+		r.AddFrame(&screen)
+	}
+	screen[1][1] = 0
+	for i := 0; i < 2; i++ {
+		r.AddFrame(&screen)
+	}
+
+	return r
+}
+
+func TestGIFMergesIdenticalFrames(t *testing.T) {
+	buf := bytes.Buffer{}
+	if err := recordBlink().Encode(&buf, FormatGIF); err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+
+	anim, err := gif.DecodeAll(&buf)
+	if err != nil {
+		t.Fatalf("Could not decode GIF: %v", err)
+	}
+
+	if len(anim.Image) != 2 {
+		t.Fatalf("Wrong number of frames. Expected %d, got %d", 2, len(anim.Image))
+	}
+	// 3 frames is 5 hundredths of a second, 5 frames is 8 in total
+	if anim.Delay[0] != 5 || anim.Delay[1] != 3 {
+		t.Errorf("Delays were not correct. Expected [5 3], got %v", anim.Delay)
+	}
+	if anim.Config.Width != 128 || anim.Config.Height != 64 {
+		t.Errorf("Size was not correct, got %dx%d", anim.Config.Width, anim.Config.Height)
+	}
+}
+
+func TestAPNGFrameControl(t *testing.T) {
+	buf := bytes.Buffer{}
+	if err := recordBlink().Encode(&buf, FormatAPNG); err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+
+	// The standard decoder ignores the animation and shows the first frame
+	img, err := png.Decode(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatalf("Could not decode PNG: %v", err)
+	}
+	if r, _, _, _ := img.At(2, 2).RGBA(); r>>8 != 0xFF {
+		t.Errorf("First frame was not drawn correctly, got %v", img.At(2, 2))
+	}
+
+	chunks, err := readChunks(buf.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	delays := []uint16{}
+	for _, c := range chunks {
+		switch c.kind {
+		case "acTL":
+			if n := binary.BigEndian.Uint32(c.data); n != 2 {
+				t.Errorf("Wrong number of frames. Expected %d, got %d", 2, n)
+			}
+		case "fcTL":
+			if den := binary.BigEndian.Uint16(c.data[22:]); den != FrameRate {
+				t.Errorf("Delay denominator was not correct. Expected %d, got %d", FrameRate, den)
+			}
+			delays = append(delays, binary.BigEndian.Uint16(c.data[20:]))
+		}
+	}
+
+	if len(delays) != 2 || delays[0] != 3 || delays[1] != 2 {
+		t.Errorf("Delays were not correct. Expected [3 2], got %v", delays)
+	}
+}
+
+func TestPhosphorFadeIsNotMerged(t *testing.T) {
+	r := recordBlink()
+	r.SetBlend(blend.ModePhosphor, 4)
+
+	// On, then each of the 2 off frames is a different step of the fade
+	if n := len(r.frames()); n != 3 {
+		t.Errorf("Wrong number of frames. Expected %d, got %d", 3, n)
+	}
+}
+
+func TestEncodeEmptyRecording(t *testing.T) {
+	r := New(render.Options{})
+
+	if err := r.Encode(&bytes.Buffer{}, FormatGIF); err == nil {
+		t.Error("Expected an error for an empty recording")
+	}
+}
+
+func TestFormatFromPath(t *testing.T) {
+	if f, err := FormatFromPath(filepath.Join("a", "b.GIF")); err != nil || f != FormatGIF {
+		t.Errorf("Format was not correct, got %v %v", f, err)
+	}
+	if f, err := FormatFromPath("b.apng"); err != nil || f != FormatAPNG {
+		t.Errorf("Format was not correct, got %v %v", f, err)
+	}
+	if _, err := FormatFromPath("b.mp4"); err == nil {
+		t.Error("Expected an error for an unknown format")
+	}
+}
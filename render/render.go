@@ -0,0 +1,136 @@
+package render
+
+import (
+	"chip8/blend"
+	"chip8/palette"
+	"image"
+	"image/color"
+)
+
+// Options controls how the screen is turned into an image
+type Options struct {
+	// Size of each chip8 pixel in image pixels; anything below 1 is treated as 1
+	Scale   int
+	Palette palette.Palette
+	Style   Style
+	// Darken every other row of image pixels like the scanlines of a CRT. Needs a scale of at least 2 to be visible.
+	Scanlines bool
+}
+
+// How bright scanlines are compared to the rest of the image
+const scanlineBrightness = 0.6
+
+// Renderer turns emulator screens into images. It keeps the image between frames so only pixels that changed are redrawn, and keeps
+// the history needed for flicker blending, so a renderer should be fed every frame in order.
+type Renderer struct {
+	opts    Options
+	blender *blend.Blender
+	img     *image.RGBA
+	redraw  bool
+}
+
+// New creates a renderer with no flicker blending
+func New(opts Options) *Renderer {
+	if opts.Scale < 1 {
+		opts.Scale = 1
+	}
+	if len(opts.Palette.Colors) == 0 {
+		opts.Palette = palette.Presets["classic"]
+	}
+
+	return &Renderer{
+		opts:    opts,
+		blender: blend.New(blend.ModeNone, 1),
+		img:     image.NewRGBA(image.Rect(0, 0, 64*opts.Scale, 32*opts.Scale)),
+		redraw:  true,
+	}
+}
+
+// Options returns the options in use
+func (r *Renderer) Options() Options {
+	return r.opts
+}
+
+// SetBlend changes how frames are combined to reduce flicker. frames is how long pixels take to fade in phosphor mode.
+func (r *Renderer) SetBlend(mode blend.Mode, frames int) {
+	r.blender = blend.New(mode, frames)
+	r.redraw = true
+}
+
+// SetPalette changes the colours used; the whole image is redrawn on the next frame
+func (r *Renderer) SetPalette(p palette.Palette) {
+	r.opts.Palette = p
+	r.redraw = true
+}
+
+// SetStyle changes how pixels are drawn; the whole image is redrawn on the next frame
+func (r *Renderer) SetStyle(style Style) {
+	r.opts.Style = style
+	r.redraw = true
+}
+
+// SetScanlines turns the CRT scanline effect on or off; the whole image is redrawn on the next frame
+func (r *Renderer) SetScanlines(scanlines bool) {
+	r.opts.Scanlines = scanlines
+	r.redraw = true
+}
+
+// Render draws the next frame. dirty marks the pixels that changed since the last frame; it is what chip8.GetDirtyFlags returns.
+// The returned image is reused for the following frames so copy it if it needs keeping.
+func (r *Renderer) Render(screen *[64][32]uint8, dirty *[64][32]bool) *image.RGBA {
+	blended, changed := r.blender.Blend(screen, dirty)
+
+	for x, column := range blended {
+		for y, p := range column {
+			if changed[x][y] || r.redraw {
+				r.drawPixel(x, y, palette.Mix(r.opts.Palette.Background(), r.opts.Palette.Color(p.Value), p.Intensity))
+			}
+		}
+	}
+
+	r.redraw = false
+	return r.img
+}
+
+// Image renders a single screen on its own, e.g. for a screenshot
+func Image(screen *[64][32]uint8, opts Options) *image.RGBA {
+	return New(opts).Render(screen, &[64][32]bool{})
+}
+
+// drawPixel fills the square for a single chip8 pixel in the current style
+func (r *Renderer) drawPixel(x int, y int, c color.RGBA) {
+	scale := r.opts.Scale
+
+	// The gap is on the right and bottom edges so pixels are evenly spaced
+	gap := 0
+	if r.opts.Style != StyleSolid {
+		gap = scale / 8
+		if gap < 1 {
+			gap = 1
+		}
+		if gap >= scale {
+			gap = 0
+		}
+	}
+
+	gapColor := r.opts.Palette.Background()
+	if r.opts.Style == StyleGrid {
+		gapColor = r.opts.Palette.GridColor()
+	}
+
+	for py := 0; py < scale; py++ {
+		for px := 0; px < scale; px++ {
+			pixelColor := c
+			if px >= scale-gap || py >= scale-gap {
+				pixelColor = gapColor
+			}
+
+			imageY := y*scale + py
+			if r.opts.Scanlines && scale >= 2 && imageY%2 == 1 {
+				pixelColor = palette.Mix(color.RGBA{A: pixelColor.A}, pixelColor, scanlineBrightness)
+			}
+
+			r.img.SetRGBA(x*scale+px, imageY, pixelColor)
+		}
+	}
+}
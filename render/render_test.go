@@ -0,0 +1,124 @@
+package render
+
+import (
+	"bytes"
+	"chip8/blend"
+	"chip8/palette"
+	"flag"
+	"image"
+	"image/png"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "update golden images in testdata")
+
+// testScreen is a pattern that exercises every pixel value of a four colour palette
+func testScreen() *[64][32]uint8 {
+	screen := [64][32]uint8{}
+	for x := 0; x < 64; x++ {
+		for y := 0; y < 32; y++ {
+			if (x/4+y/4)%2 == 0 {
+				screen[x][y] = uint8((x / 16) % 4)
+			}
+		}
+	}
+
+	return &screen
+}
+
+func checkGolden(t *testing.T, name string, img *image.RGBA) {
+	t.Helper()
+	path := filepath.Join("testdata", name+".png")
+
+	if *update {
+		buf := bytes.Buffer{}
+		png.Encode(&buf, img)
+		if err := ioutil.WriteFile(path, buf.Bytes(), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Could not read golden image; run with -update to create it: %v", err)
+	}
+	golden, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if golden.Bounds() != img.Bounds() {
+		t.Fatalf("Image size does not match %s. Expected %v, got %v", path, golden.Bounds(), img.Bounds())
+	}
+	for y := 0; y < img.Bounds().Dy(); y++ {
+		for x := 0; x < img.Bounds().Dx(); x++ {
+			r1, g1, b1, a1 := golden.At(x, y).RGBA()
+			r2, g2, b2, a2 := img.At(x, y).RGBA()
+			if r1 != r2 || g1 != g2 || b1 != b2 || a1 != a2 {
+				t.Fatalf("Image does not match %s at %d,%d", path, x, y)
+			}
+		}
+	}
+}
+
+func TestRenderSolid(t *testing.T) {
+	img := Image(testScreen(), Options{Scale: 2, Palette: palette.Presets["classic"]})
+	checkGolden(t, "solid", img)
+}
+
+func TestRenderGridFourColours(t *testing.T) {
+	img := Image(testScreen(), Options{Scale: 8, Palette: palette.Presets["octo"], Style: StyleGrid})
+	checkGolden(t, "grid_octo", img)
+}
+
+func TestRenderGapScanlines(t *testing.T) {
+	img := Image(testScreen(), Options{Scale: 4, Palette: palette.Presets["amber"], Style: StyleGap, Scanlines: true})
+	checkGolden(t, "gap_scanlines", img)
+}
+
+func TestRenderPhosphorFade(t *testing.T) {
+	r := New(Options{Scale: 2, Palette: palette.Presets["green"]})
+	r.SetBlend(blend.ModePhosphor, 4)
+
+	screen := testScreen()
+	all := [64][32]bool{}
+	for x := range all {
+		for y := range all[x] {
+			all[x][y] = true
+		}
+	}
+	r.Render(screen, &all)
+
+	// Clear the left half and check it has started fading
+	for x := 0; x < 32; x++ {
+		screen[x] = [32]uint8{}
+	}
+	checkGolden(t, "phosphor", r.Render(screen, &all))
+}
+
+func TestRenderScaleDefaultsTo1(t *testing.T) {
+	img := Image(testScreen(), Options{})
+
+	if img.Bounds() != image.Rect(0, 0, 64, 32) {
+		t.Errorf("Image size was not correct. Expected %v, got %v", image.Rect(0, 0, 64, 32), img.Bounds())
+	}
+}
+
+func TestRenderSetPaletteRedrawsEverything(t *testing.T) {
+	r := New(Options{Scale: 1})
+	screen := testScreen()
+	r.Render(screen, &[64][32]bool{})
+
+	r.SetPalette(palette.Presets["amber"])
+	img := r.Render(screen, &[64][32]bool{})
+
+	if img.RGBAAt(4, 0) != palette.Presets["amber"].Background() {
+		t.Errorf("Background was not redrawn, got %v", img.RGBAAt(4, 0))
+	}
+	if img.RGBAAt(16, 0) != palette.Presets["amber"].Foreground() {
+		t.Errorf("Foreground was not redrawn, got %v", img.RGBAAt(16, 0))
+	}
+}
@@ -0,0 +1,48 @@
+package render
+
+import (
+	"errors"
+)
+
+// Style changes how each chip8 pixel is drawn
+type Style int
+
+const (
+	// Pixels fill their whole square
+	StyleSolid Style = iota
+	// Pixels are drawn slightly smaller than their square, leaving a gap in the background colour between them
+	StyleGap
+	// Like StyleGap but the gaps are drawn as grid lines that are also visible between pixels that are off
+	StyleGrid
+)
+
+var styleNames = []string{"solid", "gap", "grid"}
+
+func (s Style) String() string {
+	if int(s) < len(styleNames) {
+		return styleNames[s]
+	}
+
+	return "unknown"
+}
+
+// ParseStyle gets a style from its name
+func ParseStyle(name string) (Style, error) {
+	for i, n := range styleNames {
+		if n == name {
+			return Style(i), nil
+		}
+	}
+
+	return StyleSolid, errors.New("Unknown style " + name)
+}
+
+// StyleNames returns the names of all styles
+func StyleNames() []string {
+	return append([]string{}, styleNames...)
+}
+
+// Next returns the style after this one, wrapping around; used to cycle through styles with a hotkey
+func (s Style) Next() Style {
+	return Style((int(s) + 1) % len(styleNames))
+}
@@ -0,0 +1,119 @@
+package romdb
+
+import (
+	"chip8/chip8"
+	_ "embed"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+//go:embed roms.json
+var builtinJSON []byte
+
+// Entry holds everything known about a ROM and the settings it needs to be playable. Empty fields mean "no recommendation".
+type Entry struct {
+	Title          string         `json:"title,omitempty"`
+	Author         string         `json:"author,omitempty"`
+	Platform       chip8.Platform `json:"platform,omitempty"`
+	CyclesPerFrame int            `json:"cyclesPerFrame,omitempty"`
+	Quirks         string         `json:"quirks,omitempty"`  // Name of a chip8 quirk preset
+	Palette        string         `json:"palette,omitempty"` // Anything accepted by the frontend's -palette flag
+	// What each chip8 key (as a hex digit) does in the game, e.g. "5": "Jump"
+	Keys map[string]string `json:"keys,omitempty"`
+}
+
+// merge overlays any fields set in other on top of the entry
+func (e Entry) merge(other Entry) Entry {
+	if other.Title != "" {
+		e.Title = other.Title
+	}
+	if other.Author != "" {
+		e.Author = other.Author
+	}
+	if other.Platform != "" {
+		e.Platform = other.Platform
+	}
+	if other.CyclesPerFrame != 0 {
+		e.CyclesPerFrame = other.CyclesPerFrame
+	}
+	if other.Quirks != "" {
+		e.Quirks = other.Quirks
+	}
+	if other.Palette != "" {
+		e.Palette = other.Palette
+	}
+	if other.Keys != nil {
+		e.Keys = other.Keys
+	}
+
+	return e
+}
+
+// Database is a set of entries keyed by the hex encoded SHA-1 of the ROM
+type Database struct {
+	entries map[string]Entry
+}
+
+// New creates an empty database
+func New() *Database {
+	return &Database{entries: map[string]Entry{}}
+}
+
+// Builtin creates a database containing the entries shipped with the emulator
+func Builtin() (*Database, error) {
+	db := New()
+	if err := db.Merge(builtinJSON); err != nil {
+		return nil, err
+	}
+
+	return db, nil
+}
+
+// Merge adds the entries from a JSON document to the database. Entries for a ROM that is already known only replace the fields they set,
+// so an override file can change e.g. just the speed of a game.
+func (db *Database) Merge(data []byte) error {
+	entries := map[string]Entry{}
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return err
+	}
+
+	for hash, entry := range entries {
+		hash = strings.ToLower(hash)
+		db.entries[hash] = db.entries[hash].merge(entry)
+	}
+
+	return nil
+}
+
+// LoadOverrides merges a user override file into the database.
+// A missing file isn't an error as most users won't have one.
+func (db *Database) LoadOverrides(path string) error {
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	return db.Merge(data)
+}
+
+// Lookup finds the entry for a ROM by its hex encoded SHA-1
+func (db *Database) Lookup(hash string) (Entry, bool) {
+	entry, ok := db.entries[strings.ToLower(hash)]
+	return entry, ok
+}
+
+// DefaultOverridePath is where the user override file lives if one isn't given explicitly
+func DefaultOverridePath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+
+	return filepath.Join(dir, "chip8", "romdb.json")
+}
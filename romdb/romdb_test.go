@@ -0,0 +1,61 @@
+package romdb
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+const pongHash = "b232ef880bd6060fb45fa6effed7edf0ae95670e"
+
+func TestBuiltinContainsBundledROMs(t *testing.T) {
+	db, err := Builtin()
+	if err != nil {
+		t.Fatalf("Could not load builtin database: %v", err)
+	}
+
+	entry, ok := db.Lookup(pongHash)
+	if !ok {
+		t.Fatal("Pong was not found")
+	}
+	if entry.Title != "Pong" {
+		t.Errorf("Title was not correct. Expected %q, got %q", "Pong", entry.Title)
+	}
+	if entry.Keys["1"] == "" {
+		t.Error("Key legend was not loaded")
+	}
+}
+
+func TestLookupIsCaseInsensitive(t *testing.T) {
+	db, _ := Builtin()
+
+	if _, ok := db.Lookup("B232EF880BD6060FB45FA6EFFED7EDF0AE95670E"); !ok {
+		t.Error("Upper case hash was not found")
+	}
+}
+
+func TestOverridesOnlyReplaceSetFields(t *testing.T) {
+	db, _ := Builtin()
+	path := filepath.Join(t.TempDir(), "romdb.json")
+	ioutil.WriteFile(path, []byte(`{"`+pongHash+`": {"cyclesPerFrame": 15}}`), 0644)
+
+	if err := db.LoadOverrides(path); err != nil {
+		t.Fatalf("Could not load overrides: %v", err)
+	}
+
+	entry, _ := db.Lookup(pongHash)
+	if entry.CyclesPerFrame != 15 {
+		t.Errorf("CyclesPerFrame was not overridden. Expected %d, got %d", 15, entry.CyclesPerFrame)
+	}
+	if entry.Title != "Pong" {
+		t.Errorf("Title should have been kept. Expected %q, got %q", "Pong", entry.Title)
+	}
+}
+
+func TestMissingOverrideFileIsIgnored(t *testing.T) {
+	db := New()
+
+	if err := db.LoadOverrides(filepath.Join(t.TempDir(), "missing.json")); err != nil {
+		t.Errorf("Unexpected error %v", err)
+	}
+}
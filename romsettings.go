@@ -0,0 +1,64 @@
+package main
+
+import (
+	"chip8/chip8"
+	"chip8/romdb"
+	"flag"
+	"fmt"
+	"io"
+	"sort"
+)
+
+func romdbUsage() string {
+	return "JSON file of per-ROM settings that override the built-in ROM database"
+}
+
+// lookupROM finds the database entry for a ROM, with any user overrides applied. ROMs that aren't in the database get an empty entry.
+func lookupROM(overrides string, info *chip8.ROMInfo) (romdb.Entry, error) {
+	db, err := romdb.Builtin()
+	if err != nil {
+		return romdb.Entry{}, err
+	}
+
+	if overrides != "" {
+		if err := db.LoadOverrides(overrides); err != nil {
+			return romdb.Entry{}, err
+		}
+	}
+
+	entry, _ := db.Lookup(info.SHA1)
+	return entry, nil
+}
+
+// setFlags returns the names of the flags that were explicitly given on the command line; these always win over the ROM database
+func setFlags(fs *flag.FlagSet) map[string]bool {
+	set := map[string]bool{}
+	fs.Visit(func(f *flag.Flag) {
+		set[f.Name] = true
+	})
+
+	return set
+}
+
+// printROMEntry writes the title and key legend of a ROM from the database
+func printROMEntry(w io.Writer, entry romdb.Entry) {
+	if entry.Title == "" {
+		return
+	}
+
+	if entry.Author != "" {
+		fmt.Fprintf(w, "%s by %s\n", entry.Title, entry.Author)
+	} else {
+		fmt.Fprintln(w, entry.Title)
+	}
+
+	keys := []string{}
+	for key := range entry.Keys {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		fmt.Fprintf(w, "  %s  %s\n", key, entry.Keys[key])
+	}
+}
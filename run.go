@@ -0,0 +1,398 @@
+package main
+
+import (
+	"chip8/blend"
+	"chip8/cheat"
+	"chip8/chip8"
+	"chip8/gdbstub"
+	"chip8/headless"
+	"chip8/keymap"
+	"chip8/palette"
+	"chip8/pixeldisplay"
+	"chip8/record"
+	"chip8/render"
+	"chip8/romdb"
+	"chip8/symbols"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/faiface/pixel/pixelgl"
+)
+
+type runOptions struct {
+	data           []uint8
+	scale          float64
+	cyclesPerFrame int
+	quirks         chip8.Quirks
+	palette        palette.Palette
+	style          render.Style
+	blend          blend.Mode
+	blendFrames    int
+	scanlines      bool
+	keys           keymap.Keymap
+	paused         bool
+	frames         int // Stop after this many frames; 0 runs until the ROM halts or the window is closed
+	record         string
+	headless       bool
+	script         headless.Script
+	trace          *traceOutput
+	profile        *profileOutput
+	coverage       *coverageOutput
+	memory         *memoryOutput
+	gdb            string
+	symbols        *symbols.Table
+	cheats         *cheat.File
+	launched       bool // Started from the launcher, so Escape goes back to it
+}
+
+func runCommand(args []string) int {
+	fs := newFlagSet("run")
+	scale := fs.Float64("scale", 8, "size of each chip8 pixel on screen")
+	cycles := fs.Int("cycles", 5, "instructions executed per 60Hz frame")
+	quirks := fs.String("quirks", quirksAuto, quirksUsage())
+	colors := fs.String("palette", "classic", "palette name ("+strings.Join(palette.Names(), ", ")+"), or colours as \"#foreground,#background[,#colour2...]\"")
+	palettes := fs.String("palettes", "", "JSON file of extra named palettes")
+	style := fs.String("style", "solid", "how pixels are drawn ("+strings.Join(render.StyleNames(), ", ")+")")
+	keys := fs.String("keymap", "", "JSON keymap file (default "+keymap.DefaultPath()+" if it exists)")
+	blending := fs.String("blend", "none", "reduce flicker by blending frames ("+strings.Join(blend.ModeNames(), ", ")+")")
+	fade := fs.Int("fade", 4, "frames a pixel takes to fade out with -blend phosphor")
+	scanlines := fs.Bool("scanlines", false, "darken every other line like a CRT")
+	paused := fs.Bool("paused", false, "start paused; press F1 to toggle")
+	database := fs.String("romdb", romdb.DefaultOverridePath(), romdbUsage())
+	frames := fs.Int("frames", 0, "stop after this many 60Hz frames")
+	recording := fs.String("record", "", "record gameplay to an animated .gif or .png (APNG) file")
+	noWindow := fs.Bool("headless", false, "run as fast as possible without a window; needs -frames")
+	input := fs.String("input", "", "script of key presses to play back; implies -headless")
+	tracing := addTraceFlags(fs)
+	profiling := addProfileFlags(fs)
+	memory := addMemoryFlags(fs)
+	coverageFile := fs.String("coverage", "", "record which instructions run and merge them into this coverage file")
+	gdb := fs.String("gdb", "", "wait for a GDB remote debugger on this address, e.g. localhost:2159")
+	symbolFile := fs.String("symbols", "", "symbol file naming addresses in traces, call stacks and the debugger (default the ROM with a .sym extension if it exists)")
+	source := addROMFlags(fs)
+	useCheats := fs.Bool("cheats", false, "apply the saved cheats for the ROM (see 'chip8 cheat')")
+	cheatDir := fs.String("cheat-dir", cheat.DefaultDir(), cheatDirUsage())
+	rom, err := parseROMArgs(fs, args)
+	if err != nil {
+		return usageExitCode(err)
+	}
+
+	if err := validateQuirks(*quirks); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitUsage
+	}
+
+	// Check the ROM before opening a window so a bad path fails straight away
+	data, err := source.read(rom)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Could not load ROM:", err)
+		return exitROM
+	}
+	info, err := chip8.NewROMInfo(data)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Invalid ROM:", err)
+		return exitROM
+	}
+
+	entry, err := lookupROM(*database, info)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Could not load ROM database:", err)
+		return exitUsage
+	}
+	printROMEntry(os.Stdout, entry)
+
+	set := setFlags(fs)
+	if entry.CyclesPerFrame != 0 && !set["cycles"] {
+		*cycles = entry.CyclesPerFrame
+	}
+	if entry.Quirks != "" && !set["quirks"] {
+		*quirks = entry.Quirks
+	}
+	if entry.Palette != "" && !set["palette"] {
+		*colors = entry.Palette
+	}
+
+	opts := runOptions{
+		data:           data,
+		scale:          *scale,
+		cyclesPerFrame: *cycles,
+		paused:         *paused,
+	}
+
+	if opts.cyclesPerFrame < 1 {
+		fmt.Fprintln(os.Stderr, "-cycles must be at least 1")
+		return exitUsage
+	}
+
+	if opts.quirks, err = resolveQuirks(*quirks, info); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitUsage
+	}
+	if info.Platform != chip8.PlatformCHIP8 {
+		fmt.Fprintf(os.Stderr, "ROM looks like it was written for %s; extended instructions are not supported\n", info.Platform)
+	}
+
+	if *palettes != "" {
+		if err := palette.Load(*palettes); err != nil {
+			fmt.Fprintln(os.Stderr, "Could not load palettes:", err)
+			return exitUsage
+		}
+	}
+	if opts.palette, err = palette.Parse(*colors); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitUsage
+	}
+	if opts.style, err = render.ParseStyle(*style); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitUsage
+	}
+	if opts.blend, err = blend.ParseMode(*blending); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitUsage
+	}
+	opts.blendFrames = *fade
+	opts.scanlines = *scanlines
+	opts.frames = *frames
+	opts.record = *recording
+	opts.headless = *noWindow || *input != ""
+	opts.gdb = *gdb
+
+	if opts.record != "" {
+		if _, err := record.FormatFromPath(opts.record); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return exitUsage
+		}
+	}
+	if opts.headless && opts.gdb != "" {
+		fmt.Fprintln(os.Stderr, "-gdb needs a window")
+		return exitUsage
+	}
+	if opts.headless && opts.frames < 1 {
+		fmt.Fprintln(os.Stderr, "-frames must be given when running headless")
+		return exitUsage
+	}
+	if *input != "" {
+		if opts.script, err = headless.LoadScript(*input); err != nil {
+			fmt.Fprintln(os.Stderr, "Could not load input script:", err)
+			return exitUsage
+		}
+	}
+
+	if opts.keys, err = loadKeymap(*keys, info); err != nil {
+		fmt.Fprintln(os.Stderr, "Could not load keymap:", err)
+		return exitUsage
+	}
+
+	if opts.symbols, err = symbols.LoadForROM(rom, *symbolFile); err != nil {
+		fmt.Fprintln(os.Stderr, "Could not load symbols:", err)
+		return exitUsage
+	}
+
+	if opts.cheats, err = loadCheats(*useCheats, *cheatDir, info); err != nil {
+		fmt.Fprintln(os.Stderr, "Could not load cheats:", err)
+		return exitUsage
+	}
+
+	if opts.trace, err = tracing.open(opts.symbols); err != nil {
+		fmt.Fprintln(os.Stderr, "Could not start trace:", err)
+		return exitUsage
+	}
+	defer func() {
+		if err := opts.trace.close(); err != nil {
+			fmt.Fprintln(os.Stderr, "Could not write trace:", err)
+		}
+	}()
+
+	opts.profile = profiling.start(rom, opts.symbols)
+	defer func() {
+		if err := opts.profile.save(); err != nil {
+			fmt.Fprintln(os.Stderr, "Could not write profile:", err)
+		}
+	}()
+
+	opts.coverage = startCoverage(*coverageFile, info)
+	defer func() {
+		if err := opts.coverage.save(); err != nil {
+			fmt.Fprintln(os.Stderr, "Could not write coverage:", err)
+		}
+	}()
+
+	opts.memory = memory.start()
+	defer func() {
+		if err := opts.memory.save(); err != nil {
+			fmt.Fprintln(os.Stderr, "Could not write memory watch:", err)
+		}
+	}()
+
+	if opts.headless {
+		return runHeadless(opts)
+	}
+
+	code := exitOK
+	pixelgl.Run(func() {
+		code = run(opts)
+	})
+
+	return code
+}
+
+// run opens the window and runs the ROM until the window is closed. Must be called from within pixelgl.Run.
+func run(opts runOptions) int {
+	return play(pixeldisplay.New(opts.scale), opts).code
+}
+
+// playResult is how a game ended, and the display settings it was left with after any hotkeys
+type playResult struct {
+	code    int
+	palette palette.Palette
+	style   render.Style
+}
+
+// play runs a ROM in an open window until the window is closed, or with opts.launched until Escape is pressed
+func play(display *pixeldisplay.PixelDisplay, opts runOptions) playResult {
+	display.SetPalette(opts.palette)
+	display.SetStyle(opts.style)
+	display.SetBlend(opts.blend, opts.blendFrames)
+	display.SetScanlines(opts.scanlines)
+	display.SetKeymap(opts.keys)
+	result := playResult{code: exitOK, palette: opts.palette, style: opts.style}
+
+	computer := chip8.New(display)
+	computer.SetQuirks(opts.quirks)
+	attachInstruments(computer, opts)
+	if _, err := computer.LoadFromMemory(opts.data); err != nil {
+		fmt.Fprintln(os.Stderr, "Could not load ROM:", err)
+		result.code = exitROM
+		return result
+	}
+	if err := opts.cheats.Patch(computer); err != nil {
+		fmt.Fprintln(os.Stderr, "Could not apply cheats:", err)
+		result.code = exitError
+		return result
+	}
+
+	// The debugger handles packets on its own goroutine, so while one is attached the CPU is only used through it
+	useCPU := func(f func(computer *chip8.Chip8, tick func() error)) { f(computer, computer.Tick) }
+	var debugger *gdbstub.Server
+	if opts.gdb != "" {
+		l, err := net.Listen("tcp", opts.gdb)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Could not start debugger:", err)
+			result.code = exitUsage
+			return result
+		}
+		defer l.Close()
+
+		debugger = gdbstub.New(computer)
+		debugger.SetSymbols(opts.symbols)
+		go debugger.Serve(l)
+		useCPU = debugger.Do
+		fmt.Fprintln(os.Stderr, "Waiting for a debugger on", l.Addr())
+	}
+
+	ticker := time.NewTicker(time.Second / 60)
+	defer ticker.Stop()
+
+	// From the launcher Escape goes back to the list, so the window is only closed to quit
+	left := false
+	exited := func() bool {
+		left = left || display.Closed() || (opts.launched && display.JustPressed(hotkeyExit))
+		return left
+	}
+
+	recorder := newRecorder(opts)
+	frame := 0
+	paused := opts.paused
+	frameDirty := [64][32]bool{}
+	screen := [64][32]uint8{}
+	halted := false
+
+	// A debugger can fix up the CPU after an error, so keep running while one is attached
+	for !exited() && (!halted || debugger != nil) && (opts.frames == 0 || frame < opts.frames) {
+		if display.JustPressed(hotkeyPause) {
+			paused = !paused
+		}
+		if display.JustPressed(hotkeyPalette) {
+			result.palette = nextPalette(result.palette)
+			display.SetPalette(result.palette)
+		}
+		if display.JustPressed(hotkeyStyle) {
+			result.style = result.style.Next()
+			display.SetStyle(result.style)
+		}
+		showCallStack := display.JustPressed(hotkeyCallStack)
+
+		frameDirty = [64][32]bool{}
+		useCPU(func(computer *chip8.Chip8, tick func() error) {
+			if showCallStack {
+				printCallStack(computer, opts.symbols)
+			}
+			if !paused {
+				var err error
+				if err = opts.cheats.Freeze(computer); err != nil {
+					fmt.Fprintln(os.Stderr, "Could not apply cheats:", err)
+				}
+				if frameDirty, err = runFrame(computer, tick, opts.cyclesPerFrame); err != nil {
+					printCallStack(computer, opts.symbols)
+					result.code = exitError
+				}
+			}
+			screen = *computer.GetScreen()
+			halted = computer.IsHalted()
+		})
+		if !paused {
+			frame++
+		}
+
+		display.Update(&screen, &frameDirty)
+		if recorder != nil && !paused {
+			recorder.AddFrame(&screen)
+		}
+
+		<-ticker.C
+	}
+
+	useCPU(func(computer *chip8.Chip8, _ func() error) {
+		computer.Pause()
+		screen = *computer.GetScreen()
+	})
+	if err := saveRecording(recorder, opts.record); err != nil {
+		fmt.Fprintln(os.Stderr, "Could not save recording:", err)
+		result.code = exitError
+	}
+
+	// Keep the display running after halting; makes it easier to debug etc
+	frameDirty = [64][32]bool{}
+	for !exited() {
+		display.Update(&screen, &frameDirty)
+		<-ticker.C
+	}
+
+	return result
+}
+
+// loadKeymap resolves the keymap for a ROM from a keymap file. With no file given the default location is used if it exists, otherwise
+// the default layout.
+func loadKeymap(path string, info *chip8.ROMInfo) (keymap.Keymap, error) {
+	if path == "" {
+		path = keymap.DefaultPath()
+		if _, err := os.Stat(path); path == "" || err != nil {
+			return keymap.Default(), nil
+		}
+	}
+
+	config, err := keymap.Load(path)
+	if err != nil {
+		return nil, err
+	}
+
+	keys, err := config.Keymap(info.SHA1)
+	if err != nil {
+		return nil, err
+	}
+
+	return keys, pixeldisplay.ValidateKeymap(keys)
+}
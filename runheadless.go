@@ -0,0 +1,52 @@
+package main
+
+import (
+	"chip8/chip8"
+	"chip8/headless"
+	"fmt"
+	"os"
+)
+
+// runHeadless runs a ROM without a window for a fixed number of frames, as fast as possible. Keys come from the input script.
+func runHeadless(opts runOptions) int {
+	display := headless.New()
+	display.SetScript(opts.script)
+	computer := chip8.New(display)
+	computer.SetQuirks(opts.quirks)
+	attachInstruments(computer, opts)
+	if _, err := computer.LoadFromMemory(opts.data); err != nil {
+		fmt.Fprintln(os.Stderr, "Could not load ROM:", err)
+		return exitROM
+	}
+	if err := opts.cheats.Patch(computer); err != nil {
+		fmt.Fprintln(os.Stderr, "Could not apply cheats:", err)
+		return exitError
+	}
+
+	recorder := newRecorder(opts)
+	code := exitOK
+
+	for frame := 0; frame < opts.frames && !computer.IsHalted(); frame++ {
+		if err := opts.cheats.Freeze(computer); err != nil {
+			fmt.Fprintln(os.Stderr, "Could not apply cheats:", err)
+			return exitError
+		}
+		frameDirty, err := runFrame(computer, computer.Tick, opts.cyclesPerFrame)
+		if err != nil {
+			printCallStack(computer, opts.symbols)
+			code = exitError
+		}
+
+		display.Update(computer.GetScreen(), &frameDirty)
+		if recorder != nil {
+			recorder.AddFrame(computer.GetScreen())
+		}
+	}
+
+	if err := saveRecording(recorder, opts.record); err != nil {
+		fmt.Fprintln(os.Stderr, "Could not save recording:", err)
+		code = exitError
+	}
+
+	return code
+}
@@ -0,0 +1,217 @@
+// Package screentest compares emulator screens against golden files so tests can have readable expectations. Goldens live in testdata
+// and are either ASCII art (.txt) or images (.png). Run tests with -update to write the goldens from the current output.
+package screentest
+
+import (
+	"bytes"
+	"chip8/palette"
+	"chip8/render"
+	"errors"
+	"flag"
+	"image"
+	"image/color"
+	"image/png"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "update golden screens in testdata")
+
+// The palette goldens are drawn in. The first two colours are black and white so ordinary chip8 screens look as expected.
+var goldenPalette = palette.Presets["ega"]
+
+// Diff colours
+var (
+	diffMatchOn  = color.RGBA{R: 0x60, G: 0x60, B: 0x60, A: 0xFF}
+	diffMatchOff = color.RGBA{A: 0xFF}
+	diffMissing  = color.RGBA{R: 0xFF, A: 0xFF}
+	diffExtra    = color.RGBA{G: 0xFF, A: 0xFF}
+)
+
+// Updating reports whether the tests were run with -update, for tests that produce screens some other way than Assert
+func Updating() bool {
+	return *update
+}
+
+// Assert fails the test if the screen doesn't match the golden file at path. The format is picked from the extension. On failure an
+// image highlighting the differences is written next to the golden: red for pixels that should be on but aren't, green for pixels that
+// are on but shouldn't be.
+func Assert(t testing.TB, path string, screen *[64][32]uint8) {
+	t.Helper()
+
+	if *update {
+		if err := Write(path, screen); err != nil {
+			t.Fatalf("Could not update golden %s: %v", path, err)
+		}
+		return
+	}
+
+	expected, err := Read(path)
+	if err != nil {
+		t.Fatalf("Could not read golden %s (run with -update to create it): %v", path, err)
+	}
+
+	if *expected == *screen {
+		return
+	}
+
+	diffPath := strings.TrimSuffix(path, filepath.Ext(path)) + ".diff.png"
+	if err := writePNG(diffPath, Diff(expected, screen)); err != nil {
+		t.Errorf("Could not write diff image: %v", err)
+	}
+	t.Errorf("Screen does not match %s; differences written to %s\nExpected:\n%s\nGot:\n%s", path, diffPath, ASCII(expected), ASCII(screen))
+}
+
+// Read loads a golden screen
+func Read(path string) (*[64][32]uint8, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if strings.ToLower(filepath.Ext(path)) == ".png" {
+		img, err := png.Decode(bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+		return FromImage(img)
+	}
+
+	return ParseASCII(string(data))
+}
+
+// Write saves a golden screen
+func Write(path string, screen *[64][32]uint8) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	if strings.ToLower(filepath.Ext(path)) == ".png" {
+		return writePNG(path, render.Image(screen, render.Options{Scale: 1, Palette: goldenPalette}))
+	}
+
+	return ioutil.WriteFile(path, []byte(ASCII(screen)), 0644)
+}
+
+// ASCII draws a screen as 32 lines of 64 characters: '.' for pixels that are off, '#' for on, and a hex digit for other values
+func ASCII(screen *[64][32]uint8) string {
+	sb := strings.Builder{}
+
+	for y := 0; y < 32; y++ {
+		for x := 0; x < 64; x++ {
+			switch v := screen[x][y]; {
+			case v == 0:
+				sb.WriteByte('.')
+			case v == 1:
+				sb.WriteByte('#')
+			default:
+				sb.WriteByte("0123456789ABCDEF"[v&0xF])
+			}
+		}
+		sb.WriteByte('\n')
+	}
+
+	return sb.String()
+}
+
+// ParseASCII reads a screen drawn by ASCII. Spaces can be used for off pixels too, and short lines are padded with off pixels.
+func ParseASCII(text string) (*[64][32]uint8, error) {
+	screen := [64][32]uint8{}
+	lines := strings.Split(strings.TrimRight(strings.ReplaceAll(text, "\r", ""), "\n"), "\n")
+	if len(lines) > 32 {
+		return nil, errors.New("ASCII screen has more than 32 lines")
+	}
+
+	for y, line := range lines {
+		if len(line) > 64 {
+			return nil, errors.New("ASCII screen line is longer than 64 characters")
+		}
+
+		for x, c := range line {
+			switch {
+			case c == '.' || c == ' ':
+			case c == '#':
+				screen[x][y] = 1
+			case c >= '2' && c <= '9':
+				screen[x][y] = uint8(c - '0')
+			case c >= 'A' && c <= 'F':
+				screen[x][y] = uint8(c-'A') + 10
+			default:
+				return nil, errors.New("Invalid character in ASCII screen: " + string(c))
+			}
+		}
+	}
+
+	return &screen, nil
+}
+
+// FromImage reads a screen from an image drawn in the golden palette at any whole scale
+func FromImage(img image.Image) (*[64][32]uint8, error) {
+	bounds := img.Bounds()
+	scale := bounds.Dx() / 64
+	if scale < 1 || bounds.Dx() != 64*scale || bounds.Dy() != 32*scale {
+		return nil, errors.New("Image is not a scaled 64x32 screen")
+	}
+
+	screen := [64][32]uint8{}
+	for x := 0; x < 64; x++ {
+		for y := 0; y < 32; y++ {
+			c := color.RGBAModel.Convert(img.At(bounds.Min.X+x*scale, bounds.Min.Y+y*scale)).(color.RGBA)
+			value, ok := colorIndex(c)
+			if !ok {
+				return nil, errors.New("Image uses a colour that isn't in the golden palette")
+			}
+			screen[x][y] = value
+		}
+	}
+
+	return &screen, nil
+}
+
+func colorIndex(c color.RGBA) (uint8, bool) {
+	for i, p := range goldenPalette.Colors {
+		if p == c {
+			return uint8(i), true
+		}
+	}
+
+	return 0, false
+}
+
+// Diff draws an image comparing two screens at 4x scale
+func Diff(expected *[64][32]uint8, actual *[64][32]uint8) *image.RGBA {
+	const scale = 4
+	img := image.NewRGBA(image.Rect(0, 0, 64*scale, 32*scale))
+
+	for x := 0; x < 64; x++ {
+		for y := 0; y < 32; y++ {
+			c := diffMatchOff
+			switch e, a := expected[x][y], actual[x][y]; {
+			case e == a && e != 0:
+				c = diffMatchOn
+			case e != a && e != 0:
+				c = diffMissing
+			case e != a:
+				c = diffExtra
+			}
+
+			for i := 0; i < scale*scale; i++ {
+				img.SetRGBA(x*scale+i%scale, y*scale+i/scale, c)
+			}
+		}
+	}
+
+	return img
+}
+
+func writePNG(path string, img image.Image) error {
+	buf := bytes.Buffer{}
+	if err := png.Encode(&buf, img); err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(path, buf.Bytes(), 0644)
+}
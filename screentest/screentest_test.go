@@ -0,0 +1,91 @@
+package screentest
+
+import (
+	"bytes"
+	"image/color"
+	"path/filepath"
+	"testing"
+)
+
+func testScreen() *[64][32]uint8 {
+	screen := [64][32]uint8{}
+	screen[0][0] = 1
+	screen[63][31] = 1
+	screen[10][5] = 3
+
+	return &screen
+}
+
+func TestASCIIRoundTrip(t *testing.T) {
+	screen := testScreen()
+
+	parsed, err := ParseASCII(ASCII(screen))
+	if err != nil {
+		t.Fatalf("Could not parse ASCII screen: %v", err)
+	}
+	if *parsed != *screen {
+		t.Errorf("Screen was not parsed correctly. Expected:\n%s\nGot:\n%s", ASCII(screen), ASCII(parsed))
+	}
+}
+
+func TestParseASCIIPadsShortLines(t *testing.T) {
+	screen, err := ParseASCII("#\n .#\n")
+	if err != nil {
+		t.Fatalf("Could not parse ASCII screen: %v", err)
+	}
+
+	if screen[0][0] != 1 || screen[2][1] != 1 || screen[1][1] != 0 || screen[63][31] != 0 {
+		t.Errorf("Screen was not parsed correctly. Got:\n%s", ASCII(screen))
+	}
+}
+
+func TestParseASCIIRejectsInvalidScreens(t *testing.T) {
+	for _, text := range []string{"x", string(bytes.Repeat([]byte{'.'}, 65)), string(bytes.Repeat([]byte(".\n"), 33))} {
+		if _, err := ParseASCII(text); err == nil {
+			t.Errorf("Expected an error parsing %q", text)
+		}
+	}
+}
+
+func TestPNGRoundTrip(t *testing.T) {
+	screen := testScreen()
+	path := filepath.Join(t.TempDir(), "screen.png")
+
+	if err := Write(path, screen); err != nil {
+		t.Fatalf("Could not write golden: %v", err)
+	}
+
+	read, err := Read(path)
+	if err != nil {
+		t.Fatalf("Could not read golden: %v", err)
+	}
+	if *read != *screen {
+		t.Errorf("Screen was not read correctly. Expected:\n%s\nGot:\n%s", ASCII(screen), ASCII(read))
+	}
+}
+
+func TestDiff(t *testing.T) {
+	expected := [64][32]uint8{}
+	actual := [64][32]uint8{}
+	expected[0][0] = 1
+	actual[0][0] = 1
+	expected[1][0] = 1
+	actual[2][0] = 1
+
+	img := Diff(&expected, &actual)
+
+	checks := []struct {
+		x, y     int
+		expected color.RGBA
+	}{
+		{0, 0, diffMatchOn},
+		{4, 0, diffMissing},
+		{8, 0, diffExtra},
+		{12, 0, diffMatchOff},
+	}
+	for _, check := range checks {
+		if got := img.RGBAAt(check.x, check.y); got != check.expected {
+			t.Errorf("Diff pixel at %d,%d was not set correctly. Expected %v, got %v", check.x, check.y, check.expected, got)
+		}
+	}
+}
@@ -0,0 +1,44 @@
+package symbols
+
+import "fmt"
+
+// Frame is one level of a call stack
+type Frame struct {
+	Address  uint16
+	Symbol   string // Empty if there is no label at or before the address
+	Location *Location
+}
+
+// String shows the frame as its symbol and source line, such as draw_paddle+0x4 (pong.8o:12), falling back to the address
+func (f Frame) String() string {
+	name := f.Symbol
+	if name == "" {
+		name = fmt.Sprintf("0x%03X", f.Address)
+	}
+	if f.Location != nil {
+		name += " (" + f.Location.String() + ")"
+	}
+
+	return name
+}
+
+// CallStack builds the frames for a CPU stopped at pc with the given CALL stack, oldest call first as returned by Chip8.Stack. The
+// frames are innermost first: pc, then the address of each CALL that hasn't returned yet.
+func (t *Table) CallStack(pc uint16, stack []uint16) []Frame {
+	addresses := []uint16{pc}
+	for i := len(stack) - 1; i >= 0; i-- {
+		addresses = append(addresses, stack[i])
+	}
+
+	frames := []Frame{}
+	for _, address := range addresses {
+		frame := Frame{Address: address}
+		frame.Symbol, _ = t.Symbol(address)
+		if location, ok := t.Line(address); ok {
+			frame.Location = &location
+		}
+		frames = append(frames, frame)
+	}
+
+	return frames
+}
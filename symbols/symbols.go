@@ -0,0 +1,291 @@
+// Package symbols reads symbol files, which map ROM addresses back to the source they were assembled from. Assemblers can emit them
+// alongside the ROM. They are text with one record per line:
+//
+//	# Addresses are hex, lines are 1 based
+//	label 0x200 main
+//	line 0x200 12 pong.8o
+//
+// A label record names an address, so addresses after it can be shown as draw_paddle+0x4. A line record says the instruction at an
+// address came from a line of a source file. Paths are relative to the symbol file. Blank lines and lines starting with # are ignored,
+// as are records of types this version doesn't know about.
+//
+// A nil *Table is an empty one, so tools can take a table without checking whether one was loaded.
+package symbols
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Location is a line in a source file
+type Location struct {
+	File string
+	Line int
+}
+
+func (l Location) String() string {
+	return filepath.Base(l.File) + ":" + strconv.Itoa(l.Line)
+}
+
+// Table holds everything read from a symbol file
+type Table struct {
+	lines  map[uint16]Location
+	labels map[string]uint16
+	// Labelled addresses in order, and the name of each
+	labelAddresses []uint16
+	labelNames     map[uint16]string
+}
+
+// New creates an empty table
+func New() *Table {
+	return &Table{lines: map[uint16]Location{}, labels: map[string]uint16{}, labelNames: map[uint16]string{}}
+}
+
+// Parse reads a symbol file. Relative source paths are resolved against dir.
+func Parse(r io.Reader, dir string) (*Table, error) {
+	table := New()
+	scanner := bufio.NewScanner(r)
+	number := 0
+
+	for scanner.Scan() {
+		number++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		fields := strings.Fields(text)
+		if err := table.parseRecord(fields, text, dir); err != nil {
+			return nil, errors.New("Line " + strconv.Itoa(number) + ": " + err.Error())
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return table, nil
+}
+
+func (t *Table) parseRecord(fields []string, text string, dir string) error {
+	switch fields[0] {
+	case "line":
+		if len(fields) < 4 {
+			return errors.New("expected line <address> <line> <file>")
+		}
+		address, err := ParseAddress(fields[1])
+		if err != nil {
+			return err
+		}
+		line, err := strconv.Atoi(fields[2])
+		if err != nil || line < 1 {
+			return errors.New("invalid line number " + fields[2])
+		}
+		// The file is the rest of the line so paths can contain spaces
+		file := afterFields(text, 3)
+		if !filepath.IsAbs(file) && dir != "" {
+			file = filepath.Join(dir, file)
+		}
+		t.lines[address] = Location{File: filepath.Clean(file), Line: line}
+
+	case "label":
+		if len(fields) != 3 {
+			return errors.New("expected label <address> <name>")
+		}
+		address, err := ParseAddress(fields[1])
+		if err != nil {
+			return err
+		}
+		return t.AddLabel(address, fields[2])
+	}
+
+	return nil
+}
+
+// AddLabel names an address. If an address has several labels the first one is used to show it, but all of them can be looked up.
+// Will return an error if the name is already used.
+func (t *Table) AddLabel(address uint16, name string) error {
+	if _, ok := t.labels[name]; ok {
+		return errors.New("label " + name + " is defined more than once")
+	}
+	t.labels[name] = address
+
+	if _, ok := t.labelNames[address]; ok {
+		return nil
+	}
+	t.labelNames[address] = name
+
+	i := sort.Search(len(t.labelAddresses), func(i int) bool {
+		return t.labelAddresses[i] >= address
+	})
+	t.labelAddresses = append(t.labelAddresses, 0)
+	copy(t.labelAddresses[i+1:], t.labelAddresses[i:])
+	t.labelAddresses[i] = address
+
+	return nil
+}
+
+// afterFields returns the rest of a line after skipping n whitespace separated fields
+func afterFields(text string, n int) string {
+	for i := 0; i < n; i++ {
+		text = strings.TrimLeft(text, " \t")
+		if end := strings.IndexAny(text, " \t"); end >= 0 {
+			text = text[end:]
+		} else {
+			text = ""
+		}
+	}
+
+	return strings.TrimSpace(text)
+}
+
+// Load reads a symbol file from disk
+func Load(path string) (*Table, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return Parse(file, filepath.Dir(path))
+}
+
+// ParseAddress reads a hex address, with or without a 0x prefix
+func ParseAddress(value string) (uint16, error) {
+	address, err := strconv.ParseUint(strings.TrimPrefix(strings.ToLower(value), "0x"), 16, 16)
+	if err != nil || address >= 0x1000 {
+		return 0, errors.New("invalid address " + value)
+	}
+
+	return uint16(address), nil
+}
+
+// Line finds the source line an address came from
+func (t *Table) Line(address uint16) (Location, bool) {
+	if t == nil {
+		return Location{}, false
+	}
+
+	location, ok := t.lines[address]
+	return location, ok
+}
+
+// Label finds the address of a label
+func (t *Table) Label(name string) (uint16, bool) {
+	if t == nil {
+		return 0, false
+	}
+
+	address, ok := t.labels[name]
+	return address, ok
+}
+
+// Symbol names an address after the closest label at or before it, such as draw_paddle+0x4. Returns false if there is no such label.
+func (t *Table) Symbol(address uint16) (string, bool) {
+	if t == nil {
+		return "", false
+	}
+
+	i := sort.Search(len(t.labelAddresses), func(i int) bool {
+		return t.labelAddresses[i] > address
+	})
+	if i == 0 {
+		return "", false
+	}
+
+	label := t.labelAddresses[i-1]
+	name := t.labelNames[label]
+	if label != address {
+		name += fmt.Sprintf("+0x%X", address-label)
+	}
+
+	return name, true
+}
+
+// Format shows an address by its symbol, or in hex if it has none
+func (t *Table) Format(address uint16) string {
+	if symbol, ok := t.Symbol(address); ok {
+		return symbol
+	}
+
+	return fmt.Sprintf("0x%03X", address)
+}
+
+// ResolveAddress reads an address given either in hex or as a label with an optional hex offset, such as draw_paddle+0x4
+func (t *Table) ResolveAddress(value string) (uint16, error) {
+	if address, err := ParseAddress(value); err == nil {
+		return address, nil
+	}
+
+	name, offset := value, uint64(0)
+	if plus := strings.LastIndex(value, "+"); plus >= 0 {
+		var err error
+		name = value[:plus]
+		if offset, err = strconv.ParseUint(strings.TrimPrefix(strings.ToLower(value[plus+1:]), "0x"), 16, 16); err != nil {
+			return 0, errors.New("invalid offset in " + value)
+		}
+	}
+
+	address, ok := t.Label(name)
+	if !ok {
+		return 0, errors.New("unknown label " + name)
+	}
+	if uint64(address)+offset >= 0x1000 {
+		return 0, errors.New(value + " is out of memory")
+	}
+
+	return address + uint16(offset), nil
+}
+
+// Addresses finds every address assembled from a source line, in order. Files match if they are the same path, or failing that have
+// the same name, so a breakpoint set on a copy of the source still works.
+func (t *Table) Addresses(file string, line int) []uint16 {
+	if t == nil {
+		return nil
+	}
+
+	file = filepath.Clean(file)
+	exact := []uint16{}
+	byName := []uint16{}
+
+	for address, location := range t.lines {
+		if location.Line != line {
+			continue
+		}
+		if location.File == file {
+			exact = append(exact, address)
+		} else if filepath.Base(location.File) == filepath.Base(file) {
+			byName = append(byName, address)
+		}
+	}
+
+	addresses := exact
+	if len(addresses) == 0 {
+		addresses = byName
+	}
+	sort.Slice(addresses, func(i, j int) bool {
+		return addresses[i] < addresses[j]
+	})
+
+	return addresses
+}
+
+// LoadForROM loads the symbol file at path, or if path is empty the file next to the ROM with a .sym extension. Returns an empty table
+// if no path was given and there is no file next to the ROM.
+func LoadForROM(rom string, path string) (*Table, error) {
+	if path == "" {
+		path = strings.TrimSuffix(rom, filepath.Ext(rom)) + ".sym"
+		if _, err := os.Stat(path); err != nil {
+			return New(), nil
+		}
+	}
+
+	return Load(path)
+}
@@ -0,0 +1,147 @@
+package symbols
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testSymbols = `
+# Test symbols
+line 0x200 3 pong.8o
+line 203 3 pong.8o
+line 0x204 10 lib/draw score.8o
+label 0x200 main
+label 0x204 draw_score
+label 0x204 score_entry
+future 0x200 something
+`
+
+func TestParseLines(t *testing.T) {
+	table, err := Parse(strings.NewReader(testSymbols), "src")
+	if err != nil {
+		t.Fatalf("Could not parse symbols: %v", err)
+	}
+
+	location, ok := table.Line(0x204)
+	expected := Location{File: filepath.Join("src", "lib", "draw score.8o"), Line: 10}
+	if !ok || location != expected {
+		t.Errorf("Line was not parsed correctly. Expected %+v, got %+v", expected, location)
+	}
+
+	if _, ok := table.Line(0x206); ok {
+		t.Errorf("Expected no line for an address without a record")
+	}
+}
+
+func TestAddresses(t *testing.T) {
+	table, _ := Parse(strings.NewReader(testSymbols), "src")
+
+	addresses := table.Addresses(filepath.Join("src", "pong.8o"), 3)
+	if len(addresses) != 2 || addresses[0] != 0x200 || addresses[1] != 0x203 {
+		t.Errorf("Addresses were not found correctly. Expected [200 203], got %X", addresses)
+	}
+
+	// A different copy of the same file still matches by name
+	addresses = table.Addresses("/elsewhere/pong.8o", 3)
+	if len(addresses) != 2 {
+		t.Errorf("Addresses were not matched by name. Got %X", addresses)
+	}
+
+	if addresses := table.Addresses("pong.8o", 4); len(addresses) != 0 {
+		t.Errorf("Expected no addresses for a line without code, got %X", addresses)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, text := range []string{"line 0x200 3", "line zz 3 a.8o", "line 0x200 0 a.8o", "line 0x1000 1 a.8o", "label 0x200", "label 0x200 a\nlabel 0x202 a"} {
+		if _, err := Parse(strings.NewReader(text), ""); err == nil || !strings.HasPrefix(err.Error(), "Line ") {
+			t.Errorf("Expected a line numbered error parsing %q, got %v", text, err)
+		}
+	}
+}
+
+func TestSymbol(t *testing.T) {
+	table, _ := Parse(strings.NewReader(testSymbols), "src")
+
+	tests := map[uint16]string{0x200: "main", 0x202: "main+0x2", 0x204: "draw_score", 0x216: "draw_score+0x12", 0x1FE: "0x1FE"}
+	for address, expected := range tests {
+		if symbol := table.Format(address); symbol != expected {
+			t.Errorf("Address 0x%03X was not formatted correctly. Expected %s, got %s", address, expected, symbol)
+		}
+	}
+
+	if address, ok := table.Label("score_entry"); !ok || address != 0x204 {
+		t.Errorf("Second label was not found correctly. Expected 0x204, got 0x%03X", address)
+	}
+}
+
+func TestResolveAddress(t *testing.T) {
+	table, _ := Parse(strings.NewReader(testSymbols), "src")
+
+	tests := map[string]uint16{"0x2A0": 0x2A0, "2a0": 0x2A0, "main": 0x200, "draw_score+0x4": 0x208, "main+a": 0x20A}
+	for value, expected := range tests {
+		if address, err := table.ResolveAddress(value); err != nil || address != expected {
+			t.Errorf("%s was not resolved correctly. Expected 0x%03X, got 0x%03X %v", value, expected, address, err)
+		}
+	}
+
+	for _, value := range []string{"missing", "main+zz", "main+0xFFF"} {
+		if _, err := table.ResolveAddress(value); err == nil {
+			t.Errorf("Expected an error resolving %s", value)
+		}
+	}
+}
+
+func TestCallStack(t *testing.T) {
+	table, _ := Parse(strings.NewReader(testSymbols), "src")
+
+	frames := table.CallStack(0x206, []uint16{0x1F0, 0x202})
+	expected := []string{"draw_score+0x2", "main+0x2", "0x1F0"}
+	if len(frames) != len(expected) {
+		t.Fatalf("Call stack was not built correctly. Expected %d frames, got %d", len(expected), len(frames))
+	}
+	for i, frame := range frames {
+		if frame.Symbol != strings.TrimPrefix(expected[i], "0x1F0") || frame.String() != expected[i] {
+			t.Errorf("Frame %d was not built correctly. Expected %s, got %s", i, expected[i], frame)
+		}
+	}
+
+	frames = table.CallStack(0x200, nil)
+	if frames[0].String() != "main (pong.8o:3)" {
+		t.Errorf("Frame with a source line was not shown correctly. Expected main (pong.8o:3), got %s", frames[0])
+	}
+}
+
+func TestNilTable(t *testing.T) {
+	var table *Table
+
+	if table.Format(0x2A0) != "0x2A0" {
+		t.Errorf("Nil table did not format in hex. Got %s", table.Format(0x2A0))
+	}
+	if frames := table.CallStack(0x200, []uint16{0x300}); len(frames) != 2 || frames[1].String() != "0x300" {
+		t.Errorf("Nil table did not build a plain call stack. Got %v", frames)
+	}
+}
+
+func TestLoadForROM(t *testing.T) {
+	dir := t.TempDir()
+	rom := filepath.Join(dir, "pong.ch8")
+
+	table, err := LoadForROM(rom, "")
+	if err != nil || table == nil {
+		t.Fatalf("Expected an empty table without a symbol file, got %v", err)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "pong.sym"), []byte("label 200 main"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if table, err = LoadForROM(rom, ""); err != nil || table.Format(0x200) != "main" {
+		t.Errorf("Symbol file next to the ROM was not loaded. Got %v", err)
+	}
+
+	if _, err := LoadForROM(rom, filepath.Join(dir, "missing.sym")); err == nil {
+		t.Error("Expected an error loading a missing symbol file")
+	}
+}
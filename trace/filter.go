@@ -0,0 +1,79 @@
+package trace
+
+import (
+	"chip8/chip8"
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// Filter limits tracing to a range of addresses and a window of cycles. Bounds are inclusive and an upper bound of 0 means no limit, so
+// the zero value lets everything through.
+type Filter struct {
+	PCFrom    uint16
+	PCTo      uint16
+	CycleFrom uint64
+	CycleTo   uint64
+}
+
+// Match reports whether a record passes the filter
+func (f Filter) Match(record *chip8.TraceRecord) bool {
+	if record.PC < f.PCFrom || (f.PCTo != 0 && record.PC > f.PCTo) {
+		return false
+	}
+	if record.Cycle < f.CycleFrom || (f.CycleTo != 0 && record.Cycle > f.CycleTo) {
+		return false
+	}
+
+	return true
+}
+
+// ParsePCRange reads an address range such as "200-2FF" or "0x300-". Addresses are hex.
+func ParsePCRange(value string) (uint16, uint16, error) {
+	from, to, err := parseRange(value, 16, 16)
+	if err != nil {
+		return 0, 0, errors.New("Invalid PC range " + value + ", expected e.g. 200-2FF")
+	}
+
+	return uint16(from), uint16(to), nil
+}
+
+// ParseCycleRange reads a cycle window such as "1000-2000" or "5000-". Cycles are decimal.
+func ParseCycleRange(value string) (uint64, uint64, error) {
+	from, to, err := parseRange(value, 10, 64)
+	if err != nil {
+		return 0, 0, errors.New("Invalid cycle range " + value + ", expected e.g. 1000-2000")
+	}
+
+	return from, to, nil
+}
+
+func parseRange(value string, base int, bits int) (uint64, uint64, error) {
+	parts := strings.SplitN(value, "-", 2)
+	if len(parts) != 2 || parts[0] == "" {
+		return 0, 0, errors.New("missing -")
+	}
+
+	parse := func(s string) (uint64, error) {
+		if base == 16 {
+			s = strings.TrimPrefix(strings.ToLower(s), "0x")
+		}
+		return strconv.ParseUint(s, base, bits)
+	}
+
+	from, err := parse(parts[0])
+	if err != nil {
+		return 0, 0, err
+	}
+	to := uint64(0)
+	if parts[1] != "" {
+		if to, err = parse(parts[1]); err != nil {
+			return 0, 0, err
+		}
+		if to < from {
+			return 0, 0, errors.New("range ends before it starts")
+		}
+	}
+
+	return from, to, nil
+}
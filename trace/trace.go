@@ -0,0 +1,136 @@
+// Package trace writes a log of every instruction a chip8 CPU executes, either as text lines that are easy to diff against other
+// emulators' logs or as JSON Lines for tooling. Attach a Writer with Chip8.SetTracer.
+package trace
+
+import (
+	"bufio"
+	"chip8/chip8"
+	"chip8/symbols"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Format is how trace records are written
+type Format int
+
+const (
+	// One fixed width line per instruction, followed by the symbol for the PC when there is one:
+	//	00000000 0200 6003 V:00 00 00 00 00 00 00 00 00 00 00 00 00 00 00 00 I:0000 SP:0 DT:00 ST:00 LD V0, 0x03 <main>
+	FormatText Format = iota
+	// One JSON object per line
+	FormatJSON
+)
+
+var formatNames = []string{"text", "jsonl"}
+
+func (f Format) String() string {
+	if int(f) < len(formatNames) {
+		return formatNames[f]
+	}
+
+	return "unknown"
+}
+
+// ParseFormat gets a format from its name
+func ParseFormat(name string) (Format, error) {
+	for i, n := range formatNames {
+		if n == name {
+			return Format(i), nil
+		}
+	}
+
+	return FormatText, errors.New("Unknown trace format " + name)
+}
+
+// FormatNames returns the names of all formats
+func FormatNames() []string {
+	return append([]string{}, formatNames...)
+}
+
+// Record is the JSON form of a trace record
+type Record struct {
+	Cycle    uint64    `json:"cycle"`
+	PC       uint16    `json:"pc"`
+	Opcode   uint16    `json:"opcode"`
+	Mnemonic string    `json:"mnemonic"`
+	V        [16]uint8 `json:"v"`
+	I        uint16    `json:"i"`
+	SP       uint8     `json:"sp"`
+	DT       uint8     `json:"dt"`
+	ST       uint8     `json:"st"`
+	Symbol   string    `json:"symbol,omitempty"`
+}
+
+// Writer is a chip8.Tracer that writes the records that pass its filter. Output is buffered, so call Flush once the CPU has stopped.
+type Writer struct {
+	w       *bufio.Writer
+	format  Format
+	filter  Filter
+	symbols *symbols.Table
+	err     error
+}
+
+// New creates a writer
+func New(w io.Writer, format Format, filter Filter) *Writer {
+	return &Writer{w: bufio.NewWriter(w), format: format, filter: filter}
+}
+
+// SetSymbols names PCs after the labels in a symbol table
+func (tw *Writer) SetSymbols(table *symbols.Table) {
+	tw.symbols = table
+}
+
+// Trace writes a record if it passes the filter. Errors are kept until Flush so tracing can't interrupt the CPU.
+func (tw *Writer) Trace(record *chip8.TraceRecord) {
+	if tw.err != nil || !tw.filter.Match(record) {
+		return
+	}
+
+	mnemonic := "???"
+	if record.Instruction != nil {
+		mnemonic = record.Instruction.String()
+	}
+	symbol, _ := tw.symbols.Symbol(record.PC)
+
+	if tw.format == FormatJSON {
+		line, err := json.Marshal(Record{
+			Cycle:    record.Cycle,
+			PC:       record.PC,
+			Opcode:   record.Opcode,
+			Mnemonic: mnemonic,
+			V:        record.V,
+			I:        record.I,
+			SP:       record.SP,
+			DT:       record.DT,
+			ST:       record.ST,
+			Symbol:   symbol,
+		})
+		if err == nil {
+			_, err = tw.w.Write(append(line, '\n'))
+		}
+		tw.err = err
+		return
+	}
+
+	registers := make([]string, len(record.V))
+	for i, v := range record.V {
+		registers[i] = fmt.Sprintf("%02X", v)
+	}
+	if symbol != "" {
+		mnemonic += " <" + symbol + ">"
+	}
+	_, tw.err = fmt.Fprintf(tw.w, "%08d %04X %04X V:%s I:%04X SP:%X DT:%02X ST:%02X %s\n", record.Cycle, record.PC, record.Opcode,
+		strings.Join(registers, " "), record.I, record.SP, record.DT, record.ST, mnemonic)
+}
+
+// Flush writes out anything buffered and returns the first error hit while tracing
+func (tw *Writer) Flush() error {
+	if tw.err != nil {
+		return tw.err
+	}
+
+	return tw.w.Flush()
+}
@@ -0,0 +1,136 @@
+package trace
+
+import (
+	"bytes"
+	"chip8/chip8"
+	"chip8/symbols"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+type display struct{}
+
+func (d *display) Update(*[64][32]uint8, *[64][32]bool) {}
+func (d *display) Closed() bool                         { return false }
+func (d *display) KeyDown(key uint8) bool               { return false }
+
+// traceProgram runs a short program with a tracer attached and returns the output
+func traceProgram(t *testing.T, format Format, filter Filter) string {
+	return traceProgramWithSymbols(t, format, filter, nil)
+}
+
+func traceProgramWithSymbols(t *testing.T, format Format, filter Filter, table *symbols.Table) string {
+	// LD V0, 3; ADD V0, 1; JP 0x202
+	computer := chip8.New(&display{})
+	if _, err := computer.LoadFromMemory([]uint8{0x60, 0x03, 0x70, 0x01, 0x12, 0x02}); err != nil {
+		t.Fatal(err)
+	}
+
+	buf := bytes.Buffer{}
+	tw := New(&buf, format, filter)
+	tw.SetSymbols(table)
+	computer.SetTracer(tw)
+	for i := 0; i < 5; i++ {
+		computer.Tick()
+	}
+	if err := tw.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	return buf.String()
+}
+
+func TestTextFormat(t *testing.T) {
+	lines := strings.Split(strings.TrimSpace(traceProgram(t, FormatText, Filter{})), "\n")
+	if len(lines) != 5 {
+		t.Fatalf("Expected %d lines, got %d", 5, len(lines))
+	}
+
+	expected := "00000001 0202 7001 V:03 00 00 00 00 00 00 00 00 00 00 00 00 00 00 00 I:0000 SP:0 DT:00 ST:00 ADD V0, 0x01"
+	if lines[1] != expected {
+		t.Errorf("Line was not written correctly.\nExpected %s\nGot      %s", expected, lines[1])
+	}
+}
+
+func TestJSONFormat(t *testing.T) {
+	lines := strings.Split(strings.TrimSpace(traceProgram(t, FormatJSON, Filter{})), "\n")
+
+	record := Record{}
+	if err := json.Unmarshal([]byte(lines[3]), &record); err != nil {
+		t.Fatalf("Could not parse line: %v", err)
+	}
+
+	if record.Cycle != 3 || record.PC != 0x202 || record.Mnemonic != "ADD V0, 0x01" || record.V[0] != 4 {
+		t.Errorf("Record was not written correctly. Got %+v", record)
+	}
+}
+
+func TestSymbols(t *testing.T) {
+	table := symbols.New()
+	table.AddLabel(0x200, "start")
+
+	lines := strings.Split(strings.TrimSpace(traceProgramWithSymbols(t, FormatText, Filter{}, table)), "\n")
+	if !strings.HasSuffix(lines[1], "ADD V0, 0x01 <start+0x2>") {
+		t.Errorf("Symbol was not written correctly. Got %s", lines[1])
+	}
+
+	lines = strings.Split(strings.TrimSpace(traceProgramWithSymbols(t, FormatJSON, Filter{}, table)), "\n")
+	record := Record{}
+	if err := json.Unmarshal([]byte(lines[0]), &record); err != nil || record.Symbol != "start" {
+		t.Errorf("Symbol was not written correctly. Expected start, got %q %v", record.Symbol, err)
+	}
+}
+
+func TestFilter(t *testing.T) {
+	lines := strings.Split(strings.TrimSpace(traceProgram(t, FormatText, Filter{PCFrom: 0x202, PCTo: 0x202, CycleTo: 3})), "\n")
+
+	if len(lines) != 2 {
+		t.Fatalf("Expected %d lines, got %d:\n%s", 2, len(lines), strings.Join(lines, "\n"))
+	}
+	if !strings.HasPrefix(lines[0], "00000001 0202") || !strings.HasPrefix(lines[1], "00000003 0202") {
+		t.Errorf("Wrong records were written:\n%s", strings.Join(lines, "\n"))
+	}
+}
+
+func TestParsePCRange(t *testing.T) {
+	from, to, err := ParsePCRange("0x200-2fF")
+	if err != nil || from != 0x200 || to != 0x2FF {
+		t.Errorf("Range was not parsed correctly. Got %X-%X, %v", from, to, err)
+	}
+
+	from, to, err = ParsePCRange("300-")
+	if err != nil || from != 0x300 || to != 0 {
+		t.Errorf("Open range was not parsed correctly. Got %X-%X, %v", from, to, err)
+	}
+
+	for _, value := range []string{"", "200", "-300", "300-200", "zz-300", "10000-"} {
+		if _, _, err := ParsePCRange(value); err == nil {
+			t.Errorf("Expected an error parsing %q", value)
+		}
+	}
+}
+
+func TestParseCycleRange(t *testing.T) {
+	from, to, err := ParseCycleRange("1000-2000")
+	if err != nil || from != 1000 || to != 2000 {
+		t.Errorf("Range was not parsed correctly. Got %d-%d, %v", from, to, err)
+	}
+
+	if _, _, err := ParseCycleRange("1f-20"); err == nil {
+		t.Errorf("Expected an error for a hex cycle count")
+	}
+}
+
+func TestParseFormat(t *testing.T) {
+	for _, name := range FormatNames() {
+		format, err := ParseFormat(name)
+		if err != nil || format.String() != name {
+			t.Errorf("Format %s was not parsed correctly", name)
+		}
+	}
+
+	if _, err := ParseFormat("xml"); err == nil {
+		t.Errorf("Expected an error for an unknown format")
+	}
+}
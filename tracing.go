@@ -0,0 +1,119 @@
+package main
+
+import (
+	"chip8/chip8"
+	"chip8/symbols"
+	"chip8/trace"
+	"flag"
+	"io"
+	"os"
+	"strings"
+)
+
+// traceFlags are the flags for writing an execution trace
+type traceFlags struct {
+	path   *string
+	format *string
+	pc     *string
+	cycles *string
+}
+
+func addTraceFlags(fs *flag.FlagSet) traceFlags {
+	return traceFlags{
+		path:   fs.String("trace", "", "write every executed instruction to this file (- for stdout)"),
+		format: fs.String("trace-format", "text", "trace format ("+strings.Join(trace.FormatNames(), ", ")+")"),
+		pc:     fs.String("trace-pc", "", "only trace instructions in this hex address range, e.g. 200-2FF"),
+		cycles: fs.String("trace-cycles", "", "only trace this window of cycles, e.g. 1000-2000"),
+	}
+}
+
+// traceOutput is an open trace and the file it is being written to
+type traceOutput struct {
+	writer *trace.Writer
+	file   io.Closer
+}
+
+// open validates the flags and opens the trace, naming PCs from the symbol table, or returns nil if tracing wasn't asked for
+func (tf traceFlags) open(table *symbols.Table) (*traceOutput, error) {
+	if *tf.path == "" {
+		return nil, nil
+	}
+
+	format, err := trace.ParseFormat(*tf.format)
+	if err != nil {
+		return nil, err
+	}
+
+	filter := trace.Filter{}
+	if *tf.pc != "" {
+		if filter.PCFrom, filter.PCTo, err = trace.ParsePCRange(*tf.pc); err != nil {
+			return nil, err
+		}
+	}
+	if *tf.cycles != "" {
+		if filter.CycleFrom, filter.CycleTo, err = trace.ParseCycleRange(*tf.cycles); err != nil {
+			return nil, err
+		}
+	}
+
+	if *tf.path == "-" {
+		return newTraceOutput(os.Stdout, nil, format, filter, table), nil
+	}
+
+	file, err := os.Create(*tf.path)
+	if err != nil {
+		return nil, err
+	}
+
+	return newTraceOutput(file, file, format, filter, table), nil
+}
+
+func newTraceOutput(w io.Writer, file io.Closer, format trace.Format, filter trace.Filter, table *symbols.Table) *traceOutput {
+	writer := trace.New(w, format, filter)
+	writer.SetSymbols(table)
+
+	return &traceOutput{writer: writer, file: file}
+}
+
+// attachInstruments sends the CPU's instructions to the trace, profiler and coverage, and its memory accesses to the memory watch, if
+// any of them are running
+func attachInstruments(computer *chip8.Chip8, opts runOptions) {
+	if opts.memory != nil {
+		computer.SetMemoryWatcher(opts.memory.watch)
+	}
+
+	tracers := []chip8.Tracer{}
+	if opts.trace != nil {
+		tracers = append(tracers, opts.trace.writer)
+	}
+	if opts.profile != nil {
+		tracers = append(tracers, opts.profile.profiler)
+	}
+	if opts.coverage != nil {
+		tracers = append(tracers, opts.coverage.coverage)
+	}
+
+	switch len(tracers) {
+	case 0:
+	case 1:
+		computer.SetTracer(tracers[0])
+	default:
+		computer.SetTracer(chip8.MultiTracer(tracers...))
+	}
+}
+
+// close flushes the trace and closes the file. Safe to call on a nil trace.
+func (to *traceOutput) close() error {
+	if to == nil {
+		return nil
+	}
+
+	err := to.writer.Flush()
+	if to.file != nil {
+		if closeErr := to.file.Close(); err == nil {
+			err = closeErr
+		}
+	}
+
+	return err
+}